@@ -0,0 +1,146 @@
+package vsrpc
+
+import (
+	"context"
+)
+
+// WithBidirectional allows calls in both directions on each Conn.
+//
+// A Client with this option sends a HELLO frame when it connects, and if the
+// Server also has this option, the Server may then Begin calls that are
+// handled by the Client (see WithHandler).  This lets a server reach a client
+// that it could not dial, e.g. one behind NAT.  Calls begun by the Client have
+// odd IDs, and calls begun by the Server have even IDs.
+//
+// Both peers must opt in: a Server without this option refuses the request,
+// and a Server that predates it closes the connection.
+func WithBidirectional(enabled bool) Option {
+	return &withBidirectional{enabled: enabled}
+}
+
+type withBidirectional struct {
+	enabled bool
+}
+
+func (opt *withBidirectional) applyToClient(c *Client) {}
+
+func (opt *withBidirectional) applyToServer(s *Server) {}
+
+func (opt *withBidirectional) applyToConn(conn *Conn) {
+	if opt == nil || conn == nil {
+		return
+	}
+	conn.bidiOption = opt.enabled
+}
+
+func (opt *withBidirectional) applyToCall(call *Call) {}
+
+var _ Option = (*withBidirectional)(nil)
+
+// WithHandler sets the Handler for calls begun by the peer of each Conn.  For
+// a Server, it replaces the Handler given to NewServer; for a Client, it
+// handles the calls begun by the Server on a bidirectional Conn.
+func WithHandler(h Handler) Option {
+	if h == nil {
+		return (*withHandler)(nil)
+	}
+	return &withHandler{h: h}
+}
+
+type withHandler struct {
+	h Handler
+}
+
+func (opt *withHandler) applyToClient(c *Client) {}
+
+func (opt *withHandler) applyToServer(s *Server) {}
+
+func (opt *withHandler) applyToConn(conn *Conn) {
+	if opt == nil || conn == nil {
+		return
+	}
+	conn.handler = opt.h
+}
+
+func (opt *withHandler) applyToCall(call *Call) {}
+
+var _ Option = (*withHandler)(nil)
+
+// Bidirectional reports whether both peers of the Conn have agreed to allow
+// calls in both directions.  For a Client, this becomes true once the reply to
+// its HELLO frame has arrived.
+func (conn *Conn) Bidirectional() bool {
+	if conn == nil {
+		return false
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.bidi
+}
+
+// Handler returns the Handler for calls begun by the peer of the Conn.
+func (conn *Conn) Handler() Handler {
+	if conn == nil {
+		return nil
+	}
+	return conn.handler
+}
+
+// gotHello handles a HELLO frame.  HELLO must be the first frame received,
+// since accepting it later could change which call IDs the peer may use after
+// the peer has begun calls with them.
+func (conn *Conn) gotHello(ctx context.Context, bidirectional bool, first bool) error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if !first || conn.hello || (conn.role == ClientRole && !conn.bidiOption) {
+		return ProtocolViolationError{Err: FrameTypeError{Type: Frame_HELLO}}
+	}
+	conn.hello = true
+
+	if conn.role == ClientRole {
+		conn.bidi = bidirectional
+		return nil
+	}
+
+	conn.bidi = bidirectional && conn.bidiOption
+	accepted := conn.bidi
+	_, _ = conn.writer.queue(ctx, nil, false, func(w PacketWriter) error {
+		return WriteHello(ctx, w, accepted)
+	})
+	return nil
+}
+
+// lockedNextID picks an unused call ID.  On a bidirectional Conn, the Client
+// picks odd IDs and the Server picks even IDs, so that they never collide.
+func (conn *Conn) lockedNextID() ID {
+	first, step := ID(1), ID(1)
+	if conn.bidiOption {
+		step = 2
+		if conn.role == ServerRole {
+			first = 2
+		}
+	}
+
+	id := conn.id
+	if numCalls := uint(len(conn.calls)); id == 0 || numCalls == 0 || uint(id) > (2*numCalls*uint(step)) {
+		id = first
+	}
+	_, found := conn.calls[id]
+	for found {
+		id += step
+		_, found = conn.calls[id]
+	}
+	conn.id = id
+	return id
+}
+
+// lockedPeerID reports whether the peer of the Conn may begin a call with
+// the given ID.
+func (conn *Conn) lockedPeerID(id ID) bool {
+	if !conn.bidi {
+		return conn.role == ServerRole
+	}
+	return (id%2 == 1) == (conn.role == ServerRole)
+}
@@ -0,0 +1,219 @@
+package vsrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// newBidiPair starts a Server whose "Register" method hands back the Conn of
+// each Client that calls it, and dials it with a Client that answers "Ping".
+func newBidiPair(t *testing.T, serverOptions, clientOptions []Option) (*Conn, *Conn) {
+	t.Helper()
+
+	registered := make(chan *Conn, 1)
+	controller := HandlerFunc(func(call *Call) error {
+		registered <- call.Conn()
+		return nil
+	})
+	agent := HandlerFunc(func(call *Call) error {
+		if call.Method() != "Ping" {
+			return NoSuchMethodError{Method: call.Method()}
+		}
+		payload, ok, _, err := call.Queue().RecvContext(call.Context())
+		if err != nil || !ok {
+			return err
+		}
+		return call.Send(payload)
+	})
+
+	ctx := context.Background()
+	pd := &PipeDialer{}
+	pl, err := pd.ListenPacket(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(pl, controller, serverOptions...)
+	t.Cleanup(func() { _ = s.Close() })
+	c := NewClient(pd, append(clientOptions, WithHandler(agent))...)
+	t.Cleanup(func() { _ = c.Close() })
+
+	conn, err := c.Dial(ctx, pl.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := conn.Begin(ctx, "Register")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := call.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if status := call.Wait(); status.Code != Status_OK {
+		t.Fatalf("Register: %v", status)
+	}
+	if id := call.ID(); clientOptions != nil && id%2 != 1 {
+		t.Errorf("expected an odd ID for a call begun by the client, got %d", id)
+	}
+	return conn, <-registered
+}
+
+func TestBidirectional(t *testing.T) {
+	bidi := []Option{WithBidirectional(true)}
+	clientConn, serverConn := newBidiPair(t, bidi, bidi)
+
+	if !clientConn.Bidirectional() || !serverConn.Bidirectional() {
+		t.Fatalf("expected both Conns to be bidirectional, got client=%v server=%v", clientConn.Bidirectional(), serverConn.Bidirectional())
+	}
+
+	for i := 0; i < 3; i++ {
+		call, err := serverConn.Begin(context.Background(), "Ping")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id := call.ID(); id%2 != 0 {
+			t.Errorf("expected an even ID for a call begun by the server, got %d", id)
+		}
+		if role := call.Role(); role != ClientRole {
+			t.Errorf("expected %v, got %v", ClientRole, role)
+		}
+		if err := call.Send(&anypb.Any{TypeUrl: "ping"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := call.CloseSend(); err != nil {
+			t.Fatal(err)
+		}
+		payload, ok, _, err := call.Queue().RecvContext(context.Background())
+		if err != nil || !ok || payload.GetTypeUrl() != "ping" {
+			t.Fatalf("expected ping, got %v ok=%v err=%v", payload, ok, err)
+		}
+		if status := call.Wait(); status.Code != Status_OK {
+			t.Errorf("Ping: %v", status)
+		}
+	}
+
+	call, err := serverConn.Begin(context.Background(), "Missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := call.Wait(); status.Code != Status_UNIMPLEMENTED {
+		t.Errorf("expected %v, got %v", Status_UNIMPLEMENTED, status.Code)
+	}
+}
+
+func TestBidirectional_Refused(t *testing.T) {
+	type testCase struct {
+		Name          string
+		ServerOptions []Option
+		ClientOptions []Option
+	}
+
+	testCases := []testCase{
+		{Name: "neither"},
+		{Name: "client-only", ClientOptions: []Option{WithBidirectional(true)}},
+		{Name: "server-only", ServerOptions: []Option{WithBidirectional(true)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			clientConn, serverConn := newBidiPair(t, tc.ServerOptions, tc.ClientOptions)
+
+			if clientConn.Bidirectional() || serverConn.Bidirectional() {
+				t.Errorf("expected neither Conn to be bidirectional, got client=%v server=%v", clientConn.Bidirectional(), serverConn.Bidirectional())
+			}
+			_, err := serverConn.Begin(context.Background(), "Ping")
+			if _, ok := err.(InappropriateError); !ok {
+				t.Errorf("expected InappropriateError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBidirectional_LateHello(t *testing.T) {
+	ctx := context.Background()
+	readErrors := make(chan error, 1)
+	observe := WithObserver(&FuncObserver{ReadError: func(conn *Conn, err error) { readErrors <- err }})
+
+	pd := &PipeDialer{}
+	pl, err := pd.ListenPacket(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(pl, HandlerFunc(func(call *Call) error {
+		<-call.Queue().Ready()
+		return nil
+	}), WithBidirectional(true), observe)
+	defer s.Close()
+
+	pc, err := pd.DialPacket(ctx, pl.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	// A HELLO after BEGIN would change the IDs that the client may use
+	// after it has already used one, so the server refuses it.
+	if err := WriteBegin(ctx, pc, 2, "Test", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteHello(ctx, pc, true); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-readErrors:
+		var perr ProtocolViolationError
+		if !errors.As(err, &perr) {
+			t.Errorf("expected ProtocolViolationError, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the server to refuse the late HELLO")
+	}
+
+	for {
+		var frame Frame
+		if err := ReadFrame(ctx, pc, &frame); err != nil {
+			break
+		}
+		if frame.Type == Frame_HELLO {
+			t.Errorf("expected no reply to the late HELLO, got %v", &frame)
+		}
+	}
+}
+
+func TestConn_lockedNextID(t *testing.T) {
+	type testCase struct {
+		Name   string
+		Role   Role
+		Bidi   bool
+		Calls  []ID
+		Last   ID
+		Expect ID
+	}
+
+	testCases := []testCase{
+		{Name: "client-first", Role: ClientRole, Expect: 1},
+		{Name: "client-next", Role: ClientRole, Calls: []ID{1, 2}, Last: 2, Expect: 3},
+		{Name: "bidi-client-first", Role: ClientRole, Bidi: true, Expect: 1},
+		{Name: "bidi-client-next", Role: ClientRole, Bidi: true, Calls: []ID{1, 2}, Last: 1, Expect: 3},
+		{Name: "bidi-server-first", Role: ServerRole, Bidi: true, Expect: 2},
+		{Name: "bidi-server-next", Role: ServerRole, Bidi: true, Calls: []ID{1, 2, 3}, Last: 2, Expect: 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			conn := newConn(tc.Role, nil, nil, &recordingConn{}, []Option{WithBidirectional(tc.Bidi)})
+			conn.calls = make(map[ID]*Call)
+			for _, id := range tc.Calls {
+				conn.calls[id] = &Call{id: id}
+			}
+			conn.id = tc.Last
+			if id := conn.lockedNextID(); id != tc.Expect {
+				t.Errorf("expected %d, got %d", tc.Expect, id)
+			}
+		})
+	}
+}
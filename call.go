@@ -6,26 +6,40 @@ import (
 	"time"
 
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Call struct {
-	options   []Option
-	observers []Observer
-	ctxOuter  context.Context
-	ctxInner  context.Context
-	cancel    context.CancelFunc
-	deadline  time.Time
-	method    Method
-	conn      *Conn
-	queue     *Queue
-	id        ID
-	role      Role
-
-	mu     sync.Mutex
-	cv     *sync.Cond
-	status *Status
-	state  state
+	options     []Option
+	observers   []Observer
+	clock       Clock
+	authorizer  Authorizer
+	ctxOuter    context.Context
+	ctxInner    context.Context
+	cancel      context.CancelFunc
+	deadline    time.Time
+	method      Method
+	conn        *Conn
+	queue       *Queue
+	queueConfig QueueConfig
+	priority    uint32
+	timeout     time.Duration
+	maxDuration time.Duration
+	propagation *PropagationConfig
+	parent      *Call
+	start       time.Time
+	id          ID
+	role        Role
+
+	mu           sync.Mutex
+	cv           *sync.Cond
+	policy       *MethodPolicy
+	status       *Status
+	overflow     *Status
+	children     map[*Call]void
+	stopChildren func() bool
+	state        state
 }
 
 func newCall(
@@ -35,6 +49,8 @@ func newCall(
 	id ID,
 	method Method,
 	deadline *timestamppb.Timestamp,
+	timeout *durationpb.Duration,
+	priority uint32,
 	options []Option,
 ) *Call {
 	call := &Call{
@@ -45,16 +61,47 @@ func newCall(
 		role:    role,
 	}
 	call.cv = sync.NewCond(&call.mu)
-	call.queue = NewQueue()
 
 	for _, opt := range options {
 		opt.applyToCall(call)
 	}
 
-	if deadline != nil {
-		t := deadline.AsTime()
-		if call.deadline.IsZero() || t.Before(call.deadline) {
-			call.deadline = t
+	call.queue = NewQueueWithConfig(call.queueConfig)
+
+	if priority != 0 {
+		call.priority = priority
+	}
+
+	now := call.Clock().Now()
+	call.start = now
+	if call.timeout != 0 {
+		call.shortenDeadline(now.Add(call.timeout))
+	}
+
+	if d := call.policy.GetTimeout(); d != nil {
+		call.shortenDeadline(now.Add(d.AsDuration()))
+	}
+
+	// The relative timeout is immune to clock skew between the hosts, so it
+	// wins over the absolute deadline when the peer sends both.
+	if timeout != nil {
+		call.shortenDeadline(now.Add(timeout.AsDuration()))
+	} else if deadline != nil {
+		call.shortenDeadline(deadline.AsTime())
+	}
+
+	if t, ok := ctx.Deadline(); ok {
+		call.shortenDeadline(t)
+	}
+
+	if role == ServerRole && call.maxDuration > 0 {
+		call.shortenDeadline(now.Add(call.maxDuration))
+	}
+
+	if parent := ContextCall(ctx); role == ClientRole && parent.Role() == ServerRole {
+		call.parent = parent
+		if t, ok := parent.Deadline(); ok && call.propagation != nil {
+			call.shortenDeadline(t.Add(-call.propagation.Margin))
 		}
 	}
 
@@ -63,7 +110,7 @@ func newCall(
 
 	var cancel context.CancelFunc = func() {}
 	if !call.deadline.IsZero() {
-		ctx, cancel = context.WithDeadline(ctx, call.deadline)
+		ctx, cancel = WithContextDeadline(ctx, call.clock, call.deadline)
 	}
 
 	call.ctxInner = ctx
@@ -92,10 +139,20 @@ func (call *Call) Queue() *Queue {
 	return call.queue
 }
 
+func (call *Call) Clock() Clock {
+	if call == nil {
+		return SystemClock{}
+	}
+	return clockOrDefault(call.clock)
+}
+
 func (call *Call) Context() context.Context {
 	if call == nil {
 		return context.Background()
 	}
+
+	call.mu.Lock()
+	defer call.mu.Unlock()
 	return call.ctxInner
 }
 
@@ -118,42 +175,52 @@ func (call *Call) Send(payload *anypb.Any) error {
 		return ErrCallClosed
 	}
 
-	call.mu.Lock()
-	defer call.mu.Unlock()
-
-	if call.state >= stateClosed {
-		return ErrCallClosed
+	pw, err := call.queueSend(payload)
+	if err != nil {
+		return err
 	}
-	if call.state >= stateShuttingDown {
-		return ErrHalfClosed
+	if err := call.conn.writer.wait(pw); err != nil {
+		return err
 	}
 
-	conn := call.conn
-	conn.mu.Lock()
-	defer conn.mu.Unlock()
-
-	if conn.state >= stateClosed {
-		return call.lockedAbort(ErrConnClosed)
-	}
 	switch call.role {
 	case ClientRole:
-		if err := WriteRequest(call.ctxOuter, conn.pc, call.id, payload); err != nil {
-			return conn.lockedGotWriteError(err)
-		}
 		onRequest(call.observers, call, payload)
-
 	case ServerRole:
-		if err := WriteResponse(call.ctxOuter, conn.pc, call.id, payload); err != nil {
-			return conn.lockedGotWriteError(err)
-		}
 		onResponse(call.observers, call, payload)
-
-	default:
-		panic("unreachable")
 	}
 	return nil
 }
 
+func (call *Call) queueSend(payload *anypb.Any) (*pendingWrite, error) {
+	call.mu.Lock()
+	defer call.mu.Unlock()
+
+	if call.state >= stateClosed {
+		return nil, ErrCallClosed
+	}
+	if call.state >= stateShuttingDown && call.role == ClientRole {
+		return nil, ErrHalfClosed
+	}
+	if err := call.lockedCheckSize(payload, true); err != nil {
+		return nil, err
+	}
+
+	return call.lockedQueue(call.ctxOuter, func(w PacketWriter) error {
+		switch call.role {
+		case ClientRole:
+			return WriteRequest(call.ctxOuter, w, call.id, payload)
+		case ServerRole:
+			return WriteResponse(call.ctxOuter, w, call.id, payload)
+		default:
+			panic("unreachable")
+		}
+	})
+}
+
+// CloseSend tells the server that the client has no more requests to send.
+// It is a no-op if the server has already ended the call, which it may do as
+// soon as it has read what it needs.
 func (call *Call) CloseSend() error {
 	if call == nil {
 		return ErrCallClosed
@@ -162,26 +229,19 @@ func (call *Call) CloseSend() error {
 	call.mu.Lock()
 	defer call.mu.Unlock()
 
-	if call.state >= stateClosed {
-		return ErrCallClosed
-	}
 	if call.state >= stateShuttingDown || call.role != ClientRole {
 		return nil
 	}
 
-	conn := call.conn
-	conn.mu.Lock()
-	defer conn.mu.Unlock()
-
-	if conn.state >= stateClosed {
-		return call.lockedAbort(ErrConnClosed)
-	}
-	if err := WriteHalfClose(call.ctxOuter, conn.pc, call.id); err != nil {
-		return conn.lockedGotWriteError(err)
+	pw, err := call.lockedQueue(call.ctxOuter, func(w PacketWriter) error {
+		return WriteHalfClose(call.ctxOuter, w, call.id)
+	})
+	if err != nil {
+		return err
 	}
 	call.state = stateShuttingDown
 	onHalfClose(call.observers, call)
-	return nil
+	return call.unlockedWait(pw)
 }
 
 func (call *Call) Cancel() error {
@@ -202,20 +262,20 @@ func (call *Call) Cancel() error {
 		return nil
 	}
 
-	conn := call.conn
-	conn.mu.Lock()
-	defer conn.mu.Unlock()
-
-	if conn.state >= stateClosed {
-		return call.lockedAbort(ErrConnClosed)
-	}
-	if err := WriteCancel(call.ctxOuter, conn.pc, call.id); err != nil {
-		return conn.lockedGotWriteError(err)
+	// The CANCEL frame is sent even if the context of the call is done,
+	// which is often the reason for cancelling it.
+	ctx := context.WithoutCancel(call.ctxOuter)
+	pw, err := call.lockedQueue(ctx, func(w PacketWriter) error {
+		return WriteCancel(ctx, w, call.id)
+	})
+	if err != nil {
+		return err
 	}
+	call.queue.Done()
 	call.state = stateGoingAway
 	call.cancel()
 	onCancel(call.observers, call)
-	return nil
+	return call.unlockedWait(pw)
 }
 
 func (call *Call) End(status *Status) error {
@@ -233,24 +293,37 @@ func (call *Call) End(status *Status) error {
 		return ErrCallClosed
 	}
 
-	conn := call.conn
-	conn.mu.Lock()
-	defer conn.mu.Unlock()
-
-	if conn.state >= stateClosed {
-		return call.lockedAbort(ErrConnClosed)
-	}
-
 	if status == nil {
 		status = &Status{Code: Status_OK}
 	}
 
-	if err := WriteEnd(call.ctxOuter, conn.pc, call.id, status); err != nil {
-		return conn.lockedGotWriteError(err)
+	pw, err := call.lockedQueue(call.ctxOuter, func(w PacketWriter) error {
+		return WriteEnd(call.ctxOuter, w, call.id, status)
+	})
+	if err != nil {
+		return err
 	}
-
 	call.lockedEnd(status)
-	return nil
+	return call.unlockedWait(pw)
+}
+
+// lockedQueue queues a frame for the writer thread of the Conn.  Queueing
+// under call.mu keeps the frames of a call in the order of its state changes,
+// while the write itself happens without any lock held.
+func (call *Call) lockedQueue(ctx context.Context, fn func(PacketWriter) error) (*pendingWrite, error) {
+	pw, err := call.conn.writer.queue(ctx, call, true, fn)
+	if err == ErrConnClosed {
+		return nil, call.lockedAbort(err)
+	}
+	return pw, err
+}
+
+// unlockedWait waits for a queued frame to be written, releasing call.mu in
+// the meantime.
+func (call *Call) unlockedWait(pw *pendingWrite) error {
+	call.mu.Unlock()
+	defer call.mu.Lock()
+	return call.conn.writer.wait(pw)
 }
 
 func (call *Call) Wait() *Status {
@@ -273,7 +346,7 @@ func (call *Call) Close() error {
 		return nil
 	}
 	if call.role == ClientRole {
-		if err := call.Cancel(); err != nil {
+		if err := call.Cancel(); err != nil && err != ErrCallClosed {
 			return err
 		}
 	}
@@ -286,14 +359,15 @@ func (call *Call) gotRequest(payload *anypb.Any) error {
 	}
 
 	call.mu.Lock()
-	defer call.mu.Unlock()
+	closed := call.state >= stateShuttingDown
+	call.mu.Unlock()
 
-	if call.state >= stateShuttingDown {
+	if closed {
 		return nil
 	}
-
-	call.queue.Push(payload)
-	onRequest(call.observers, call, payload)
+	if call.gotPayload(payload) {
+		onRequest(call.observers, call, payload)
+	}
 	return nil
 }
 
@@ -303,17 +377,45 @@ func (call *Call) gotResponse(payload *anypb.Any) error {
 	}
 
 	call.mu.Lock()
-	defer call.mu.Unlock()
+	closed := call.state >= stateClosed
+	call.mu.Unlock()
 
-	if call.state >= stateClosed {
+	if closed {
 		return nil
 	}
-
-	call.queue.Push(payload)
-	onResponse(call.observers, call, payload)
+	if call.gotPayload(payload) {
+		onResponse(call.observers, call, payload)
+	}
 	return nil
 }
 
+// gotPayload queues a payload from the read thread.  It must not hold call.mu,
+// because Push may wait for the reader under the QueueBlock policy, and the
+// reader may need call.mu to end the call.
+func (call *Call) gotPayload(payload *anypb.Any) bool {
+	err := call.queue.push(payload, false)
+	if x, ok := err.(QueueFullError); ok && call.queueConfig.Overflow == QueueClose {
+		call.gotOverflow(x)
+	}
+	return err == nil
+}
+
+// gotOverflow ends a call whose Queue overflowed under the QueueClose policy.
+// A client cancels the call, but reports RESOURCE_EXHAUSTED rather than
+// whatever the server ends it with.
+func (call *Call) gotOverflow(err QueueFullError) {
+	status := StatusFromError(err)
+	if call.role == ServerRole {
+		_ = call.End(status)
+		return
+	}
+
+	call.mu.Lock()
+	call.overflow = status
+	call.mu.Unlock()
+	_ = call.Cancel()
+}
+
 func (call *Call) gotHalfClose() error {
 	if call == nil || call.role != ServerRole {
 		return nil
@@ -347,6 +449,7 @@ func (call *Call) gotCancel() error {
 	call.queue.Done()
 	call.state = stateGoingAway
 	call.cancel()
+	call.lockedCancelChildren()
 	onCancel(call.observers, call)
 	return nil
 }
@@ -362,11 +465,20 @@ func (call *Call) gotEnd(status *Status) error {
 	if call.state >= stateClosed {
 		return ProtocolViolationError{Err: ErrCallClosed}
 	}
+	if call.overflow != nil {
+		status = call.overflow
+	}
 
 	call.lockedEnd(status)
 	return nil
 }
 
+func (call *Call) gotConnClose() {
+	call.mu.Lock()
+	defer call.mu.Unlock()
+	call.lockedEnd(Abort(ErrConnClosed))
+}
+
 func (call *Call) lockedAbort(err error) error {
 	if call.state < stateClosed {
 		call.lockedEnd(Abort(err))
@@ -388,6 +500,13 @@ func (call *Call) lockedEnd(status *Status) {
 	call.status = status
 	call.cv.Broadcast()
 	call.cancel()
+	call.lockedCancelChildren()
 	onEnd(call.observers, call, status)
+	if !call.deadline.IsZero() {
+		onBudget(call.observers, call, call.lockedBudget())
+	}
+	if call.parent != nil {
+		go call.parent.forgetChild(call)
+	}
 	go call.conn.forgetCall(call)
 }
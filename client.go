@@ -12,6 +12,7 @@ import (
 type Client struct {
 	options   []Option
 	observers []Observer
+	clock     Clock
 	pd        PacketDialer
 
 	mu       sync.Mutex
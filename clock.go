@@ -0,0 +1,204 @@
+package vsrpc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, fn func()) Timer
+}
+
+type Timer interface {
+	Stop() bool
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) AfterFunc(d time.Duration, fn func()) Timer {
+	return time.AfterFunc(d, fn)
+}
+
+var _ Clock = SystemClock{}
+
+func clockOrDefault(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock{}
+	}
+	return clock
+}
+
+func isSystemClock(clock Clock) bool {
+	switch clock.(type) {
+	case nil:
+		return true
+	case SystemClock:
+		return true
+	case *SystemClock:
+		return true
+	default:
+		return false
+	}
+}
+
+func WithClock(clock Clock) Option {
+	if clock == nil {
+		return (*withClock)(nil)
+	}
+	return &withClock{clock: clock}
+}
+
+type withClock struct {
+	clock Clock
+}
+
+func (opt *withClock) applyToClient(c *Client) {
+	if opt == nil || c == nil {
+		return
+	}
+	c.clock = opt.clock
+}
+
+func (opt *withClock) applyToServer(s *Server) {
+	if opt == nil || s == nil {
+		return
+	}
+	s.clock = opt.clock
+}
+
+func (opt *withClock) applyToConn(conn *Conn) {
+	if opt == nil || conn == nil {
+		return
+	}
+	conn.clock = opt.clock
+}
+
+func (opt *withClock) applyToCall(call *Call) {
+	if opt == nil || call == nil {
+		return
+	}
+	call.clock = opt.clock
+}
+
+var _ Option = (*withClock)(nil)
+
+// WithContextDeadline is like context.WithDeadline, except that the deadline
+// is measured by the given Clock instead of by the system clock.
+func WithContextDeadline(ctx context.Context, clock Clock, deadline time.Time) (context.Context, context.CancelFunc) {
+	if isSystemClock(clock) {
+		return context.WithDeadline(ctx, deadline)
+	}
+
+	if t, ok := ctx.Deadline(); ok && !deadline.Before(t) {
+		return context.WithCancel(ctx)
+	}
+
+	c := &clockContext{
+		parent:   ctx,
+		deadline: deadline,
+		done:     make(chan struct{}),
+	}
+
+	if err := ctx.Err(); err != nil {
+		c.cancel(err)
+		return c, func() {}
+	}
+
+	d := deadline.Sub(clock.Now())
+	if d <= 0 {
+		c.cancel(context.DeadlineExceeded)
+		return c, func() {}
+	}
+
+	c.mu.Lock()
+	c.timer = clock.AfterFunc(d, func() { c.cancel(context.DeadlineExceeded) })
+	c.mu.Unlock()
+
+	if parentDone := ctx.Done(); parentDone != nil {
+		go func() {
+			select {
+			case <-parentDone:
+				c.cancel(ctx.Err())
+			case <-c.done:
+			}
+		}()
+	}
+
+	return c, func() { c.cancel(context.Canceled) }
+}
+
+// WithContextTimeout is like context.WithTimeout, except that the timeout is
+// measured by the given Clock instead of by the system clock.
+func WithContextTimeout(ctx context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	clock = clockOrDefault(clock)
+	return WithContextDeadline(ctx, clock, clock.Now().Add(timeout))
+}
+
+type clockContext struct {
+	parent   context.Context
+	deadline time.Time
+	done     chan struct{}
+
+	mu    sync.Mutex
+	err   error
+	timer Timer
+}
+
+func (c *clockContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *clockContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *clockContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *clockContext) Value(key any) any {
+	return c.parent.Value(key)
+}
+
+func (c *clockContext) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+
+	c.err = err
+	close(c.done)
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+}
+
+var _ context.Context = (*clockContext)(nil)
+
+func sleepContext(ctx context.Context, clock Clock, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	ch := make(chan void)
+	t := clockOrDefault(clock).AfterFunc(d, func() { close(ch) })
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-ch:
+		return nil
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"google.golang.org/protobuf/compiler/protogen"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/chronos-tachyon/vsrpc"
 )
 
 const (
@@ -38,7 +40,10 @@ type Generator struct {
 	Plugin    *protogen.Plugin
 	File      *protogen.File
 	Out       *protogen.GeneratedFile
+	Params    Params
 	MethodMap map[*protogen.Method]MethodProperties
+	Messages  map[protoreflect.FullName]*protogen.Message
+	Enums     map[protoreflect.FullName]*protogen.Enum
 }
 
 func (g *Generator) Error(err error) {
@@ -64,13 +69,44 @@ func (g *Generator) GenerateFile() {
 
 	for _, service := range g.File.Services {
 		for _, method := range service.Methods {
+			policy, err := MethodPolicyOf(method)
+			if err != nil {
+				g.Error(fmt.Errorf("method %s: failed to read (vsrpc.policy): %w", method.Desc.FullName(), err))
+			}
+
 			var mp MethodProperties
-			mp.Set(service, method)
+			mp.Set(g.ServiceName(service), service, method, policy)
 			g.MethodMap[method] = mp
 		}
 	}
 
-	fileName := g.File.GeneratedFilenamePrefix + "_vsrpc.pb.go"
+	g.GenerateHeader(g.File.GeneratedFilenamePrefix + "_vsrpc.pb.go")
+	for _, service := range g.File.Services {
+		g.GenerateCommon(service)
+		if g.Params.Client {
+			g.GenerateClientInterface(service)
+			g.GenerateClientImpl(service)
+		}
+		if g.Params.Server {
+			g.GenerateServerInterface(service)
+			g.GenerateUnimplementedServer(service)
+			g.GenerateHandlerImpl(service)
+			g.GenerateRegister(service)
+		}
+	}
+
+	if g.Params.Mocks {
+		g.GenerateHeader(g.File.GeneratedFilenamePrefix + "_vsrpc_mock.pb.go")
+		for _, service := range g.File.Services {
+			g.GenerateMockClient(service)
+			if g.Params.Server {
+				g.GenerateLoopback(service)
+			}
+		}
+	}
+}
+
+func (g *Generator) GenerateHeader(fileName string) {
 	g.Out = g.Plugin.NewGeneratedFile(fileName, g.File.GoImportPath)
 	g.GenerateLeadingComments(g.File.Desc.SourceLocations().ByPath(protoreflect.SourcePath{FileDescriptorProto_Package_FieldNumber}))
 	g.P("// Code generated by protoc-gen-go-vsrpc. DO NOT EDIT.")
@@ -85,14 +121,6 @@ func (g *Generator) GenerateFile() {
 	g.P()
 	g.GenerateLeadingComments(g.File.Desc.SourceLocations().ByPath(protoreflect.SourcePath{FileDescriptorProto_Syntax_FieldNumber}))
 	g.P("package ", g.File.GoPackageName)
-
-	for _, service := range g.File.Services {
-		g.GenerateCommon(service)
-		g.GenerateClientInterface(service)
-		g.GenerateClientImpl(service)
-		g.GenerateServerInterface(service)
-		g.GenerateHandlerImpl(service)
-	}
 }
 
 func (g *Generator) PluginVersion() string {
@@ -130,26 +158,96 @@ func (g *Generator) GenerateLeadingComments(loc protoreflect.SourceLocation) {
 	}
 }
 
+// GenerateServiceComments copies the service's own comments and deprecation
+// status onto the doc comment of a generated type.
+func (g *Generator) GenerateServiceComments(service *protogen.Service) {
+	if s := service.Comments.Leading; s != "" {
+		g.P("//")
+		g.P(strings.TrimSuffix(s.String(), "\n"))
+	}
+	if service.Desc.Options().(*descriptorpb.ServiceOptions).GetDeprecated() {
+		g.P("//")
+		g.P(DeprecationComment)
+	}
+}
+
+// GenerateMethodComments copies the method's own comments and deprecation
+// status onto an interface method.
+func (g *Generator) GenerateMethodComments(method *protogen.Method) {
+	s := method.Comments.Leading
+	if s != "" {
+		g.P(strings.TrimSuffix(s.String(), "\n"))
+	}
+	if method.Desc.Options().(*descriptorpb.MethodOptions).GetDeprecated() {
+		if s != "" {
+			g.P("//")
+		}
+		g.P(DeprecationComment)
+	}
+}
+
 func (g *Generator) GenerateCommon(service *protogen.Service) {
-	if len(service.Methods) <= 0 {
-		return
+	if len(service.Methods) > 0 {
+		g.P()
+		g.P("const (")
+		for _, method := range service.Methods {
+			mp := g.MethodMap[method]
+			g.P("\t", mp.NameSymbol, " ", CorePackage.Ident("Method"), ` = "`, mp.NameValue, `"`)
+		}
+		g.P(")")
 	}
 
+	g.GeneratePolicies(service)
+	g.GenerateServiceDesc(service)
+}
+
+func (g *Generator) ServiceDescName(service *protogen.Service) string {
+	return g.ServiceName(service) + "_ServiceDesc"
+}
+
+func (g *Generator) GenerateServiceDesc(service *protogen.Service) {
+	descName := g.ServiceDescName(service)
+
 	g.P()
-	g.P("const (")
-	for _, method := range service.Methods {
-		mp := g.MethodMap[method]
-		g.P("\t", mp.NameSymbol, " ", CorePackage.Ident("Method"), ` = "`, mp.NameValue, `"`)
+	g.P("// ", descName, " describes the ", service.GoName, " service.")
+	g.P("var ", descName, " = ", CorePackage.Ident("ServiceDesc"), "{")
+	g.P("\tName: \"", service.Desc.FullName(), "\",")
+	if len(service.Methods) > 0 {
+		g.P("\tMethods: []", CorePackage.Ident("MethodDesc"), "{")
+		for _, method := range service.Methods {
+			mp := g.MethodMap[method]
+			g.P("\t\t{")
+			g.P("\t\t\tName: ", mp.NameSymbol, ",")
+			if mp.In.IsPlural {
+				g.P("\t\t\tClientStreaming: true,")
+			}
+			if mp.Out.IsPlural {
+				g.P("\t\t\tServerStreaming: true,")
+			}
+			g.P("\t\t\tRequest: \"", mp.In.Message.Desc.FullName(), "\",")
+			g.P("\t\t\tResponse: \"", mp.Out.Message.Desc.FullName(), "\",")
+			if mp.Policy != nil {
+				g.P("\t\t\tPolicy: ", mp.PolicySymbol, ",")
+			}
+			g.P("\t\t},")
+		}
+		g.P("\t},")
 	}
-	g.P(")")
+	g.P("}")
+}
+
+// ServiceName is the base from which the names of the generated types for a
+// service are derived.
+func (g *Generator) ServiceName(service *protogen.Service) string {
+	return g.Params.TypePrefix + service.GoName
 }
 
 func (g *Generator) ClientInterfaceName(service *protogen.Service) string {
-	return service.GoName + "Client"
+	return g.ServiceName(service) + "Client"
 }
 
 func (g *Generator) ClientImplName(service *protogen.Service) string {
-	return "vsrpcClientImpl_" + service.GoName
+	return "vsrpcClientImpl_" + g.ServiceName(service)
 }
 
 func (g *Generator) GenerateClientInterface(service *protogen.Service) {
@@ -158,22 +256,41 @@ func (g *Generator) GenerateClientInterface(service *protogen.Service) {
 
 	g.P()
 	g.P("// ", interfaceName, " is the client API for ", service.GoName, " service.")
-	if service.Desc.Options().(*descriptorpb.ServiceOptions).GetDeprecated() {
-		g.P("//")
-		g.P(DeprecationComment)
-	}
+	g.GenerateServiceComments(service)
 	g.P("type ", interfaceName, " interface {")
 	for _, method := range service.Methods {
 		mp := g.MethodMap[method]
 
-		v = v[:0]
-		v = append(v, "\t", method.GoName)
-		v = mp.AppendClientSignature(v)
-		g.P(v...)
+		if g.HasCallbackMethod(mp) {
+			g.GenerateMethodComments(method)
+			v = v[:0]
+			v = append(v, "\t", method.GoName)
+			v = mp.AppendClientSignature(v)
+			g.P(v...)
+		}
+		if g.HasOpenMethod(mp) {
+			g.GenerateMethodComments(method)
+			v = v[:0]
+			v = append(v, "\tOpen", method.GoName)
+			v = mp.AppendOpenSignature(v)
+			g.P(v...)
+		}
 	}
 	g.P("}")
 }
 
+// HasCallbackMethod reports whether the client API has a method X, taking a
+// callback for streaming methods.
+func (g *Generator) HasCallbackMethod(mp MethodProperties) bool {
+	return !mp.IsStreaming() || g.Params.CallbackStreams
+}
+
+// HasOpenMethod reports whether the client API has a method OpenX, returning
+// a stream object.
+func (g *Generator) HasOpenMethod(mp MethodProperties) bool {
+	return mp.IsStreaming() && g.Params.ObjectStreams
+}
+
 func (g *Generator) GenerateClientImpl(service *protogen.Service) {
 	interfaceName := g.ClientInterfaceName(service)
 	implName := g.ClientImplName(service)
@@ -194,7 +311,13 @@ func (g *Generator) GenerateClientImpl(service *protogen.Service) {
 	g.P("}")
 
 	for _, method := range service.Methods {
-		g.GenerateClientMethod(service, method, implName)
+		mp := g.MethodMap[method]
+		if g.HasCallbackMethod(mp) {
+			g.GenerateClientMethod(service, method, implName)
+		}
+		if g.HasOpenMethod(mp) {
+			g.GenerateClientOpenMethod(service, method, implName)
+		}
 	}
 
 	g.P()
@@ -217,6 +340,11 @@ func (g *Generator) GenerateClientMethod(service *protogen.Service, method *prot
 		g.P()
 	}
 
+	g.GeneratePolicyOption(mp)
+	if mp.HasRetry() {
+		g.P("\treturn ", CorePackage.Ident("Retry"), "(ctx, client.Conn().Clock(), ", mp.PolicySymbol, ", func() error {")
+	}
+
 	g.P("\tcall, err := client.Conn().Begin(ctx, ", mp.NameSymbol, ", options...)")
 	g.P("\tif err != nil {")
 	g.P("\t\treturn err")
@@ -254,22 +382,89 @@ func (g *Generator) GenerateClientMethod(service *protogen.Service, method *prot
 	}
 
 	if mp.Out.IsSingular {
-		g.P("\t_, _, err = stream.Recv(true, resp)")
+		g.P("\tvar ok bool")
+		g.P("\tok, _, err = stream.Recv(true, resp)")
 		g.P("\tif err != nil {")
 		g.P("\t\treturn err")
 		g.P("\t}")
+		g.P("\terr = call.Wait().AsError()")
+		g.P("\tif err == nil && !ok {")
+		g.P("\t\terr = ", CorePackage.Ident("ErrNoResponse"))
+		g.P("\t}")
+		g.P("\treturn err")
+	} else {
+		g.P("\treturn call.Wait().AsError()")
+	}
+	if mp.HasRetry() {
+		g.P("\t})")
+	}
+	g.P("}")
+}
+
+// GeneratePolicyOption puts the (vsrpc.policy) option of the method, if any,
+// ahead of the options given by the caller.
+func (g *Generator) GeneratePolicyOption(mp MethodProperties) {
+	if mp.Policy == nil {
+		return
+	}
+	g.P("\toptions = ", CorePackage.Ident("ConcatOptions"), "([]", CorePackage.Ident("Option"), "{", CorePackage.Ident("WithMethodPolicy"), "(", mp.PolicySymbol, ")}, options...)")
+	g.P()
+}
+
+func (g *Generator) GenerateClientOpenMethod(service *protogen.Service, method *protogen.Method, implName string) {
+	mp := g.MethodMap[method]
+
+	g.P()
+	v := make([]any, 0, 16)
+	v = append(v, "func (client ", implName, ") Open", method.GoName)
+	v = mp.AppendOpenSignature(v)
+	v = append(v, " {")
+	g.P(v...)
+
+	g.GeneratePolicyOption(mp)
+	g.P("\tcall, err := client.Conn().Begin(ctx, ", mp.NameSymbol, ", options...)")
+	g.P("\tif err != nil {")
+	g.P("\t\treturn nil, err")
+	g.P("\t}")
+	g.P()
+	if mp.In.IsPlural {
+		g.P("\treturn ", CorePackage.Ident("NewClientStream"), "[*", mp.In.GoIdent, ", *", mp.Out.GoIdent, "](call), nil")
+		g.P("}")
+		return
+	}
+
+	g.P("\tstream := ", CorePackage.Ident("NewClientStream"), "[*", mp.In.GoIdent, ", *", mp.Out.GoIdent, "](call)")
+	if mp.In.IsSingular {
+		g.P("\terr = stream.Send(req)")
+		g.P("\tif err != nil {")
+		g.P("\t\t_ = call.Close()")
+		g.P("\t\treturn nil, err")
+		g.P("\t}")
 	}
 
-	g.P("\treturn call.Wait().AsError()")
+	g.P("\terr = stream.CloseSend()")
+	g.P("\tif err != nil {")
+	g.P("\t\t_ = call.Close()")
+	g.P("\t\treturn nil, err")
+	g.P("\t}")
+	g.P("\treturn stream, nil")
 	g.P("}")
 }
 
 func (g *Generator) ServerInterfaceName(service *protogen.Service) string {
-	return service.GoName + "Server"
+	return g.ServiceName(service) + "Server"
+}
+
+func (g *Generator) UnimplementedServerName(service *protogen.Service) string {
+	return "Unimplemented" + g.ServiceName(service) + "Server"
+}
+
+func (g *Generator) MustEmbedMethodName(service *protogen.Service) string {
+	return "mustEmbed" + g.UnimplementedServerName(service)
 }
 
 func (g *Generator) HandlerImplName(service *protogen.Service) string {
-	return "vsrpcHandler_" + service.GoName
+	return "vsrpcHandler_" + g.ServiceName(service)
 }
 
 func (g *Generator) GenerateServerInterface(service *protogen.Service) {
@@ -278,29 +473,64 @@ func (g *Generator) GenerateServerInterface(service *protogen.Service) {
 
 	g.P()
 	g.P("// ", serverName, " is the server API for ", service.GoName, " service.")
-	if service.Desc.Options().(*descriptorpb.ServiceOptions).GetDeprecated() {
-		g.P("//")
-		g.P(DeprecationComment)
-	}
+	g.GenerateServiceComments(service)
 	g.P("type ", serverName, " interface {")
 	for _, method := range service.Methods {
 		mp := g.MethodMap[method]
 
+		g.GenerateMethodComments(method)
 		v = v[:0]
 		v = append(v, "\t")
 		v = append(v, method.GoName)
 		v = mp.AppendServerSignature(v)
 		g.P(v...)
 	}
+	if g.Params.RequireUnimplemented {
+		g.P("\t", g.MustEmbedMethodName(service), "()")
+	}
 	g.P("}")
 }
 
+func (g *Generator) GenerateUnimplementedServer(service *protogen.Service) {
+	v := make([]any, 0, 16)
+	serverName := g.ServerInterfaceName(service)
+	unimplName := g.UnimplementedServerName(service)
+
+	g.P()
+	if g.Params.RequireUnimplemented {
+		g.P("// ", unimplName, " must be embedded by implementations of ", serverName, ".")
+	} else {
+		g.P("// ", unimplName, " should be embedded by implementations of ", serverName, ".")
+	}
+	g.P("// Methods added to ", service.GoName, " in the future will then fail with")
+	g.P("// UNIMPLEMENTED instead of breaking the build.")
+	g.P("type ", unimplName, " struct{}")
+	for _, method := range service.Methods {
+		mp := g.MethodMap[method]
+
+		g.P()
+		v = v[:0]
+		v = append(v, "func (", unimplName, ") ", method.GoName)
+		v = mp.AppendServerSignature(v)
+		v = append(v, " {")
+		g.P(v...)
+		g.P("\treturn ", CorePackage.Ident("NoSuchMethodError"), "{Method: ", mp.NameSymbol, "}")
+		g.P("}")
+	}
+	if g.Params.RequireUnimplemented {
+		g.P()
+		g.P("func (", unimplName, ") ", g.MustEmbedMethodName(service), "() {}")
+	}
+	g.P()
+	g.P("var _ ", serverName, " = ", unimplName, "{}")
+}
+
 func (g *Generator) GenerateHandlerImpl(service *protogen.Service) {
 	serverName := g.ServerInterfaceName(service)
 	handlerName := g.HandlerImplName(service)
 
 	g.P()
-	g.P("func New", service.GoName, "Handler(impl ", serverName, ") ", CorePackage.Ident("Handler"), " {")
+	g.P("func New", g.ServiceName(service), "Handler(impl ", serverName, ") ", CorePackage.Ident("Handler"), " {")
 	g.P("\treturn ", handlerName, "{impl: impl}")
 	g.P("}")
 	g.P()
@@ -309,6 +539,13 @@ func (g *Generator) GenerateHandlerImpl(service *protogen.Service) {
 	g.P("}")
 	g.P()
 	g.P("func (h ", handlerName, ") Handle(call *", CorePackage.Ident("Call"), ") error {")
+	if len(service.Methods) <= 0 {
+		g.P("\treturn ", CorePackage.Ident("NoSuchMethodError"), "{Method: call.Method()}")
+		g.P("}")
+		g.P()
+		g.P("var _ ", CorePackage.Ident("Handler"), " = ", handlerName, "{}")
+		return
+	}
 	g.P("\tctx := call.Context()")
 	g.P("\tmethod := call.Method()")
 	g.P()
@@ -329,12 +566,37 @@ func (g *Generator) GenerateHandlerImpl(service *protogen.Service) {
 	g.P("var _ ", CorePackage.Ident("Handler"), " = ", handlerName, "{}")
 }
 
+// RegisterFuncName returns the name of the function that registers a server
+// for the service, per the register_func parameter.
+func (g *Generator) RegisterFuncName(service *protogen.Service) string {
+	return strings.ReplaceAll(g.Params.RegisterFunc, ServicePlaceholder, g.ServiceName(service))
+}
+
+func (g *Generator) GenerateRegister(service *protogen.Service) {
+	serviceName := g.ServiceName(service)
+	funcName := g.RegisterFuncName(service)
+
+	g.P()
+	g.P("// ", funcName, " registers impl with mux under the exact names")
+	g.P("// of the ", service.GoName, " methods.")
+	g.P("func ", funcName, "(mux *", CorePackage.Ident("HandlerMux"), ", impl ", g.ServerInterfaceName(service), ") {")
+	g.P("\tmux.AddService(&", g.ServiceDescName(service), ", New", serviceName, "Handler(impl))")
+	g.P("}")
+}
+
 func (g *Generator) GenerateHandlerCase(service *protogen.Service, method *protogen.Method) {
 	mp := g.MethodMap[method]
 	v := make([]any, 0, 16)
 	v = append(v, "\t\tif err := h.impl.", method.GoName, "(ctx")
 
 	g.P("\tcase ", mp.NameSymbol, ":")
+	if mp.Policy != nil {
+		g.P("\t\tif err := call.ApplyPolicy(", mp.PolicySymbol, "); err != nil {")
+		g.P("\t\t\treturn err")
+		g.P("\t\t}")
+		g.P("\t\tctx = call.Context()")
+		g.P()
+	}
 	if !mp.In.IsNullary || !mp.Out.IsNullary {
 		g.P("\t\tstream := ", CorePackage.Ident("NewStream"), "[*", mp.Out.GoIdent, ", *", mp.In.GoIdent, "](call)")
 	}
@@ -365,11 +627,13 @@ func (g *Generator) GenerateHandlerCase(service *protogen.Service, method *proto
 }
 
 type MethodProperties struct {
-	Method     *protogen.Method
-	NameSymbol string
-	NameValue  string
-	In         ParamProperties
-	Out        ParamProperties
+	Method       *protogen.Method
+	NameSymbol   string
+	NameValue    string
+	Policy       *vsrpc.MethodPolicy
+	PolicySymbol string
+	In           ParamProperties
+	Out          ParamProperties
 }
 
 type ParamProperties struct {
@@ -380,7 +644,7 @@ type ParamProperties struct {
 	IsNullary  bool
 }
 
-func (mp *MethodProperties) Set(service *protogen.Service, method *protogen.Method) {
+func (mp *MethodProperties) Set(serviceName string, service *protogen.Service, method *protogen.Method, policy *vsrpc.MethodPolicy) {
 	inMessage := method.Input
 	inIdent := inMessage.GoIdent
 	inMulti := method.Desc.IsStreamingClient()
@@ -392,9 +656,11 @@ func (mp *MethodProperties) Set(service *protogen.Service, method *protogen.Meth
 	outEmpty := (outIdent == EmptyIdent)
 
 	*mp = MethodProperties{
-		Method:     method,
-		NameSymbol: fmt.Sprintf("vsrpcMethodName_%s_%s", service.GoName, method.GoName),
-		NameValue:  fmt.Sprintf("%s.%s", service.Desc.FullName(), method.Desc.Name()),
+		Method:       method,
+		NameSymbol:   fmt.Sprintf("%s_%s_FullMethodName", serviceName, method.GoName),
+		NameValue:    fmt.Sprintf("%s.%s", service.Desc.FullName(), method.Desc.Name()),
+		Policy:       policy,
+		PolicySymbol: fmt.Sprintf("vsrpcMethodPolicy_%s_%s", serviceName, method.GoName),
 		In: ParamProperties{
 			Message:    inMessage,
 			GoIdent:    inIdent,
@@ -434,6 +700,37 @@ func (mp MethodProperties) AppendClientSignature(out []any) []any {
 	return out
 }
 
+// IsStreaming reports whether the method streams its requests, its
+// responses, or both.
+func (mp MethodProperties) IsStreaming() bool {
+	return mp.In.IsPlural || mp.Out.IsPlural
+}
+
+func (mp MethodProperties) AppendOpenSignature(out []any) []any {
+	out = append(out, "(ctx ", ContextPackage.Ident("Context"))
+	if mp.In.IsSingular {
+		out = append(out, ", req *", mp.In.GoIdent)
+	}
+	out = append(out, ", options ...", CorePackage.Ident("Option"), ") (")
+	out = mp.AppendOpenStreamType(out)
+	out = append(out, ", error)")
+	return out
+}
+
+func (mp MethodProperties) AppendOpenStreamType(out []any) []any {
+	switch {
+	case mp.Out.IsPlural && mp.In.IsPlural:
+		out = append(out, CorePackage.Ident("ClientBiStream"), "[*", mp.In.GoIdent, ", *", mp.Out.GoIdent, "]")
+
+	case mp.Out.IsPlural:
+		out = append(out, CorePackage.Ident("ClientRecvStream"), "[*", mp.Out.GoIdent, "]")
+
+	case mp.In.IsPlural:
+		out = append(out, CorePackage.Ident("ClientSendStream"), "[*", mp.In.GoIdent, ", *", mp.Out.GoIdent, "]")
+	}
+	return out
+}
+
 func (mp MethodProperties) AppendServerSignature(out []any) []any {
 	out = append(out, "(ctx ", ContextPackage.Ident("Context"))
 	if mp.In.IsSingular {
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+type GoldenCase struct {
+	Name  string
+	Files []string
+	Param string
+}
+
+func TestGolden(t *testing.T) {
+	testCases := []GoldenCase{
+		{Name: "shapes", Files: []string{"shapes.proto"}},
+		{Name: "deprecated", Files: []string{"deprecated.proto"}},
+		{Name: "comments", Files: []string{"comments.proto"}},
+		{Name: "multi", Files: []string{"multi.proto"}},
+		{Name: "nothing", Files: []string{"nothing.proto"}},
+		{Name: "several_files", Files: []string{"shapes.proto", "multi.proto", "nothing.proto"}},
+		{Name: "mocks", Files: []string{"shapes.proto", "multi.proto"}, Param: "mocks=true"},
+		{Name: "policy", Files: []string{"policy.proto"}, Param: "mocks=true"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runGolden(t, tc)
+		})
+	}
+}
+
+// TestExampleUpToDate checks that the generated files in example/ match what
+// the generator currently produces, apart from the version stamps.
+func TestExampleUpToDate(t *testing.T) {
+	dir := filepath.Join("..", "..", "example")
+	files, err := runGeneratorIn(t, dir, []string{"example.proto"}, "mocks=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 generated files, got %d", len(files))
+	}
+
+	for _, file := range files {
+		path := filepath.Join(dir, file.GetName())
+		expect, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		e := stripVersions(string(expect))
+		a := stripVersions(file.GetContent())
+		if e != a {
+			t.Errorf("%s is out of date; run genproto.sh\n%s", path, diffLines(e, a))
+		}
+	}
+}
+
+func stripVersions(str string) string {
+	lines := strings.Split(str, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "// - protoc") {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func TestGolden_Params(t *testing.T) {
+	testCases := []GoldenCase{
+		{Name: "params_default", Files: []string{"params.proto"}},
+		{Name: "params_client_only", Files: []string{"params.proto"}, Param: "server=false"},
+		{Name: "params_server_only", Files: []string{"params.proto"}, Param: "client=false"},
+		{Name: "params_type_prefix", Files: []string{"params.proto"}, Param: "type_prefix=Vs"},
+		{Name: "params_register_func", Files: []string{"params.proto"}, Param: "register_func=Add<Svc>To,type_prefix=Vs"},
+		{Name: "params_require_unimplemented", Files: []string{"params.proto"}, Param: "require_unimplemented_servers=true"},
+		{Name: "params_mocks_client_only", Files: []string{"params.proto"}, Param: "mocks=true,server=false"},
+		{Name: "params_mocks_type_prefix", Files: []string{"params.proto"}, Param: "mocks=true,type_prefix=Vs"},
+		{Name: "params_streaming_callback", Files: []string{"shapes.proto"}, Param: "streaming=callback,mocks=true"},
+		{Name: "params_streaming_object", Files: []string{"shapes.proto"}, Param: "streaming=object,mocks=true"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runGolden(t, tc)
+		})
+	}
+}
+
+func TestParams_Errors(t *testing.T) {
+	type ErrorCase struct {
+		Param  string
+		Expect string
+	}
+
+	testCases := []ErrorCase{
+		{Param: "bogus=1", Expect: `unknown parameter name "bogus"`},
+		{Param: "client=maybe", Expect: `parameter "client": invalid boolean value "maybe"`},
+		{Param: "client=false,server=false", Expect: `nothing to generate`},
+		{Param: "mocks=true,client=false", Expect: `parameter "mocks" requires parameter "client"`},
+		{Param: "streaming=maybe", Expect: `parameter "streaming": invalid value "maybe"`},
+		{Param: "type_prefix=vs", Expect: `must begin with an upper-case letter`},
+		{Param: "type_prefix=V-s", Expect: `not valid in a Go identifier`},
+		{Param: "register_func=Register", Expect: `must contain "<Svc>"`},
+		{Param: "register_func=register<Svc>", Expect: `must begin with an upper-case letter`},
+		{Param: "register_func=Add.<Svc>", Expect: `not valid in a Go identifier`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Param, func(t *testing.T) {
+			_, err := runGenerator(t, []string{"params.proto"}, tc.Param)
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tc.Expect)
+			}
+			if !strings.Contains(err.Error(), tc.Expect) {
+				t.Errorf("expected error containing %q, got %q", tc.Expect, err.Error())
+			}
+		})
+	}
+}
+
+func runGolden(t *testing.T, tc GoldenCase) {
+	t.Helper()
+
+	files, err := runGenerator(t, tc.Files, tc.Param)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join("testdata", "golden", tc.Name)
+	if *update {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+		if len(files) <= 0 {
+			return
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			path := filepath.Join(dir, file.GetName()+".golden")
+			if err := os.WriteFile(path, []byte(file.GetContent()), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !(len(files) <= 0 && os.IsNotExist(err)) {
+		t.Fatalf("%v (run with -update to create the golden files)", err)
+	}
+	if len(entries) != len(files) {
+		t.Errorf("expected %d generated files, got %d", len(entries), len(files))
+	}
+
+	for _, file := range files {
+		path := filepath.Join(dir, file.GetName()+".golden")
+		expect, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("%v (run with -update to create the golden files)", err)
+			continue
+		}
+		if actual := []byte(file.GetContent()); !bytes.Equal(expect, actual) {
+			t.Errorf("%s: output differs from %s (run with -update to accept)\n%s", file.GetName(), path, diffLines(string(expect), string(actual)))
+		}
+	}
+}
+
+// runGenerator compiles the named fixtures from testdata and runs the plugin
+// on them in-process, as protoc would with "--go-vsrpc_opt=<param>".
+func runGenerator(t *testing.T, names []string, param string) ([]*pluginpb.CodeGeneratorResponse_File, error) {
+	t.Helper()
+	return runGeneratorIn(t, "testdata", names, param)
+}
+
+func runGeneratorIn(t *testing.T, dir string, names []string, param string) ([]*pluginpb.CodeGeneratorResponse_File, error) {
+	t.Helper()
+
+	compiler := protocompile.Compiler{
+		Resolver:       protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{dir, filepath.Join("..", "..", "proto")}}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	results, err := compiler.Compile(context.Background(), names...)
+	if err != nil {
+		t.Fatalf("failed to compile %v: %v", names, err)
+	}
+
+	seen := make(map[string]void, 16)
+	var protos []*descriptorpb.FileDescriptorProto
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if _, found := seen[fd.Path()]; found {
+			return
+		}
+		seen[fd.Path()] = void{}
+
+		imports := fd.Imports()
+		for i, n := 0, imports.Len(); i < n; i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+
+		if r, ok := fd.(linker.Result); ok {
+			protos = append(protos, r.FileDescriptorProto())
+		} else {
+			protos = append(protos, protodesc.ToFileDescriptorProto(fd))
+		}
+	}
+	for _, fd := range results {
+		add(fd)
+	}
+
+	fullParam := "paths=source_relative"
+	if param != "" {
+		fullParam += "," + param
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: names,
+		Parameter:      proto.String(fullParam),
+		ProtoFile:      protos,
+	}
+
+	params := DefaultParams()
+	plugin, err := protogen.Options{ParamFunc: params.Set}.New(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := Generate(plugin, params); err != nil {
+		return nil, err
+	}
+
+	resp := plugin.Response()
+	if resp.Error != nil {
+		t.Fatalf("plugin reported error: %s", resp.GetError())
+	}
+	return resp.File, nil
+}
+
+// diffLines returns a minimal report of the first differing lines.
+func diffLines(expect string, actual string) string {
+	expectLines := strings.Split(expect, "\n")
+	actualLines := strings.Split(actual, "\n")
+
+	for i := 0; i < len(expectLines) || i < len(actualLines); i++ {
+		var e, a string
+		if i < len(expectLines) {
+			e = expectLines[i]
+		}
+		if i < len(actualLines) {
+			a = actualLines[i]
+		}
+		if e != a {
+			return fmt.Sprintf("line %d:\n-\t%s\n+\t%s", i+1, e, a)
+		}
+	}
+	return ""
+}
@@ -12,6 +12,25 @@ import (
 
 const helpText = `protoc plugin for Go for the Very Simple RPC protocol
 Usage: protoc --go-vsrpc_out=. path/to/service.proto
+
+Parameters (--go-vsrpc_opt=name=value):
+  client=BOOL
+      generate the client interface and stubs (default true)
+  server=BOOL
+      generate the server interface and Handler (default true)
+  type_prefix=NAME
+      prepend NAME to the names of all generated types and functions
+  register_func=NAME
+      name of the function that registers a server with a HandlerMux, with
+      <Svc> standing for the service name (default Register<Svc>Server)
+  require_unimplemented_servers=BOOL
+      require server implementations to embed UnimplementedXServer
+  streaming=callback|object|both
+      which API to generate for streaming methods: callbacks (X), stream
+      objects (OpenX), or both (default both)
+  mocks=BOOL
+      also generate a _vsrpc_mock.pb.go file with MockXClient and, if the
+      server is generated too, NewXLoopback (default false)
 `
 
 var (
@@ -76,19 +95,26 @@ func main() {
 		return
 	}
 
+	params := DefaultParams()
 	o := protogen.Options{
-		ParamFunc: func(name string, value string) error {
-			return fmt.Errorf("unknown parameter name %q", name)
-		},
+		ParamFunc: params.Set,
 	}
 	o.Run(func(plugin *protogen.Plugin) error {
-		plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
-		for _, file := range plugin.Files {
-			if file.Generate {
-				g := &Generator{Plugin: plugin, File: file}
-				g.GenerateFile()
-			}
-		}
-		return nil
+		return Generate(plugin, params)
 	})
 }
+
+func Generate(plugin *protogen.Plugin, params Params) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+	for _, file := range plugin.Files {
+		if file.Generate {
+			g := &Generator{Plugin: plugin, File: file, Params: params}
+			g.GenerateFile()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+func (g *Generator) MockClientName(service *protogen.Service) string {
+	return "Mock" + g.ServiceName(service) + "Client"
+}
+
+func (g *Generator) GenerateMockClient(service *protogen.Service) {
+	interfaceName := g.ClientInterfaceName(service)
+	mockName := g.MockClientName(service)
+	v := make([]any, 0, 16)
+
+	g.P()
+	g.P("// ", mockName, " is a programmable ", interfaceName, " for use in tests.")
+	g.P("//")
+	g.P("// For each method M, if MFunc (or OpenMFunc) is set then it handles the call.")
+	g.P("// Otherwise, the call delivers MResponses and then returns MErr.  The n'th")
+	g.P("// call to a method with a single response gets MResponses[n], or the last")
+	g.P("// element once the list runs out; a call to a method with streamed responses")
+	g.P("// gets all of them.")
+	g.P("//")
+	g.P("// Every call is recorded along with its requests.  Calls handled by MFunc or")
+	g.P("// OpenMFunc record only the single request, if the method has one.")
+	g.P("type ", mockName, " struct {")
+	g.P("\t", CorePackage.Ident("MockRecorder"))
+	for _, method := range service.Methods {
+		mp := g.MethodMap[method]
+
+		g.P()
+		if g.HasCallbackMethod(mp) {
+			v = v[:0]
+			v = append(v, "\t", method.GoName, "Func func")
+			v = mp.AppendClientSignature(v)
+			g.P(v...)
+		}
+		if g.HasOpenMethod(mp) {
+			v = v[:0]
+			v = append(v, "\tOpen", method.GoName, "Func func")
+			v = mp.AppendOpenSignature(v)
+			g.P(v...)
+		}
+		if !mp.Out.IsNullary {
+			g.P("\t", method.GoName, "Responses []*", mp.Out.GoIdent)
+		}
+		g.P("\t", method.GoName, "Err error")
+	}
+	g.P("}")
+
+	for _, method := range service.Methods {
+		mp := g.MethodMap[method]
+		if g.HasCallbackMethod(mp) {
+			g.GenerateMockClientMethod(service, method, mockName)
+		}
+		if g.HasOpenMethod(mp) {
+			g.GenerateMockClientOpenMethod(service, method, mockName)
+		}
+	}
+
+	g.P()
+	g.P("var _ ", interfaceName, " = (*", mockName, ")(nil)")
+}
+
+// mockRecord returns the statement that records a call to a mocked method
+// whose requests are not streamed.
+func mockRecord(mp MethodProperties) []any {
+	if mp.In.IsSingular {
+		return []any{"mock.Record(", mp.NameSymbol, ", ", ProtoPackage.Ident("Clone"), "(req))"}
+	}
+	return []any{"mock.Record(", mp.NameSymbol, ")"}
+}
+
+// mockStreamResponses returns the initial responses of a MockStream.
+func mockStreamResponses(mp MethodProperties, method *protogen.Method) string {
+	if mp.Out.IsPlural {
+		return "mock." + method.GoName + "Responses"
+	}
+	return "nil"
+}
+
+func (g *Generator) GenerateMockClientMethod(service *protogen.Service, method *protogen.Method, mockName string) {
+	mp := g.MethodMap[method]
+
+	g.P()
+	v := make([]any, 0, 16)
+	v = append(v, "func (mock *", mockName, ") ", method.GoName)
+	v = mp.AppendClientSignature(v)
+	v = append(v, " {")
+	g.P(v...)
+
+	if mp.Out.IsSingular {
+		g.P("\t", AssertPackage.Ident("NotNil"), "(&resp)")
+		g.P("\tresp.Reset()")
+		g.P()
+	}
+
+	v = v[:0]
+	v = append(v, "\t\treturn mock.", method.GoName, "Func(ctx")
+	if mp.In.IsSingular {
+		v = append(v, ", req")
+	}
+	if mp.Out.IsSingular {
+		v = append(v, ", resp")
+	}
+	if mp.IsStreaming() {
+		v = append(v, ", fn")
+	}
+	v = append(v, ", options...)")
+
+	g.P("\tif mock.", method.GoName, "Func != nil {")
+	g.P(append([]any{"\t\t"}, mockRecord(mp)...)...)
+	g.P(v...)
+	g.P("\t}")
+	g.P()
+
+	prefix := "\t"
+	if mp.Out.IsSingular {
+		prefix = "\tn := "
+	}
+
+	if mp.IsStreaming() {
+		g.P("\tstream := ", CorePackage.Ident("NewMockStream"), "[*", mp.In.GoIdent, ", *", mp.Out.GoIdent, "](ctx, ", mp.NameSymbol, ", ", mockStreamResponses(mp, method), ", nil)")
+		if mp.In.IsPlural {
+			g.P(prefix, "mock.RecordStream(", mp.NameSymbol, ", stream)")
+		} else {
+			g.P(append([]any{prefix}, mockRecord(mp)...)...)
+		}
+		g.P("\tif err := fn(stream); err != nil {")
+		g.P("\t\treturn err")
+		g.P("\t}")
+	} else {
+		g.P(append([]any{prefix}, mockRecord(mp)...)...)
+	}
+
+	if mp.Out.IsSingular {
+		g.P("\tif out, ok := ", CorePackage.Ident("MockResponse"), "(mock.", method.GoName, "Responses, n); ok {")
+		g.P("\t\t", ProtoPackage.Ident("Merge"), "(resp, out)")
+		g.P("\t}")
+	}
+	g.P("\treturn mock.", method.GoName, "Err")
+	g.P("}")
+}
+
+func (g *Generator) GenerateMockClientOpenMethod(service *protogen.Service, method *protogen.Method, mockName string) {
+	mp := g.MethodMap[method]
+
+	g.P()
+	v := make([]any, 0, 16)
+	v = append(v, "func (mock *", mockName, ") Open", method.GoName)
+	v = mp.AppendOpenSignature(v)
+	v = append(v, " {")
+	g.P(v...)
+
+	v = v[:0]
+	v = append(v, "\t\treturn mock.Open", method.GoName, "Func(ctx")
+	if mp.In.IsSingular {
+		v = append(v, ", req")
+	}
+	v = append(v, ", options...)")
+
+	g.P("\tif mock.Open", method.GoName, "Func != nil {")
+	g.P(append([]any{"\t\t"}, mockRecord(mp)...)...)
+	g.P(v...)
+	g.P("\t}")
+	g.P()
+
+	g.P("\tstream := ", CorePackage.Ident("NewMockStream"), "[*", mp.In.GoIdent, ", *", mp.Out.GoIdent, "](ctx, ", mp.NameSymbol, ", ", mockStreamResponses(mp, method), ", mock.", method.GoName, "Err)")
+	switch {
+	case mp.In.IsPlural && mp.Out.IsSingular:
+		g.P("\tn := mock.RecordStream(", mp.NameSymbol, ", stream)")
+		g.P("\tif out, ok := ", CorePackage.Ident("MockResponse"), "(mock.", method.GoName, "Responses, n); ok {")
+		g.P("\t\tstream.SetResponses(out)")
+		g.P("\t}")
+	case mp.In.IsPlural:
+		g.P("\tmock.RecordStream(", mp.NameSymbol, ", stream)")
+	default:
+		g.P(append([]any{"\t"}, mockRecord(mp)...)...)
+	}
+	g.P("\treturn stream, nil")
+	g.P("}")
+}
+
+func (g *Generator) GenerateLoopback(service *protogen.Service) {
+	serviceName := g.ServiceName(service)
+	interfaceName := g.ClientInterfaceName(service)
+
+	g.P()
+	g.P("// New", serviceName, "Loopback serves impl through the generated Handler over an")
+	g.P("// in-process connection, and returns a client that talks to it.  Close the")
+	g.P("// Loopback when done.")
+	g.P("func New", serviceName, "Loopback(ctx ", ContextPackage.Ident("Context"), ", impl ", g.ServerInterfaceName(service), ", options ...", CorePackage.Ident("Option"), ") (", interfaceName, ", *", CorePackage.Ident("Loopback"), ", error) {")
+	g.P("\tlb, err := ", CorePackage.Ident("NewLoopback"), "(ctx, New", serviceName, "Handler(impl), options...)")
+	g.P("\tif err != nil {")
+	g.P("\t\treturn nil, nil, err")
+	g.P("\t}")
+	g.P("\treturn New", interfaceName, "(lb.Conn), lb, nil")
+	g.P("}")
+}
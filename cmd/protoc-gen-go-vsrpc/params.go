@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ServicePlaceholder is replaced with the name of the service in the
+// register_func parameter.
+const ServicePlaceholder = "<Svc>"
+
+// Params holds the values of the --go-vsrpc_opt parameters.
+type Params struct {
+	TypePrefix           string
+	RegisterFunc         string
+	Client               bool
+	Server               bool
+	RequireUnimplemented bool
+	Mocks                bool
+	CallbackStreams      bool
+	ObjectStreams        bool
+}
+
+func DefaultParams() Params {
+	return Params{
+		RegisterFunc:    "Register" + ServicePlaceholder + "Server",
+		Client:          true,
+		Server:          true,
+		CallbackStreams: true,
+		ObjectStreams:   true,
+	}
+}
+
+func (p *Params) Set(name string, value string) error {
+	switch name {
+	case "client":
+		return parseBoolParam(&p.Client, name, value)
+
+	case "server":
+		return parseBoolParam(&p.Server, name, value)
+
+	case "type_prefix":
+		if err := checkTypePrefix(value); err != nil {
+			return fmt.Errorf("parameter %q: %w", name, err)
+		}
+		p.TypePrefix = value
+		return nil
+
+	case "register_func":
+		if err := checkRegisterFunc(value); err != nil {
+			return fmt.Errorf("parameter %q: %w", name, err)
+		}
+		p.RegisterFunc = value
+		return nil
+
+	case "require_unimplemented_servers":
+		return parseBoolParam(&p.RequireUnimplemented, name, value)
+
+	case "mocks":
+		return parseBoolParam(&p.Mocks, name, value)
+
+	case "streaming":
+		switch value {
+		case "callback":
+			p.CallbackStreams, p.ObjectStreams = true, false
+		case "object":
+			p.CallbackStreams, p.ObjectStreams = false, true
+		case "both":
+			p.CallbackStreams, p.ObjectStreams = true, true
+		default:
+			return fmt.Errorf("parameter %q: invalid value %q; must be one of \"callback\", \"object\", or \"both\"", name, value)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown parameter name %q", name)
+	}
+}
+
+// Validate checks the parameters for combinations that make no sense.
+func (p Params) Validate() error {
+	if !p.Client && !p.Server {
+		return fmt.Errorf("parameters \"client\" and \"server\" are both false; there is nothing to generate")
+	}
+	if p.Mocks && !p.Client {
+		return fmt.Errorf("parameter \"mocks\" requires parameter \"client\"")
+	}
+	return nil
+}
+
+func parseBoolParam(out *bool, name string, value string) error {
+	if value == "" {
+		*out = true
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("parameter %q: invalid boolean value %q", name, value)
+	}
+	*out = b
+	return nil
+}
+
+func checkTypePrefix(value string) error {
+	for index, ch := range value {
+		switch {
+		case index == 0 && !unicode.IsUpper(ch):
+			return fmt.Errorf("prefix %q must begin with an upper-case letter, so that generated types are exported", value)
+		case ch != '_' && !unicode.IsLetter(ch) && !unicode.IsDigit(ch):
+			return fmt.Errorf("prefix %q contains %q, which is not valid in a Go identifier", value, ch)
+		}
+	}
+	return nil
+}
+
+func checkRegisterFunc(value string) error {
+	if !strings.Contains(value, ServicePlaceholder) {
+		return fmt.Errorf("name %q must contain %q, so that each service gets its own function", value, ServicePlaceholder)
+	}
+	name := strings.ReplaceAll(value, ServicePlaceholder, "X")
+	for index, ch := range name {
+		switch {
+		case index == 0 && !unicode.IsUpper(ch):
+			return fmt.Errorf("name %q must begin with an upper-case letter, so that the function is exported", value)
+		case ch != '_' && !unicode.IsLetter(ch) && !unicode.IsDigit(ch):
+			return fmt.Errorf("name %q contains %q, which is not valid in a Go identifier", value, ch)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+// MethodPolicyOf returns the (vsrpc.policy) option of method, or nil if the
+// method has none.
+func MethodPolicyOf(method *protogen.Method) (*vsrpc.MethodPolicy, error) {
+	opts, ok := method.Desc.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return nil, nil
+	}
+
+	// Depending on how the request was decoded, the extension may still be
+	// sitting in the unknown fields.  A round trip through the wire format
+	// resolves it against the types linked into this binary.
+	raw, err := proto.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	var clean descriptorpb.MethodOptions
+	if err := proto.Unmarshal(raw, &clean); err != nil {
+		return nil, err
+	}
+	if !proto.HasExtension(&clean, vsrpc.E_Policy) {
+		return nil, nil
+	}
+	return proto.GetExtension(&clean, vsrpc.E_Policy).(*vsrpc.MethodPolicy), nil
+}
+
+// HasRetry reports whether the client stub for a method retries failed calls.
+// Only unary methods that are declared safe to repeat are retried.
+func (mp MethodProperties) HasRetry() bool {
+	if mp.Policy.GetRetry().GetMaxAttempts() < 2 {
+		return false
+	}
+	if mp.In.IsPlural || mp.Out.IsPlural {
+		return false
+	}
+	return mp.Policy.GetIdempotency() != vsrpc.MethodPolicy_IDEMPOTENCY_UNKNOWN
+}
+
+func (g *Generator) GeneratePolicies(service *protogen.Service) {
+	var found bool
+	for _, method := range service.Methods {
+		if g.MethodMap[method].Policy != nil {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return
+	}
+
+	g.P()
+	g.P("var (")
+	for _, method := range service.Methods {
+		mp := g.MethodMap[method]
+		if mp.Policy == nil {
+			continue
+		}
+		g.GenerateMessageLiteral("\t", []any{"\t", mp.PolicySymbol, " = "}, mp.Policy.ProtoReflect(), "")
+	}
+	g.P(")")
+}
+
+// GenerateMessageLiteral writes m as a Go composite literal.  The first line
+// begins with head, and the last line ends with tail.
+func (g *Generator) GenerateMessageLiteral(indent string, head []any, m protoreflect.Message, tail string) {
+	msg := g.FindMessage(m.Descriptor().FullName())
+	if msg == nil {
+		g.Error(fmt.Errorf("cannot find the Go type of message %s", m.Descriptor().FullName()))
+		return
+	}
+
+	// The descriptors seen by protogen are distinct from the ones linked into
+	// this binary, so fields are matched up by number.
+	fields := m.Descriptor().Fields()
+	g.P(append(head, "&", msg.GoIdent, "{")...)
+	for _, field := range msg.Fields {
+		fd := fields.ByNumber(field.Desc.Number())
+		if fd == nil || !m.Has(fd) {
+			continue
+		}
+		if fd.IsMap() || (fd.ContainingOneof() != nil && !fd.ContainingOneof().IsSynthetic()) {
+			g.Error(fmt.Errorf("field %s: maps and oneofs are not supported in options", fd.FullName()))
+			continue
+		}
+
+		fieldHead := []any{indent, "\t", field.GoName, ": "}
+		if !fd.IsList() {
+			g.GenerateValue(indent+"\t", fieldHead, fd, m.Get(fd), ",")
+			continue
+		}
+
+		g.P(append(fieldHead, "[]", g.ElementType(fd), "{")...)
+		list := m.Get(fd).List()
+		for i, n := 0, list.Len(); i < n; i++ {
+			g.GenerateValue(indent+"\t\t", []any{indent, "\t\t"}, fd, list.Get(i), ",")
+		}
+		g.P(indent, "\t},")
+	}
+	g.P(indent, "}", tail)
+}
+
+func (g *Generator) GenerateValue(indent string, head []any, fd protoreflect.FieldDescriptor, v protoreflect.Value, tail string) {
+	var value any
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		g.GenerateMessageLiteral(indent, head, v.Message(), tail)
+		return
+
+	case protoreflect.EnumKind:
+		value = g.EnumValue(fd.Enum(), v.Enum())
+
+	case protoreflect.BoolKind:
+		value = strconv.FormatBool(v.Bool())
+
+	case protoreflect.StringKind:
+		value = strconv.Quote(v.String())
+
+	case protoreflect.BytesKind:
+		value = fmt.Sprintf("[]byte(%q)", v.Bytes())
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		value = strconv.FormatInt(v.Int(), 10)
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		value = strconv.FormatUint(v.Uint(), 10)
+
+	case protoreflect.FloatKind:
+		value = strconv.FormatFloat(v.Float(), 'g', -1, 32)
+
+	case protoreflect.DoubleKind:
+		value = strconv.FormatFloat(v.Float(), 'g', -1, 64)
+
+	default:
+		g.Error(fmt.Errorf("field %s: unsupported kind %v", fd.FullName(), fd.Kind()))
+		return
+	}
+	g.P(append(head, value, tail)...)
+}
+
+// ElementType returns the Go type of one element of a repeated field.
+func (g *Generator) ElementType(fd protoreflect.FieldDescriptor) any {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if msg := g.FindMessage(fd.Message().FullName()); msg != nil {
+			return "*" + g.Out.QualifiedGoIdent(msg.GoIdent)
+		}
+	case protoreflect.EnumKind:
+		if enum := g.FindEnum(fd.Enum().FullName()); enum != nil {
+			return enum.GoIdent
+		}
+	case protoreflect.BoolKind:
+		return "bool"
+	case protoreflect.StringKind:
+		return "string"
+	case protoreflect.BytesKind:
+		return "[]byte"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "int32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return "int64"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "uint32"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "uint64"
+	case protoreflect.FloatKind:
+		return "float32"
+	case protoreflect.DoubleKind:
+		return "float64"
+	}
+	g.Error(fmt.Errorf("field %s: cannot find the Go type of its elements", fd.FullName()))
+	return "any"
+}
+
+// EnumValue returns the Go constant for an enum value, or a conversion if the
+// number has no name.
+func (g *Generator) EnumValue(desc protoreflect.EnumDescriptor, number protoreflect.EnumNumber) any {
+	enum := g.FindEnum(desc.FullName())
+	if enum == nil {
+		g.Error(fmt.Errorf("cannot find the Go type of enum %s", desc.FullName()))
+		return strconv.FormatInt(int64(number), 10)
+	}
+	for _, value := range enum.Values {
+		if value.Desc.Number() == number {
+			return value.GoIdent
+		}
+	}
+	return g.Out.QualifiedGoIdent(enum.GoIdent) + "(" + strconv.FormatInt(int64(number), 10) + ")"
+}
+
+func (g *Generator) FindMessage(name protoreflect.FullName) *protogen.Message {
+	g.indexTypes()
+	return g.Messages[name]
+}
+
+func (g *Generator) FindEnum(name protoreflect.FullName) *protogen.Enum {
+	g.indexTypes()
+	return g.Enums[name]
+}
+
+func (g *Generator) indexTypes() {
+	if g.Messages != nil {
+		return
+	}
+
+	g.Messages = make(map[protoreflect.FullName]*protogen.Message, 64)
+	g.Enums = make(map[protoreflect.FullName]*protogen.Enum, 64)
+
+	var addMessages func(list []*protogen.Message)
+	addEnums := func(list []*protogen.Enum) {
+		for _, enum := range list {
+			g.Enums[enum.Desc.FullName()] = enum
+		}
+	}
+	addMessages = func(list []*protogen.Message) {
+		for _, msg := range list {
+			g.Messages[msg.Desc.FullName()] = msg
+			addEnums(msg.Enums)
+			addMessages(msg.Messages)
+		}
+	}
+	for _, file := range g.Plugin.Files {
+		addEnums(file.Enums)
+		addMessages(file.Messages)
+	}
+}
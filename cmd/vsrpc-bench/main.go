@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chronos-tachyon/vsrpc/vsrpcbench"
+)
+
+const helpText = `load generator for the Very Simple RPC protocol
+Usage: vsrpc-bench [flags]
+
+Starts an in-process vsrpc server on a unixpacket socket, then drives it from
+-workers goroutines sharing -conns connections, issuing a mix of unary and
+streaming calls.  One run is made for each payload size in -sizes, and each
+run prints one row: QPS, latency percentiles, allocations and bytes per call
+(for the whole process, client and server together), and bytes on the wire
+per call.
+
+Flags:
+`
+
+var (
+	Version    = "devel"
+	Commit     = ""
+	CommitDate = ""
+	TreeState  = ""
+)
+
+func versionText() string {
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	fmt.Fprintf(buf, "%s\n", Version)
+	if Commit != "" {
+		fmt.Fprintf(buf, "Commit=%s\n", Commit)
+	}
+	if CommitDate != "" {
+		fmt.Fprintf(buf, "CommitDate=%s\n", CommitDate)
+	}
+	if TreeState != "" {
+		fmt.Fprintf(buf, "TreeState=%s\n", TreeState)
+	}
+	return buf.String()
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	var (
+		mixText     string
+		sizesText   string
+		cfg         vsrpcbench.Config
+		wantVersion bool
+	)
+
+	fs := flag.NewFlagSet("vsrpc-bench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&mixText, "mix", "unary", "call `mix` as comma-separated shape=weight pairs (shapes: unary, server, client, bidi)")
+	fs.StringVar(&sizesText, "sizes", "0,64,1024,16384", "comma-separated payload `sizes` in bytes to sweep over")
+	fs.UintVar(&cfg.Conns, "conns", vsrpcbench.DefaultConns, "number of client `connections`")
+	fs.UintVar(&cfg.Workers, "workers", vsrpcbench.DefaultWorkers, "number of concurrent `workers`")
+	fs.UintVar(&cfg.Calls, "calls", 0, "stop each run after this many `calls` (overrides -duration)")
+	fs.DurationVar(&cfg.Duration, "duration", vsrpcbench.DefaultDuration, "length of each run")
+	fs.UintVar(&cfg.StreamLength, "stream-length", vsrpcbench.DefaultStreamLength, "messages per streaming call")
+	fs.UintVar(&cfg.MaxPacketSize, "max-packet-size", vsrpcbench.DefaultMaxPacketSize, "maximum packet size in `bytes`")
+	fs.Int64Var(&cfg.Seed, "seed", 0, "seed for choosing call shapes")
+	fs.BoolVar(&wantVersion, "version", false, "print the version and exit")
+	fs.Usage = func() {
+		io.WriteString(fs.Output(), helpText)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	if wantVersion {
+		io.WriteString(stdout, versionText())
+		return 0
+	}
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	mix, err := vsrpcbench.ParseMix(mixText)
+	if err != nil {
+		fmt.Fprintf(stderr, "vsrpc-bench: -mix: %v\n", err)
+		return 2
+	}
+	cfg.Mix = mix
+
+	sizes, err := parseSizes(sizesText)
+	if err != nil {
+		fmt.Fprintf(stderr, "vsrpc-bench: -sizes: %v\n", err)
+		return 2
+	}
+	for _, size := range sizes {
+		// Leave room for the Frame and Any wrappers around the payload.
+		if size+1024 > cfg.MaxPacketSize {
+			fmt.Fprintf(stderr, "vsrpc-bench: payload size %d does not fit in -max-packet-size %d\n", size, cfg.MaxPacketSize)
+			return 2
+		}
+	}
+
+	ctx := context.Background()
+	env, err := vsrpcbench.NewEnv(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "vsrpc-bench: %v\n", err)
+		return 1
+	}
+	defer func() { _ = env.Close() }()
+
+	fmt.Fprintf(stdout, "# mix=%s conns=%d workers=%d stream-length=%d\n", cfg.Mix, cfg.Conns, cfg.Workers, cfg.StreamLength)
+	vsrpcbench.WriteHeader(stdout)
+
+	exitCode := 0
+	for _, size := range sizes {
+		cfg.PayloadSize = size
+		r, err := env.Run(ctx, cfg)
+		if err != nil {
+			fmt.Fprintf(stderr, "vsrpc-bench: size %d: %v\n", size, err)
+			return 1
+		}
+		r.WriteRow(stdout)
+		if r.Errors != 0 {
+			fmt.Fprintf(stderr, "vsrpc-bench: size %d: %d calls failed; first error: %v\n", size, r.Errors, r.FirstError)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+func parseSizes(str string) ([]uint, error) {
+	var sizes []uint
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		size, err := strconv.ParseUint(item, 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, uint(size))
+	}
+	if len(sizes) <= 0 {
+		return nil, fmt.Errorf("no payload sizes given")
+	}
+	return sizes, nil
+}
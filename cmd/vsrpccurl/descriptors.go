@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type Descriptors struct {
+	Files *protoregistry.Files
+	Types *protoregistry.Types
+}
+
+func LoadDescriptors(paths []string) (*Descriptors, error) {
+	d := &Descriptors{
+		Files: new(protoregistry.Files),
+		Types: new(protoregistry.Types),
+	}
+	for _, path := range paths {
+		if err := d.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func (d *Descriptors) loadFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(raw, &set); err != nil {
+		return fmt.Errorf("%s: failed to parse FileDescriptorSet: %w", path, err)
+	}
+
+	for _, fdp := range set.File {
+		if _, err := d.Files.FindFileByPath(fdp.GetName()); err == nil {
+			continue
+		}
+
+		fd, err := protodesc.NewFile(fdp, fileResolver{d.Files})
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", path, fdp.GetName(), err)
+		}
+		if err := d.Files.RegisterFile(fd); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, fdp.GetName(), err)
+		}
+		if err := d.registerTypes(fd.Messages(), fd.Enums(), fd.Extensions()); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, fdp.GetName(), err)
+		}
+	}
+	return nil
+}
+
+func (d *Descriptors) registerTypes(msgs protoreflect.MessageDescriptors, enums protoreflect.EnumDescriptors, exts protoreflect.ExtensionDescriptors) error {
+	for i, n := 0, enums.Len(); i < n; i++ {
+		if err := d.Types.RegisterEnum(dynamicpb.NewEnumType(enums.Get(i))); err != nil {
+			return err
+		}
+	}
+	for i, n := 0, exts.Len(); i < n; i++ {
+		if err := d.Types.RegisterExtension(dynamicpb.NewExtensionType(exts.Get(i))); err != nil {
+			return err
+		}
+	}
+	for i, n := 0, msgs.Len(); i < n; i++ {
+		md := msgs.Get(i)
+		if err := d.Types.RegisterMessage(dynamicpb.NewMessageType(md)); err != nil {
+			return err
+		}
+		if err := d.registerTypes(md.Messages(), md.Enums(), md.Extensions()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Descriptors) FindMethod(name string) (protoreflect.MethodDescriptor, error) {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[:i] + "." + name[i+1:]
+	}
+	name = strings.TrimPrefix(name, ".")
+
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid method name %q: expected \"package.Service.Method\"", name)
+	}
+	serviceName := protoreflect.FullName(name[:i])
+	methodName := protoreflect.Name(name[i+1:])
+
+	desc, err := fileResolver{d.Files}.FindDescriptorByName(serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("service %q not found: %w", serviceName, err)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a service", serviceName)
+	}
+	md := sd.Methods().ByName(methodName)
+	if md == nil {
+		return nil, fmt.Errorf("service %q has no method %q", serviceName, methodName)
+	}
+	return md, nil
+}
+
+func (d *Descriptors) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	mt, err := d.Types.FindMessageByName(name)
+	if err == protoregistry.NotFound {
+		mt, err = protoregistry.GlobalTypes.FindMessageByName(name)
+	}
+	return mt, err
+}
+
+func (d *Descriptors) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	mt, err := d.Types.FindMessageByURL(url)
+	if err == protoregistry.NotFound {
+		mt, err = protoregistry.GlobalTypes.FindMessageByURL(url)
+	}
+	return mt, err
+}
+
+func (d *Descriptors) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	xt, err := d.Types.FindExtensionByName(field)
+	if err == protoregistry.NotFound {
+		xt, err = protoregistry.GlobalTypes.FindExtensionByName(field)
+	}
+	return xt, err
+}
+
+func (d *Descriptors) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	xt, err := d.Types.FindExtensionByNumber(message, field)
+	if err == protoregistry.NotFound {
+		xt, err = protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+	}
+	return xt, err
+}
+
+// fileResolver consults the loaded descriptors first, then falls back to
+// whatever descriptors were linked into this binary (the well-known types and
+// vsrpc's own protos), so that a FileDescriptorSet built without
+// --include_imports can still be used.
+type fileResolver struct {
+	files *protoregistry.Files
+}
+
+func (r fileResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	fd, err := r.files.FindFileByPath(path)
+	if err == protoregistry.NotFound {
+		fd, err = protoregistry.GlobalFiles.FindFileByPath(path)
+	}
+	return fd, err
+}
+
+func (r fileResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	desc, err := r.files.FindDescriptorByName(name)
+	if err == protoregistry.NotFound {
+		desc, err = protoregistry.GlobalFiles.FindDescriptorByName(name)
+	}
+	return desc, err
+}
+
+var _ protodesc.Resolver = fileResolver{}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+const helpText = `command-line client for the Very Simple RPC protocol
+Usage: vsrpccurl [flags] <target> <package.Service.Method>
+
+The target is "transport:address", where transport is one of: %s.
+A target without a transport prefix is the path to a unixpacket socket.
+For ws and wss, the target is the WebSocket URL, e.g. "ws://host:8080/vsrpc".
+
+Message types are resolved from the FileDescriptorSet files given with
+-protoset (as written by "protoc --include_imports --descriptor_set_out"),
+falling back to the descriptors compiled into vsrpccurl itself.  vsrpc does
+not define a reflection service, so there is nothing to query at runtime.
+
+Requests are taken from -d flags if any are present, otherwise they are read
+from stdin as newline-delimited JSON.  Methods whose input type is
+google.protobuf.Empty take no request at all.  Responses are written to
+stdout as newline-delimited JSON, and the final Status is written to stderr.
+
+Flags:
+`
+
+var (
+	Version    = "devel"
+	Commit     = ""
+	CommitDate = ""
+	TreeState  = ""
+)
+
+func versionText() string {
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	fmt.Fprintf(buf, "%s\n", Version)
+	if Commit != "" {
+		fmt.Fprintf(buf, "Commit=%s\n", Commit)
+	}
+	if CommitDate != "" {
+		fmt.Fprintf(buf, "CommitDate=%s\n", CommitDate)
+	}
+	if TreeState != "" {
+		fmt.Fprintf(buf, "TreeState=%s\n", TreeState)
+	}
+	return buf.String()
+}
+
+type stringList []string
+
+func (list *stringList) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *stringList) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
+
+var _ flag.Value = (*stringList)(nil)
+
+const (
+	exitOK     = 0
+	exitStatus = 1
+	exitUsage  = 2
+	exitError  = 3
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	var (
+		protosets     stringList
+		data          stringList
+		timeout       time.Duration
+		maxPacketSize uint
+		wantVersion   bool
+	)
+
+	fs := flag.NewFlagSet("vsrpccurl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Var(&protosets, "protoset", "load descriptors from this FileDescriptorSet `file` (repeatable)")
+	fs.Var(&data, "d", "send this JSON `message` as a request (repeatable)")
+	fs.DurationVar(&timeout, "timeout", 0, "abort the call after this `duration`")
+	fs.UintVar(&maxPacketSize, "max-packet-size", 0, "maximum packet size in `bytes` for the transport")
+	fs.BoolVar(&wantVersion, "version", false, "print the version and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), helpText, strings.Join(transportNames(), ", "))
+		fs.PrintDefaults()
+	}
+
+	// Allow flags to appear after the positional arguments, curl-style.
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return exitOK
+			}
+			return exitUsage
+		}
+		args = fs.Args()
+		if len(args) <= 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if wantVersion {
+		io.WriteString(stdout, versionText())
+		return exitOK
+	}
+
+	if len(positional) != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	d, err := LoadDescriptors(protosets)
+	if err != nil {
+		fmt.Fprintf(stderr, "vsrpccurl: %v\n", err)
+		return exitError
+	}
+
+	md, err := d.FindMethod(positional[1])
+	if err != nil {
+		fmt.Fprintf(stderr, "vsrpccurl: %v\n", err)
+		return exitError
+	}
+
+	pd, addr, err := ParseTarget(TransportConfig{MaxPacketSize: maxPacketSize}, positional[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "vsrpccurl: %v\n", err)
+		return exitUsage
+	}
+
+	inv := &invocation{desc: d, method: md, stdout: stdout}
+	inv.src = &stdinSource{r: bufio.NewReader(stdin)}
+	if len(data) > 0 {
+		if err := inv.checkData(data); err != nil {
+			fmt.Fprintf(stderr, "vsrpccurl: %v\n", err)
+			return exitUsage
+		}
+		inv.src = &listSource{list: data}
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	c := vsrpc.NewClient(pd)
+	defer func() { _ = c.Close() }()
+
+	conn, err := c.Dial(ctx, addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "vsrpccurl: %v\n", err)
+		return exitError
+	}
+
+	status, err := inv.Run(ctx, conn)
+	if err != nil {
+		fmt.Fprintf(stderr, "vsrpccurl: %v\n", err)
+		return exitError
+	}
+
+	raw, err := protojson.MarshalOptions{Resolver: d, EmitUnpopulated: true}.Marshal(status)
+	if err != nil {
+		fmt.Fprintf(stderr, "vsrpccurl: failed to format status: %v\n", err)
+		return exitError
+	}
+	fmt.Fprintf(stderr, "%s\n", raw)
+
+	if !status.IsOK() {
+		return exitStatus
+	}
+	return exitOK
+}
+
+type invocation struct {
+	desc   *Descriptors
+	method protoreflect.MethodDescriptor
+	src    requestSource
+	stdout io.Writer
+}
+
+func (inv *invocation) Run(ctx context.Context, conn *vsrpc.Conn) (*vsrpc.Status, error) {
+	call, err := conn.Begin(ctx, vsrpc.Method(inv.method.FullName()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = call.Close() }()
+
+	// The error is sent before the call is cancelled, so that it is there
+	// to be found once the cancellation has ended the call.
+	sendErrCh := make(chan error, 1)
+	go func() {
+		err := inv.sendRequests(call)
+		sendErrCh <- err
+		if err != nil {
+			_ = call.Cancel()
+		}
+	}()
+
+	var writeErr error
+	for {
+		payload, ok, done := call.Queue().Recv(true)
+		if ok && writeErr == nil {
+			writeErr = inv.writeResponse(payload)
+			if writeErr != nil {
+				_ = call.Cancel()
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	status := call.Wait()
+
+	// If the server ended the call before we ran out of requests, the sender
+	// may still be blocked reading stdin; don't wait for it.
+	var sendErr error
+	select {
+	case sendErr = <-sendErrCh:
+	default:
+	}
+
+	if sendErr != nil {
+		return nil, sendErr
+	}
+	if writeErr != nil {
+		return nil, writeErr
+	}
+	return status, nil
+}
+
+func (inv *invocation) sendRequests(call *vsrpc.Call) error {
+	md := inv.method
+	switch {
+	case md.IsStreamingClient():
+		for {
+			raw, ok, err := inv.src.Next()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				break
+			}
+			if err := inv.sendRequest(call, raw); err != nil {
+				return err
+			}
+		}
+
+	case md.Input().FullName() == "google.protobuf.Empty":
+		// Nullary methods take no request, matching the generated client.
+
+	default:
+		raw, ok, err := inv.src.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("method %q requires a request of type %s", md.FullName(), md.Input().FullName())
+		}
+		if err := inv.sendRequest(call, raw); err != nil {
+			return err
+		}
+	}
+	return call.CloseSend()
+}
+
+// checkData validates requests given on the command line before the call is
+// begun, so that a typo doesn't leave a half-finished call behind.
+func (inv *invocation) checkData(data []string) error {
+	md := inv.method
+	switch {
+	case md.IsStreamingClient():
+	case md.Input().FullName() == "google.protobuf.Empty":
+		return fmt.Errorf("method %q takes no request", md.FullName())
+	case len(data) > 1:
+		return fmt.Errorf("method %q accepts only one request", md.FullName())
+	}
+	for _, item := range data {
+		if _, err := inv.parseRequest([]byte(item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (inv *invocation) parseRequest(raw []byte) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(inv.method.Input())
+	if err := (protojson.UnmarshalOptions{Resolver: inv.desc}).Unmarshal(raw, msg); err != nil {
+		return nil, fmt.Errorf("failed to parse request as %s: %w", inv.method.Input().FullName(), err)
+	}
+	return msg, nil
+}
+
+func (inv *invocation) sendRequest(call *vsrpc.Call, raw []byte) error {
+	msg, err := inv.parseRequest(raw)
+	if err != nil {
+		return err
+	}
+	payload, err := anypb.New(msg)
+	if err != nil {
+		return err
+	}
+	return call.Send(payload)
+}
+
+func (inv *invocation) writeResponse(payload *anypb.Any) error {
+	var msg proto.Message = payload
+	if payload.MessageName() == inv.method.Output().FullName() {
+		m := dynamicpb.NewMessage(inv.method.Output())
+		if err := proto.Unmarshal(payload.Value, m); err != nil {
+			return fmt.Errorf("failed to decode response as %s: %w", inv.method.Output().FullName(), err)
+		}
+		msg = m
+	}
+
+	raw, err := protojson.MarshalOptions{Resolver: inv.desc}.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(inv.stdout, "%s\n", raw)
+	return err
+}
+
+type requestSource interface {
+	Next() ([]byte, bool, error)
+}
+
+type listSource struct {
+	list []string
+}
+
+func (src *listSource) Next() ([]byte, bool, error) {
+	if len(src.list) <= 0 {
+		return nil, false, nil
+	}
+	item := src.list[0]
+	src.list = src.list[1:]
+	return []byte(item), true, nil
+}
+
+type stdinSource struct {
+	r *bufio.Reader
+}
+
+func (src *stdinSource) Next() ([]byte, bool, error) {
+	for {
+		line, err := src.r.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, true, nil
+		}
+		if errors.Is(err, io.EOF) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+var (
+	_ requestSource = (*listSource)(nil)
+	_ requestSource = (*stdinSource)(nil)
+)
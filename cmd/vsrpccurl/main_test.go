@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/chronos-tachyon/vsrpc"
+	"github.com/chronos-tachyon/vsrpc/example"
+	"github.com/chronos-tachyon/vsrpc/vsrpctest"
+)
+
+func TestRun(t *testing.T) {
+	mux := &vsrpc.HandlerMux{}
+	example.RegisterExampleApiServer(mux, vsrpctest.ReferenceServer{})
+	lb, err := vsrpc.NewLoopback(context.Background(), mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lb.Close() }()
+
+	transports["pipe"] = func(config TransportConfig, addr string) (vsrpc.PacketDialer, net.Addr, error) {
+		return lb.Dialer, lb.Server.Addr(), nil
+	}
+	defer delete(transports, "pipe")
+
+	type testCase struct {
+		Name         string
+		Args         []string
+		Stdin        string
+		ExpectCode   int
+		ExpectStdout string
+		ExpectStderr string
+	}
+
+	testCases := []testCase{
+		{
+			Name:         "unary",
+			Args:         []string{"vsrpc.ExampleApi.OneInOneOut", "-d", `{"value": 7}`},
+			ExpectCode:   exitOK,
+			ExpectStdout: `{"value":"7"}` + "\n",
+			ExpectStderr: `"code":"OK"`,
+		},
+		{
+			Name:         "streaming-input",
+			Args:         []string{"vsrpc.ExampleApi/ManyInOneOut"},
+			Stdin:        "{\"value\": 1}\n\n{\"value\": 2}\n{\"value\": 3}\n",
+			ExpectCode:   exitOK,
+			ExpectStdout: `{"value":"6"}` + "\n",
+			ExpectStderr: `"code":"OK"`,
+		},
+		{
+			Name:         "streaming-output",
+			Args:         []string{"vsrpc.ExampleApi.OneInManyOut", "-d", `{"value": 2}`},
+			ExpectCode:   exitOK,
+			ExpectStdout: "{\"value\":\"1\"}\n{\"value\":\"2\"}\n",
+			ExpectStderr: `"code":"OK"`,
+		},
+		{
+			// The parse error is reported, not the CANCELLED status of
+			// the call that it cancels.
+			Name:         "bad-json",
+			Args:         []string{"vsrpc.ExampleApi.ManyInOneOut"},
+			Stdin:        "{\"value\": 1}\n{\"value\": \"seven\"}\n",
+			ExpectCode:   exitError,
+			ExpectStderr: "failed to parse request as vsrpc.ExampleRequest",
+		},
+		{
+			Name:         "bad-json-flag",
+			Args:         []string{"vsrpc.ExampleApi.OneInOneOut", "-d", `{"value": "seven"}`},
+			ExpectCode:   exitUsage,
+			ExpectStderr: "failed to parse request as vsrpc.ExampleRequest",
+		},
+		{
+			Name:         "unknown-method",
+			Args:         []string{"vsrpc.ExampleApi.Bogus"},
+			ExpectCode:   exitError,
+			ExpectStderr: `service "vsrpc.ExampleApi" has no method "Bogus"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"pipe:loopback"}, tc.Args...)
+			code := run(args, strings.NewReader(tc.Stdin), &stdout, &stderr)
+			if code != tc.ExpectCode {
+				t.Errorf("expected exit code %d, got %d: %s", tc.ExpectCode, code, stderr.String())
+			}
+
+			// protojson deliberately varies its spacing between builds.
+			if actual := strings.ReplaceAll(stdout.String(), " ", ""); actual != tc.ExpectStdout {
+				t.Errorf("expected stdout %q, got %q", tc.ExpectStdout, actual)
+			}
+			if actual := strings.ReplaceAll(stderr.String(), " ", ""); !strings.Contains(actual, strings.ReplaceAll(tc.ExpectStderr, " ", "")) {
+				t.Errorf("expected stderr containing %q, got %q", tc.ExpectStderr, stderr.String())
+			}
+		})
+	}
+}
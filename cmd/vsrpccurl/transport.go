@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+type TransportConfig struct {
+	MaxPacketSize uint
+}
+
+type TransportFunc func(config TransportConfig, addr string) (vsrpc.PacketDialer, net.Addr, error)
+
+var transports = map[string]TransportFunc{
+	"unix":       dialUnix,
+	"unixpacket": dialUnix,
+	"ws":         dialWebSocket("ws"),
+	"wss":        dialWebSocket("wss"),
+}
+
+const defaultTransport = "unixpacket"
+
+// ParseTarget splits a target of the form "transport:address" and returns a
+// dialer and address for it.  A target with no recognized transport prefix is
+// treated as the path to a unixpacket socket.
+func ParseTarget(config TransportConfig, target string) (vsrpc.PacketDialer, net.Addr, error) {
+	name, addr := defaultTransport, target
+	if i := strings.IndexByte(target, ':'); i >= 0 {
+		if _, found := transports[target[:i]]; found {
+			name, addr = target[:i], target[i+1:]
+		}
+	}
+	if addr == "" {
+		return nil, nil, fmt.Errorf("invalid target %q: missing address", target)
+	}
+	return transports[name](config, addr)
+}
+
+func transportNames() []string {
+	names := make([]string, 0, len(transports))
+	for name := range transports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func dialUnix(config TransportConfig, addr string) (vsrpc.PacketDialer, net.Addr, error) {
+	pd := &vsrpc.UnixDialer{MaxPacketSize: config.MaxPacketSize}
+	return pd, &net.UnixAddr{Net: "unixpacket", Name: addr}, nil
+}
+
+// dialWebSocket returns a TransportFunc for the given URL scheme.  The scheme
+// is consumed by ParseTarget, so "ws://host/path" arrives as "//host/path".
+func dialWebSocket(scheme string) TransportFunc {
+	return func(config TransportConfig, addr string) (vsrpc.PacketDialer, net.Addr, error) {
+		if !strings.HasPrefix(addr, "//") {
+			return nil, nil, fmt.Errorf("invalid %s target %q: expected %s://host[:port]/path", scheme, scheme+":"+addr, scheme)
+		}
+		pd := &vsrpc.WebSocketDialer{MaxPacketSize: config.MaxPacketSize}
+		return pd, vsrpc.WebSocketAddr(scheme + ":" + addr), nil
+	}
+}
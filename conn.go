@@ -5,21 +5,33 @@ import (
 	"net"
 	"sync"
 
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Conn struct {
 	options   []Option
 	observers []Observer
+	clock     Clock
 	pc        PacketConn
 	c         *Client
 	s         *Server
 	role      Role
 
+	panicPolicy PanicPolicy
+	writer      *connWriter
+	handler     Handler
+	bidiOption  bool
+
+	// received counts the frames read so far.  Only readThread uses it.
+	received uint64
+
 	mu    sync.Mutex
 	calls map[ID]*Call
 	id    ID
 	state state
+	hello bool
+	bidi  bool
 }
 
 func newConn(role Role, c *Client, s *Server, pc PacketConn, options []Option) *Conn {
@@ -30,6 +42,10 @@ func newConn(role Role, c *Client, s *Server, pc PacketConn, options []Option) *
 		s:       s,
 		role:    role,
 	}
+	conn.writer = newConnWriter(conn)
+	if s != nil {
+		conn.handler = s.h
+	}
 	for _, opt := range options {
 		opt.applyToConn(conn)
 	}
@@ -57,6 +73,13 @@ func (conn *Conn) Server() *Server {
 	return conn.s
 }
 
+func (conn *Conn) Clock() Clock {
+	if conn == nil {
+		return SystemClock{}
+	}
+	return clockOrDefault(conn.clock)
+}
+
 func (conn *Conn) PacketConn() PacketConn {
 	if conn == nil {
 		return nil
@@ -82,13 +105,13 @@ func (conn *Conn) Begin(ctx context.Context, method Method, options ...Option) (
 	if conn == nil {
 		return nil, InappropriateError{Op: "Begin", Role: UnknownRole}
 	}
-	if conn.role != ClientRole {
-		return nil, InappropriateError{Op: "Begin", Role: conn.role}
-	}
 
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
 
+	if conn.role != ClientRole && !conn.bidi {
+		return nil, InappropriateError{Op: "Begin", Role: conn.role}
+	}
 	if conn.state >= stateClosed {
 		return nil, ErrConnClosed
 	}
@@ -99,30 +122,44 @@ func (conn *Conn) Begin(ctx context.Context, method Method, options ...Option) (
 		return nil, ErrConnShuttingDown
 	}
 
-	id := conn.id
-	if numCalls := uint(len(conn.calls)); id == 0 || numCalls == 0 || uint(id) > (2*numCalls) {
-		id = 1
-	}
-	_, found := conn.calls[id]
-	for found {
-		id++
-		_, found = conn.calls[id]
-	}
-	conn.id = id
+	id := conn.lockedNextID()
 
 	ctx = WithContextClient(ctx, conn.c)
+	ctx = WithContextServer(ctx, conn.s)
 	ctx = WithContextConn(ctx, conn)
 
-	if err := WriteBegin(ctx, conn.pc, id, method); err != nil {
-		return nil, conn.lockedGotWriteError(err)
-	}
-
 	options = ConcatOptions(conn.options, options...)
-	call := newCall(ctx, ClientRole, conn, id, method, nil, options)
+	call := newCall(ctx, ClientRole, conn, id, method, nil, nil, 0, options)
+	pw, err := conn.writer.queueLate(ctx, call, true, func(w PacketWriter) error {
+		return WriteBegin(call.ctxInner, w, id, method, call.priority)
+	})
+	if err != nil {
+		call.cancel()
+		return nil, err
+	}
 	if conn.calls == nil {
 		conn.calls = make(map[ID]*Call, 16)
 	}
 	conn.calls[id] = call
+	if call.parent != nil && call.propagation != nil && call.propagation.Cancel && !call.parent.addChild(call) {
+		go call.Cancel()
+	}
+	conn.mu.Unlock()
+
+	err = conn.writer.wait(pw)
+
+	conn.mu.Lock()
+	if err != nil {
+		if conn.calls[id] == call {
+			delete(conn.calls, id)
+		}
+		conn.writer.forget(call)
+		call.cancel()
+		if call.parent != nil {
+			go call.parent.forgetChild(call)
+		}
+		return nil, err
+	}
 	return call, nil
 }
 
@@ -131,48 +168,63 @@ func (conn *Conn) Shutdown(ctx context.Context) error {
 		return ErrConnClosed
 	}
 
+	pw, err := conn.queueShutdown(ctx)
+	if pw == nil || err != nil {
+		return err
+	}
+	return conn.writer.wait(pw)
+}
+
+func (conn *Conn) queueShutdown(ctx context.Context) (*pendingWrite, error) {
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
 
 	if conn.state >= stateClosed {
-		return ErrConnClosed
+		return nil, ErrConnClosed
 	}
 
 	switch conn.role {
 	case ClientRole:
 		if conn.state >= stateShuttingDown {
-			return nil
+			return nil, nil
 		}
 
 		ctx = WithContextClient(ctx, conn.c)
 		ctx = WithContextConn(ctx, conn)
 
-		if err := WriteShutdown(ctx, conn.pc); err != nil {
-			return conn.lockedGotWriteError(err)
+		pw, err := conn.writer.queue(ctx, nil, true, func(w PacketWriter) error {
+			return WriteShutdown(ctx, w)
+		})
+		if err != nil {
+			return nil, err
 		}
 
 		conn.state = stateShuttingDown
 		onShutdown(conn.observers, conn)
+		return pw, nil
 
 	case ServerRole:
 		if conn.state >= stateGoingAway {
-			return nil
+			return nil, nil
 		}
 
 		ctx = WithContextServer(ctx, conn.s)
 		ctx = WithContextConn(ctx, conn)
 
-		if err := WriteGoAway(ctx, conn.pc); err != nil {
-			return conn.lockedGotWriteError(err)
+		pw, err := conn.writer.queue(ctx, nil, true, func(w PacketWriter) error {
+			return WriteGoAway(ctx, w)
+		})
+		if err != nil {
+			return nil, err
 		}
 
 		conn.state = stateGoingAway
 		onGoAway(conn.observers, conn)
+		return pw, nil
 
 	default:
 		panic("unreachable")
 	}
-	return nil
 }
 
 func (conn *Conn) Close() error {
@@ -186,23 +238,45 @@ func (conn *Conn) Close() error {
 }
 
 func (conn *Conn) start() {
+	if conn.role == ClientRole && conn.bidiOption {
+		ctx := context.Background()
+		ctx = WithContextClient(ctx, conn.c)
+		ctx = WithContextConn(ctx, conn)
+		_, _ = conn.writer.queue(ctx, nil, false, func(w PacketWriter) error {
+			return WriteHello(ctx, w, true)
+		})
+	}
 	go conn.readThread()
+	go conn.writer.writeThread()
 }
 
+// maxReadErrors is the number of recoverable read errors in a row after which
+// readThread gives up and closes the Conn, so that a PacketConn that keeps
+// failing without closing cannot keep it spinning.
+const maxReadErrors = 16
+
 func (conn *Conn) readThread() {
 	ctx := context.Background()
 	ctx = WithContextClient(ctx, conn.c)
 	ctx = WithContextServer(ctx, conn.s)
 	ctx = WithContextConn(ctx, conn)
-	for {
+	readErrors := 0
+	looping := true
+	for looping {
 		var frame Frame
-		if err := ReadFrame(ctx, conn.pc, &frame); err != nil {
-			conn.gotReadError(err)
-			return
+		err := ReadFrame(ctx, conn.pc, &frame)
+		if err == nil {
+			readErrors = 0
+			conn.received++
+			err = conn.dispatch(ctx, &frame)
+		} else {
+			readErrors++
+			if readErrors >= maxReadErrors {
+				err = UnrecoverableError{Err: err}
+			}
 		}
-		if err := conn.dispatch(ctx, &frame); err != nil {
-			conn.gotReadError(err)
-			return
+		if err != nil {
+			looping = conn.gotReadError(err)
 		}
 	}
 }
@@ -226,12 +300,14 @@ func (conn *Conn) dispatch(ctx context.Context, frame *Frame) error {
 	switch frameType {
 	case Frame_NO_OP:
 		return conn.gotNoOp()
+	case Frame_HELLO:
+		return conn.gotHello(ctx, frame.Bidirectional, conn.received == 1)
 	case Frame_SHUTDOWN:
 		return conn.gotShutdown()
 	case Frame_GO_AWAY:
 		return conn.gotGoAway()
 	case Frame_BEGIN:
-		return conn.gotBegin(ctx, id, method, deadline)
+		return conn.gotBegin(ctx, id, method, deadline, frame.Timeout, frame.Priority)
 	case Frame_REQUEST:
 		return conn.findCall(id).gotRequest(payload)
 	case Frame_RESPONSE:
@@ -285,28 +361,39 @@ func (conn *Conn) gotGoAway() error {
 	return nil
 }
 
-func (conn *Conn) gotBegin(ctx context.Context, id ID, method Method, deadline *timestamppb.Timestamp) error {
-	if conn.role != ServerRole {
-		return ProtocolViolationError{Err: FrameTypeError{Type: Frame_BEGIN}}
-	}
-
+func (conn *Conn) gotBegin(ctx context.Context, id ID, method Method, deadline *timestamppb.Timestamp, timeout *durationpb.Duration, priority uint32) error {
 	if deadline != nil {
 		if err := deadline.CheckValid(); err != nil {
 			return ProtocolViolationError{Err: err}
 		}
 	}
+	if timeout != nil {
+		if err := timeout.CheckValid(); err != nil {
+			return ProtocolViolationError{Err: err}
+		}
+	}
 
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
 
+	if conn.role != ServerRole && !conn.bidi {
+		return ProtocolViolationError{Err: FrameTypeError{Type: Frame_BEGIN}}
+	}
+	if !conn.lockedPeerID(id) {
+		return ProtocolViolationError{Err: CallIdError{Type: Frame_BEGIN, ID: id}}
+	}
+
 	if call := conn.calls[id]; call != nil {
 		return ProtocolViolationError{Err: DuplicateCallError{ID: id, Old: call.method, New: method}}
 	}
 	if conn.state >= stateShuttingDown {
+		_, _ = conn.writer.queue(ctx, nil, false, func(w PacketWriter) error {
+			return WriteEnd(ctx, w, id, Abort(ErrConnGoingAway))
+		})
 		return nil
 	}
 
-	call := newCall(ctx, ServerRole, conn, id, method, deadline, conn.options)
+	call := newCall(ctx, ServerRole, conn, id, method, deadline, timeout, clampPriority(priority), conn.options)
 	if conn.calls == nil {
 		conn.calls = make(map[ID]*Call, 16)
 	}
@@ -317,15 +404,30 @@ func (conn *Conn) gotBegin(ctx context.Context, id ID, method Method, deadline *
 }
 
 func (conn *Conn) handle(call *Call) {
-	h := conn.Server().Handler()
+	h := conn.handler
+	if h == nil {
+		h = HandlerFunc(nil)
+	}
 	err := try(func() error { return h.Handle(call) })
-	_ = call.End(StatusFromError(err))
+	status := StatusFromError(err)
+
+	perr, isPanic := err.(PanicError)
+	if isPanic {
+		conn.gotPanic(call, perr, status)
+	}
+
+	_ = call.End(status)
+
+	if isPanic && conn.panicPolicy.Crash {
+		crashProcess(perr)
+	}
 }
 
-func (conn *Conn) gotReadError(err error) {
+func (conn *Conn) gotReadError(err error) bool {
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
 	conn.lockedGotReadError(err)
+	return conn.state < stateClosed
 }
 
 func (conn *Conn) lockedGotReadError(err error) {
@@ -356,8 +458,11 @@ func (conn *Conn) lockedClose() error {
 
 	err := try(conn.pc.Close)
 	conn.state = stateClosed
+	conn.writer.close()
+	// Calls never lock conn.mu while holding call.mu, and no longer write
+	// while holding it, so the calls can end before Close returns.
 	for _, call := range conn.calls {
-		call.gotEnd(Abort(ErrConnClosed))
+		call.gotConnClose()
 	}
 	conn.calls = nil
 	onClose(conn.observers, conn, err)
@@ -380,4 +485,5 @@ func (conn *Conn) forgetCall(call *Call) {
 		delete(conn.calls, call.id)
 	}
 	conn.mu.Unlock()
+	conn.writer.forget(call)
 }
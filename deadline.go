@@ -0,0 +1,109 @@
+package vsrpc
+
+import (
+	"time"
+)
+
+// WithDeadline sets a deadline for each Call.  The deadline of a Call is the
+// earliest of those set by its options, its MethodPolicy, and, for a Call
+// begun by the peer, the BEGIN frame.  A Call begun locally also inherits the
+// deadline of the context passed to Conn.Begin.
+func WithDeadline(deadline time.Time) Option {
+	return &withDeadline{deadline: deadline}
+}
+
+type withDeadline struct {
+	deadline time.Time
+}
+
+func (opt *withDeadline) applyToClient(c *Client) {}
+
+func (opt *withDeadline) applyToServer(s *Server) {}
+
+func (opt *withDeadline) applyToConn(conn *Conn) {}
+
+func (opt *withDeadline) applyToCall(call *Call) {
+	if opt == nil || call == nil {
+		return
+	}
+	call.shortenDeadline(opt.deadline)
+}
+
+var _ Option = (*withDeadline)(nil)
+
+// WithTimeout is like WithDeadline, but the deadline is the given duration
+// after the Call begins, as measured by the Clock of the Call.  Zero means no
+// timeout.  Given to a Client or a Server, it sets a default timeout for
+// every Call.
+func WithTimeout(timeout time.Duration) Option {
+	return &withTimeout{timeout: timeout}
+}
+
+type withTimeout struct {
+	timeout time.Duration
+}
+
+func (opt *withTimeout) applyToClient(c *Client) {}
+
+func (opt *withTimeout) applyToServer(s *Server) {}
+
+func (opt *withTimeout) applyToConn(conn *Conn) {}
+
+func (opt *withTimeout) applyToCall(call *Call) {
+	if opt == nil || call == nil {
+		return
+	}
+	call.timeout = opt.timeout
+}
+
+var _ Option = (*withTimeout)(nil)
+
+// WithMaxCallDuration limits how long each Call begun by the peer may run,
+// whatever deadline the peer asked for.  Zero means no limit.  It does not
+// apply to Calls begun locally.
+func WithMaxCallDuration(max time.Duration) Option {
+	return &withMaxCallDuration{max: max}
+}
+
+type withMaxCallDuration struct {
+	max time.Duration
+}
+
+func (opt *withMaxCallDuration) applyToClient(c *Client) {}
+
+func (opt *withMaxCallDuration) applyToServer(s *Server) {}
+
+func (opt *withMaxCallDuration) applyToConn(conn *Conn) {}
+
+func (opt *withMaxCallDuration) applyToCall(call *Call) {
+	if opt == nil || call == nil {
+		return
+	}
+	call.maxDuration = opt.max
+}
+
+var _ Option = (*withMaxCallDuration)(nil)
+
+// Deadline returns the deadline of the Call, if it has one.
+func (call *Call) Deadline() (time.Time, bool) {
+	if call == nil {
+		return time.Time{}, false
+	}
+
+	call.mu.Lock()
+	defer call.mu.Unlock()
+
+	if call.deadline.IsZero() {
+		return time.Time{}, false
+	}
+	return call.deadline, true
+}
+
+func (call *Call) shortenDeadline(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if call.deadline.IsZero() || t.Before(call.deadline) {
+		call.deadline = t
+	}
+}
@@ -0,0 +1,177 @@
+package vsrpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCallDeadline(t *testing.T) {
+	type testCase struct {
+		Name          string
+		ServerOptions []Option
+		CallOptions   []Option
+		Expect        time.Duration
+	}
+
+	testCases := []testCase{
+		{Name: "none"},
+		{Name: "timeout", CallOptions: []Option{WithTimeout(time.Hour)}, Expect: time.Hour},
+		{Name: "deadline", CallOptions: []Option{WithDeadline(time.Now().Add(time.Hour))}, Expect: time.Hour},
+		{Name: "earliest", CallOptions: []Option{WithTimeout(time.Hour), WithDeadline(time.Now().Add(time.Minute))}, Expect: time.Minute},
+		{Name: "max", ServerOptions: []Option{WithMaxCallDuration(time.Minute)}, Expect: time.Minute},
+		{Name: "max-caps", ServerOptions: []Option{WithMaxCallDuration(time.Minute)}, CallOptions: []Option{WithTimeout(time.Hour)}, Expect: time.Minute},
+		{Name: "max-loose", ServerOptions: []Option{WithMaxCallDuration(time.Hour)}, CallOptions: []Option{WithTimeout(time.Minute)}, Expect: time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			deadlines := make(chan time.Time, 1)
+			handler := HandlerFunc(func(call *Call) error {
+				deadline, _ := call.Deadline()
+				deadlines <- deadline
+				return nil
+			})
+
+			lb, err := NewLoopback(context.Background(), handler, tc.ServerOptions...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer lb.Close()
+
+			start := time.Now()
+			call, err := lb.Conn.Begin(context.Background(), "Test", tc.CallOptions...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status := call.Wait(); status.Code != Status_OK {
+				t.Fatal(status)
+			}
+
+			deadline := <-deadlines
+			if tc.Expect == 0 {
+				if !deadline.IsZero() {
+					t.Errorf("expected no deadline, got %v", deadline)
+				}
+				return
+			}
+			if d := deadline.Sub(start); d > tc.Expect+time.Second || d < tc.Expect-time.Second {
+				t.Errorf("expected a deadline %v from now, got %v", tc.Expect, d)
+			}
+		})
+	}
+}
+
+func TestCallDeadline_Skew(t *testing.T) {
+	// The peer's clock is an hour fast, but the relative timeout still gives
+	// the right deadline.
+	now := time.Now()
+	skewed := timestamppb.New(now.Add(time.Hour + time.Minute))
+	call := newCall(context.Background(), ServerRole, nil, 1, "Test", skewed, durationpb.New(time.Minute), 0, nil)
+	defer call.cancel()
+
+	deadline, ok := call.Deadline()
+	if d := deadline.Sub(now); !ok || d > time.Minute+time.Second || d < time.Minute {
+		t.Errorf("expected a deadline %v from now, got %v ok=%v", time.Minute, d, ok)
+	}
+
+	// Without a timeout, the absolute deadline is all there is.
+	call = newCall(context.Background(), ServerRole, nil, 1, "Test", skewed, nil, 0, nil)
+	defer call.cancel()
+
+	if deadline, ok := call.Deadline(); !ok || !deadline.Equal(skewed.AsTime()) {
+		t.Errorf("expected %v, got %v ok=%v", skewed.AsTime(), deadline, ok)
+	}
+}
+
+func TestWriteBegin_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pw := &pendingWrite{}
+	if err := WriteBegin(ctx, pw, 1, "Test", 0); err != nil {
+		t.Fatal(err)
+	}
+	var frame Frame
+	if err := ReadFrame(ctx, packetReaderFunc(pw.raw), &frame); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline, _ := ctx.Deadline()
+	if !frame.Deadline.AsTime().Equal(deadline) {
+		t.Errorf("expected deadline %v, got %v", deadline, frame.Deadline.AsTime())
+	}
+	if d := frame.Timeout.AsDuration(); d > time.Minute || d < time.Minute-time.Second {
+		t.Errorf("expected a timeout of %v, got %v", time.Minute, d)
+	}
+}
+
+type packetReaderFunc []byte
+
+func (p packetReaderFunc) ReadPacket(ctx context.Context) ([]byte, func(), error) {
+	return p, func() {}, nil
+}
+
+// fakeClock is a Clock that only moves when told to.  Its timers never fire.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+func (clock *fakeClock) Advance(d time.Duration) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	clock.now = clock.now.Add(d)
+}
+
+func (clock *fakeClock) AfterFunc(d time.Duration, fn func()) Timer {
+	return fakeTimer{}
+}
+
+type fakeTimer struct{}
+
+func (fakeTimer) Stop() bool { return true }
+
+func TestWriteBegin_TimeoutAfterQueueing(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000000, 0)}
+	pc := &recordingConn{}
+	conn := newConn(ClientRole, nil, nil, pc, []Option{WithClock(clock)})
+	defer conn.writer.close()
+
+	// The time that BEGIN spends waiting in the writer's queue comes out of
+	// the timeout that the peer sees.
+	ctx := context.Background()
+	call := newCall(ctx, ClientRole, conn, 1, "Test", nil, nil, 0, []Option{WithClock(clock), WithTimeout(time.Minute)})
+	defer call.cancel()
+	pw, err := conn.writer.queueLate(ctx, call, true, func(w PacketWriter) error {
+		return WriteBegin(call.ctxInner, w, 1, "Test", 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(20 * time.Second)
+	go conn.writer.writeThread()
+	if err := conn.writer.wait(pw); err != nil {
+		t.Fatal(err)
+	}
+
+	var frame Frame
+	if err := proto.Unmarshal([]byte(pc.packets[0]), &frame); err != nil {
+		t.Fatal(err)
+	}
+	if d := frame.Timeout.AsDuration(); d != 40*time.Second {
+		t.Errorf("expected a timeout of %v, got %v", 40*time.Second, d)
+	}
+}
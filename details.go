@@ -0,0 +1,264 @@
+package vsrpc
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/chronos-tachyon/vsrpc/prettyprinter"
+)
+
+func NewRetryInfo(delay time.Duration) *RetryInfo {
+	return &RetryInfo{RetryDelay: durationpb.New(delay)}
+}
+
+func NewBadRequest(violations ...*BadRequest_FieldViolation) *BadRequest {
+	return &BadRequest{FieldViolations: violations}
+}
+
+func NewFieldViolation(field string, description string) *BadRequest_FieldViolation {
+	return &BadRequest_FieldViolation{Field: field, Description: description}
+}
+
+func NewQuotaFailure(violations ...*QuotaFailure_Violation) *QuotaFailure {
+	return &QuotaFailure{Violations: violations}
+}
+
+func NewQuotaViolation(subject string, description string) *QuotaFailure_Violation {
+	return &QuotaFailure_Violation{Subject: subject, Description: description}
+}
+
+func NewResourceInfo(resourceType string, resourceName string, owner string, description string) *ResourceInfo {
+	return &ResourceInfo{
+		ResourceType: resourceType,
+		ResourceName: resourceName,
+		Owner:        owner,
+		Description:  description,
+	}
+}
+
+// NewDebugInfo returns a DebugInfo holding detail and the stack of the
+// caller.  The skip argument is the number of additional stack frames to
+// leave out, as with runtime.Caller.
+func NewDebugInfo(detail string, skip int) *DebugInfo {
+	var pcs [64]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	info := &DebugInfo{Detail: detail}
+	for {
+		frame, more := frames.Next()
+		info.StackEntries = append(info.StackEntries, fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line))
+		if !more {
+			break
+		}
+	}
+	return info
+}
+
+func NewErrorInfo(reason string, domain string, metadata map[string]string) *ErrorInfo {
+	return &ErrorInfo{Reason: reason, Domain: domain, Metadata: metadata}
+}
+
+// AddDetails appends each message to status.Details.
+func (status *Status) AddDetails(details ...proto.Message) error {
+	for _, detail := range details {
+		if detail == nil {
+			continue
+		}
+		value, err := anypb.New(detail)
+		if err != nil {
+			return err
+		}
+		status.Details = append(status.Details, value)
+	}
+	return nil
+}
+
+// FindDetail unmarshals the first detail whose type matches out into out,
+// and reports whether there was one.
+func (status *Status) FindDetail(out proto.Message) bool {
+	name := MessageType(out)
+	for _, detail := range status.GetDetails() {
+		if detail.MessageName() == name {
+			return UnmarshalAny(out, detail) == nil
+		}
+	}
+	return false
+}
+
+// RetryInfo returns the first RetryInfo detail, or nil.
+func (status *Status) RetryInfo() *RetryInfo {
+	return findDetail[RetryInfo](status)
+}
+
+// BadRequest returns the first BadRequest detail, or nil.
+func (status *Status) BadRequest() *BadRequest {
+	return findDetail[BadRequest](status)
+}
+
+// QuotaFailure returns the first QuotaFailure detail, or nil.
+func (status *Status) QuotaFailure() *QuotaFailure {
+	return findDetail[QuotaFailure](status)
+}
+
+// ResourceInfo returns the first ResourceInfo detail, or nil.
+func (status *Status) ResourceInfo() *ResourceInfo {
+	return findDetail[ResourceInfo](status)
+}
+
+// DebugInfo returns the first DebugInfo detail, or nil.
+func (status *Status) DebugInfo() *DebugInfo {
+	return findDetail[DebugInfo](status)
+}
+
+// ErrorInfo returns the first ErrorInfo detail, or nil.
+func (status *Status) ErrorInfo() *ErrorInfo {
+	return findDetail[ErrorInfo](status)
+}
+
+func findDetail[T any, PT interface {
+	*T
+	proto.Message
+}](status *Status) PT {
+	out := PT(new(T))
+	if status.FindDetail(out) {
+		return out
+	}
+	return nil
+}
+
+// The detail types are named by string, because this package's init runs
+// before the generated code has initialized the message types.
+func init() {
+	reg := prettyprinter.Global()
+	reg.Add("vsrpc.RetryInfo", detailPrinter(prettyPrintRetryInfo))
+	reg.Add("vsrpc.BadRequest", detailPrinter(prettyPrintBadRequest))
+	reg.Add("vsrpc.QuotaFailure", detailPrinter(prettyPrintQuotaFailure))
+	reg.Add("vsrpc.ResourceInfo", detailPrinter(prettyPrintResourceInfo))
+	reg.Add("vsrpc.DebugInfo", detailPrinter(prettyPrintDebugInfo))
+	reg.Add("vsrpc.ErrorInfo", detailPrinter(prettyPrintErrorInfo))
+}
+
+func detailPrinter[T any, PT interface {
+	*T
+	proto.Message
+}](fn func(buf []byte, msg PT) []byte) prettyprinter.Func {
+	return func(buf []byte, detail *anypb.Any) []byte {
+		msg := PT(new(T))
+		if err := detail.UnmarshalTo(msg); err != nil {
+			return buf
+		}
+		return fn(buf, msg)
+	}
+}
+
+func prettyPrintRetryInfo(buf []byte, msg *RetryInfo) []byte {
+	buf = append(buf, "; retry after "...)
+	buf = append(buf, msg.GetRetryDelay().AsDuration().String()...)
+	return buf
+}
+
+func prettyPrintBadRequest(buf []byte, msg *BadRequest) []byte {
+	buf = append(buf, "; bad request"...)
+	for index, v := range msg.GetFieldViolations() {
+		if index == 0 {
+			buf = append(buf, ':', ' ')
+		} else {
+			buf = append(buf, ',', ' ')
+		}
+		buf = append(buf, "field "...)
+		buf = strconv.AppendQuote(buf, v.GetField())
+		if v.GetDescription() != "" {
+			buf = append(buf, ':', ' ')
+			buf = append(buf, v.GetDescription()...)
+		}
+	}
+	return buf
+}
+
+func prettyPrintQuotaFailure(buf []byte, msg *QuotaFailure) []byte {
+	buf = append(buf, "; quota exceeded"...)
+	for index, v := range msg.GetViolations() {
+		if index == 0 {
+			buf = append(buf, ':', ' ')
+		} else {
+			buf = append(buf, ',', ' ')
+		}
+		buf = append(buf, v.GetSubject()...)
+		if v.GetDescription() != "" {
+			buf = append(buf, ':', ' ')
+			buf = append(buf, v.GetDescription()...)
+		}
+	}
+	return buf
+}
+
+func prettyPrintResourceInfo(buf []byte, msg *ResourceInfo) []byte {
+	buf = append(buf, "; resource "...)
+	if msg.GetResourceType() != "" {
+		buf = append(buf, msg.GetResourceType()...)
+		buf = append(buf, ' ')
+	}
+	buf = strconv.AppendQuote(buf, msg.GetResourceName())
+	if msg.GetOwner() != "" {
+		buf = append(buf, " owned by "...)
+		buf = strconv.AppendQuote(buf, msg.GetOwner())
+	}
+	if msg.GetDescription() != "" {
+		buf = append(buf, ':', ' ')
+		buf = append(buf, msg.GetDescription()...)
+	}
+	return buf
+}
+
+func prettyPrintDebugInfo(buf []byte, msg *DebugInfo) []byte {
+	buf = append(buf, "; debug info"...)
+	if msg.GetDetail() != "" {
+		buf = append(buf, ':', ' ')
+		buf = append(buf, msg.GetDetail()...)
+	}
+	for _, entry := range msg.GetStackEntries() {
+		buf = append(buf, "\n\t"...)
+		buf = append(buf, entry...)
+	}
+	return buf
+}
+
+func prettyPrintErrorInfo(buf []byte, msg *ErrorInfo) []byte {
+	buf = append(buf, "; reason "...)
+	buf = append(buf, msg.GetReason()...)
+	if msg.GetDomain() != "" {
+		buf = append(buf, " in "...)
+		buf = append(buf, msg.GetDomain()...)
+	}
+
+	metadata := msg.GetMetadata()
+	if len(metadata) == 0 {
+		return buf
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf = append(buf, ' ', '{')
+	for index, key := range keys {
+		if index > 0 {
+			buf = append(buf, ',', ' ')
+		}
+		buf = append(buf, key...)
+		buf = append(buf, '=')
+		buf = strconv.AppendQuote(buf, metadata[key])
+	}
+	buf = append(buf, '}')
+	return buf
+}
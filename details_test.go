@@ -0,0 +1,91 @@
+package vsrpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStatus_Details(t *testing.T) {
+	status := &Status{Code: Status_INVALID_ARGUMENT, Text: "bad input"}
+	err := status.AddDetails(
+		NewBadRequest(NewFieldViolation("name", "must not be empty")),
+		NewErrorInfo("EMPTY_NAME", "example.com", nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info := status.RetryInfo(); info != nil {
+		t.Errorf("expected no RetryInfo, got %v", info)
+	}
+	if br := status.BadRequest(); br == nil || len(br.FieldViolations) != 1 || br.FieldViolations[0].Field != "name" {
+		t.Errorf("expected a BadRequest for field \"name\", got %v", br)
+	}
+	if info := status.ErrorInfo(); info == nil || info.Reason != "EMPTY_NAME" {
+		t.Errorf("expected an ErrorInfo with reason EMPTY_NAME, got %v", info)
+	}
+
+	info := NewDebugInfo("oops", 0)
+	if len(info.StackEntries) == 0 || !strings.Contains(info.StackEntries[0], "TestStatus_Details") {
+		t.Errorf("expected the stack to start in TestStatus_Details, got %q", info.StackEntries)
+	}
+}
+
+func TestStatusError_Details(t *testing.T) {
+	type testCase struct {
+		Name   string
+		Detail proto.Message
+		Expect string
+	}
+
+	testCases := []testCase{
+		{
+			Name:   "RetryInfo",
+			Detail: NewRetryInfo(1500 * time.Millisecond),
+			Expect: "UNAVAILABLE[14]: nope; retry after 1.5s",
+		},
+		{
+			Name: "BadRequest",
+			Detail: NewBadRequest(
+				NewFieldViolation("name", "must not be empty"),
+				NewFieldViolation("items[2].count", "must be positive"),
+			),
+			Expect: `UNAVAILABLE[14]: nope; bad request: field "name": must not be empty, field "items[2].count": must be positive`,
+		},
+		{
+			Name:   "QuotaFailure",
+			Detail: NewQuotaFailure(NewQuotaViolation("user:alice", "too many calls")),
+			Expect: "UNAVAILABLE[14]: nope; quota exceeded: user:alice: too many calls",
+		},
+		{
+			Name:   "ResourceInfo",
+			Detail: NewResourceInfo("file", "/etc/passwd", "root", "not readable"),
+			Expect: `UNAVAILABLE[14]: nope; resource file "/etc/passwd" owned by "root": not readable`,
+		},
+		{
+			Name:   "DebugInfo",
+			Detail: &DebugInfo{Detail: "oops", StackEntries: []string{"a.F (a.go:1)", "b.G (b.go:2)"}},
+			Expect: "UNAVAILABLE[14]: nope; debug info: oops\n\ta.F (a.go:1)\n\tb.G (b.go:2)",
+		},
+		{
+			Name:   "ErrorInfo",
+			Detail: NewErrorInfo("OVERLOADED", "example.com", map[string]string{"zone": "b", "region": "a"}),
+			Expect: `UNAVAILABLE[14]: nope; reason OVERLOADED in example.com {region="a", zone="b"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			status := &Status{Code: Status_UNAVAILABLE, Text: "nope"}
+			if err := status.AddDetails(tc.Detail); err != nil {
+				t.Fatal(err)
+			}
+			if actual := status.AsError().Error(); actual != tc.Expect {
+				t.Errorf("expected %q, got %q", tc.Expect, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package vsrpc
+
+import (
+	"encoding"
+	"fmt"
+)
+
+type FaultType uint
+
+const (
+	FaultType_None FaultType = iota
+	FaultType_Delay
+	FaultType_Drop
+	FaultType_Duplicate
+	FaultType_Truncate
+	FaultType_Error
+	FaultType_Close
+)
+
+var faultTypeGoNames = [...]string{
+	"vsrpc.FaultType_None",
+	"vsrpc.FaultType_Delay",
+	"vsrpc.FaultType_Drop",
+	"vsrpc.FaultType_Duplicate",
+	"vsrpc.FaultType_Truncate",
+	"vsrpc.FaultType_Error",
+	"vsrpc.FaultType_Close",
+}
+
+var faultTypeNames = [...]string{
+	"none",
+	"delay",
+	"drop",
+	"duplicate",
+	"truncate",
+	"error",
+	"close",
+}
+
+func (enum FaultType) GoString() string {
+	if enum < FaultType(len(faultTypeGoNames)) {
+		return faultTypeGoNames[enum]
+	}
+	return fmt.Sprintf("vsrpc.FaultType(%d)", uint(enum))
+}
+
+func (enum FaultType) String() string {
+	if enum < FaultType(len(faultTypeNames)) {
+		return faultTypeNames[enum]
+	}
+	return fmt.Sprintf("#%d", uint(enum))
+}
+
+func (enum FaultType) MarshalText() ([]byte, error) {
+	str := enum.String()
+	return []byte(str), nil
+}
+
+var (
+	_ fmt.GoStringer         = FaultType(0)
+	_ fmt.Stringer           = FaultType(0)
+	_ encoding.TextMarshaler = FaultType(0)
+)
@@ -0,0 +1,59 @@
+package vsrpc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AuthError is returned when a call does not satisfy the AuthPolicy of its
+// method.  Err is the error returned by the Authorizer, or nil if the Server
+// has no Authorizer at all.
+type AuthError struct {
+	Method Method
+	Err    error
+}
+
+func (err AuthError) Error() string {
+	if err.Err == nil {
+		return fmt.Sprintf("method %q requires authorization, but no Authorizer is configured", err.Method)
+	}
+	return fmt.Sprintf("method %q: authorization failed: %v", err.Method, err.Err)
+}
+
+func (err AuthError) Unwrap() error {
+	return err.Err
+}
+
+func (err AuthError) Is(target error) bool {
+	return isStatusCode(err, target)
+}
+
+func (err AuthError) As(out any) bool {
+	switch x := out.(type) {
+	case *StatusError:
+		var serr StatusError
+		if errors.As(err.Err, &serr) {
+			x.Status = serr.Status
+			return true
+		}
+		code := Status_PERMISSION_DENIED
+		if err.Err == nil {
+			code = Status_UNAUTHENTICATED
+		}
+		x.Status = &Status{
+			Code: code,
+			Text: err.Error(),
+		}
+		return true
+
+	default:
+		return false
+	}
+}
+
+var (
+	_ error           = AuthError{}
+	_ unwrapInterface = AuthError{}
+	_ asInterface     = AuthError{}
+	_ isInterface     = AuthError{}
+)
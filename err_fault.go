@@ -0,0 +1,20 @@
+package vsrpc
+
+import (
+	"fmt"
+)
+
+type FaultError struct {
+	Type    FaultType
+	IsWrite bool
+}
+
+func (err FaultError) Error() string {
+	op := "read"
+	if err.IsWrite {
+		op = "write"
+	}
+	return fmt.Sprintf("injected %v fault on %s", err.Type, op)
+}
+
+var _ error = FaultError{}
@@ -0,0 +1,49 @@
+package vsrpc
+
+import (
+	"fmt"
+)
+
+// MessageSizeError is returned when a request or response is larger than the
+// limit set by the MethodPolicy of its call.  Role is the role of the sender.
+type MessageSizeError struct {
+	Method Method
+	Role   Role
+	Size   uint64
+	Limit  uint64
+}
+
+func (err MessageSizeError) Error() string {
+	return fmt.Sprintf("%v for method %q is %d bytes, which exceeds the limit of %d bytes", err.kind(), err.Method, err.Size, err.Limit)
+}
+
+func (err MessageSizeError) Is(target error) bool {
+	return isStatusCode(err, target)
+}
+
+func (err MessageSizeError) As(out any) bool {
+	switch x := out.(type) {
+	case *StatusError:
+		x.Status = &Status{
+			Code: Status_RESOURCE_EXHAUSTED,
+			Text: err.Error(),
+		}
+		return true
+
+	default:
+		return false
+	}
+}
+
+func (err MessageSizeError) kind() string {
+	if err.Role == ServerRole {
+		return "response"
+	}
+	return "request"
+}
+
+var (
+	_ error       = MessageSizeError{}
+	_ asInterface = MessageSizeError{}
+	_ isInterface = MessageSizeError{}
+)
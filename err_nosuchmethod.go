@@ -12,6 +12,10 @@ func (err NoSuchMethodError) Error() string {
 	return fmt.Sprintf("method %q is not implemented", err.Method)
 }
 
+func (err NoSuchMethodError) Is(target error) bool {
+	return isStatusCode(err, target)
+}
+
 func (err NoSuchMethodError) As(out any) bool {
 	switch x := out.(type) {
 	case *StatusError:
@@ -29,4 +33,5 @@ func (err NoSuchMethodError) As(out any) bool {
 var (
 	_ error       = NoSuchMethodError{}
 	_ asInterface = NoSuchMethodError{}
+	_ isInterface = NoSuchMethodError{}
 )
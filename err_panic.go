@@ -4,8 +4,11 @@ import (
 	"fmt"
 )
 
+// PanicError is the error that a recovered panic is turned into.  Stack is
+// the stack of the panicking goroutine, as formatted by debug.Stack.
 type PanicError struct {
-	Err error
+	Err   error
+	Stack []byte
 }
 
 func (err PanicError) Error() string {
@@ -16,7 +19,27 @@ func (err PanicError) Unwrap() error {
 	return err.Err
 }
 
+func (err PanicError) Is(target error) bool {
+	return isStatusCode(err, target)
+}
+
+func (err PanicError) As(out any) bool {
+	switch x := out.(type) {
+	case *StatusError:
+		x.Status = &Status{
+			Code: Status_INTERNAL,
+			Text: err.Error(),
+		}
+		return true
+
+	default:
+		return false
+	}
+}
+
 var (
 	_ error           = PanicError{}
 	_ unwrapInterface = PanicError{}
+	_ asInterface     = PanicError{}
+	_ isInterface     = PanicError{}
 )
@@ -76,6 +76,10 @@ func (err MessageTypeError) Error() string {
 	return fmt.Sprintf("expected message of type %q, but got message of type %q", err.Expect, err.Actual)
 }
 
+func (err MessageTypeError) Is(target error) bool {
+	return isStatusCode(err, target)
+}
+
 func (err MessageTypeError) As(out any) bool {
 	switch x := out.(type) {
 	case *StatusError:
@@ -91,6 +95,7 @@ func (err MessageTypeError) As(out any) bool {
 var (
 	_ error       = MessageTypeError{}
 	_ asInterface = MessageTypeError{}
+	_ isInterface = MessageTypeError{}
 )
 
 type UnmarshalError struct {
@@ -106,6 +111,10 @@ func (err UnmarshalError) Unwrap() error {
 	return err.Err
 }
 
+func (err UnmarshalError) Is(target error) bool {
+	return isStatusCode(err, target)
+}
+
 func (err UnmarshalError) As(out any) bool {
 	switch x := out.(type) {
 	case *StatusError:
@@ -122,4 +131,5 @@ var (
 	_ error           = UnmarshalError{}
 	_ unwrapInterface = UnmarshalError{}
 	_ asInterface     = UnmarshalError{}
+	_ isInterface     = UnmarshalError{}
 )
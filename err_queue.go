@@ -0,0 +1,42 @@
+package vsrpc
+
+import (
+	"fmt"
+)
+
+// QueueFullError is returned by Queue.PushErr when a bounded Queue has no room
+// for a payload and its QueueOverflow policy does not allow waiting.  Len and
+// Bytes describe the Queue at the time, and Size is the size of the payload.
+type QueueFullError struct {
+	Len   uint
+	Bytes uint64
+	Size  uint64
+}
+
+func (err QueueFullError) Error() string {
+	return fmt.Sprintf("queue is full: cannot add %d bytes to %d items totalling %d bytes", err.Size, err.Len, err.Bytes)
+}
+
+func (err QueueFullError) Is(target error) bool {
+	return isStatusCode(err, target)
+}
+
+func (err QueueFullError) As(out any) bool {
+	switch x := out.(type) {
+	case *StatusError:
+		x.Status = &Status{
+			Code: Status_RESOURCE_EXHAUSTED,
+			Text: err.Error(),
+		}
+		return true
+
+	default:
+		return false
+	}
+}
+
+var (
+	_ error       = QueueFullError{}
+	_ asInterface = QueueFullError{}
+	_ isInterface = QueueFullError{}
+)
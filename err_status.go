@@ -36,4 +36,71 @@ func (err StatusError) Error() string {
 	return string(buf)
 }
 
-var _ error = StatusError{}
+// Is reports whether target is one of the per-code sentinels, such as
+// ErrNotFound, with the same code as err.
+func (err StatusError) Is(target error) bool {
+	sentinel, ok := statusSentinel(target)
+	return ok && err.Status.GetCode() == sentinel.Code
+}
+
+var (
+	_ error       = StatusError{}
+	_ isInterface = StatusError{}
+)
+
+// Sentinel errors for each non-OK status code.  errors.Is matches them
+// against any error that maps to a Status with the same code:
+//
+//	if errors.Is(err, vsrpc.ErrNotFound) {
+//		...
+//	}
+var (
+	ErrCancelled          error = StatusError{Status: &Status{Code: Status_CANCELLED}}
+	ErrUnknown            error = StatusError{Status: &Status{Code: Status_UNKNOWN}}
+	ErrInvalidArgument    error = StatusError{Status: &Status{Code: Status_INVALID_ARGUMENT}}
+	ErrDeadlineExceeded   error = StatusError{Status: &Status{Code: Status_DEADLINE_EXCEEDED}}
+	ErrNotFound           error = StatusError{Status: &Status{Code: Status_NOT_FOUND}}
+	ErrAlreadyExists      error = StatusError{Status: &Status{Code: Status_ALREADY_EXISTS}}
+	ErrPermissionDenied   error = StatusError{Status: &Status{Code: Status_PERMISSION_DENIED}}
+	ErrResourceExhausted  error = StatusError{Status: &Status{Code: Status_RESOURCE_EXHAUSTED}}
+	ErrFailedPrecondition error = StatusError{Status: &Status{Code: Status_FAILED_PRECONDITION}}
+	ErrAborted            error = StatusError{Status: &Status{Code: Status_ABORTED}}
+	ErrOutOfRange         error = StatusError{Status: &Status{Code: Status_OUT_OF_RANGE}}
+	ErrUnimplemented      error = StatusError{Status: &Status{Code: Status_UNIMPLEMENTED}}
+	ErrInternal           error = StatusError{Status: &Status{Code: Status_INTERNAL}}
+	ErrUnavailable        error = StatusError{Status: &Status{Code: Status_UNAVAILABLE}}
+	ErrDataLoss           error = StatusError{Status: &Status{Code: Status_DATA_LOSS}}
+	ErrUnauthenticated    error = StatusError{Status: &Status{Code: Status_UNAUTHENTICATED}}
+)
+
+// ErrNoResponse is returned by the client side of a method with a single
+// response when the call ends OK without one.
+var ErrNoResponse error = StatusError{Status: &Status{Code: Status_INTERNAL, Text: "call ended without a response"}}
+
+// statusSentinel returns the Status of target if target is a bare
+// StatusError, i.e. one that carries nothing but a non-OK code.
+func statusSentinel(target error) (*Status, bool) {
+	var status *Status
+	switch x := target.(type) {
+	case StatusError:
+		status = x.Status
+	case *StatusError:
+		if x != nil {
+			status = x.Status
+		}
+	}
+	if status.IsOK() || status.Text != "" || len(status.Details) != 0 || status.CanRetry {
+		return nil, false
+	}
+	return status, true
+}
+
+// isStatusCode implements Is for error types that map to a Status via As.
+func isStatusCode(err asInterface, target error) bool {
+	sentinel, ok := statusSentinel(target)
+	if !ok {
+		return false
+	}
+	var serr StatusError
+	return err.As(&serr) && serr.Status.GetCode() == sentinel.Code
+}
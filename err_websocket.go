@@ -0,0 +1,39 @@
+package vsrpc
+
+import (
+	"fmt"
+)
+
+// WebSocket close codes used by WebSocketConn; see RFC 6455 section 7.4.1.
+const (
+	WebSocketCloseNormal          uint16 = 1000
+	WebSocketCloseGoingAway       uint16 = 1001
+	WebSocketCloseProtocolError   uint16 = 1002
+	WebSocketCloseUnsupportedData uint16 = 1003
+	WebSocketCloseNoStatus        uint16 = 1005
+	WebSocketCloseMessageTooBig   uint16 = 1009
+)
+
+// WebSocketError reports a WebSocket connection that was closed with a close
+// code other than WebSocketCloseNormal or WebSocketCloseGoingAway, either by
+// the peer or locally because the peer broke the protocol.
+type WebSocketError struct {
+	Code uint16
+	Text string
+}
+
+func (err WebSocketError) Error() string {
+	if err.Text == "" {
+		return fmt.Sprintf("websocket: closed with code %d", err.Code)
+	}
+	return fmt.Sprintf("websocket: closed with code %d: %s", err.Code, err.Text)
+}
+
+func (err WebSocketError) IsRecoverable() bool {
+	return false
+}
+
+var (
+	_ error                  = WebSocketError{}
+	_ isRecoverableInterface = WebSocketError{}
+)
@@ -14,28 +14,91 @@ import (
 )
 
 const (
-	vsrpcMethodName_ExampleApi_ZeroInZeroOut vsrpc.Method = "vsrpc.ExampleApi.ZeroInZeroOut"
-	vsrpcMethodName_ExampleApi_ZeroInOneOut  vsrpc.Method = "vsrpc.ExampleApi.ZeroInOneOut"
-	vsrpcMethodName_ExampleApi_ZeroInManyOut vsrpc.Method = "vsrpc.ExampleApi.ZeroInManyOut"
-	vsrpcMethodName_ExampleApi_OneInZeroOut  vsrpc.Method = "vsrpc.ExampleApi.OneInZeroOut"
-	vsrpcMethodName_ExampleApi_OneInOneOut   vsrpc.Method = "vsrpc.ExampleApi.OneInOneOut"
-	vsrpcMethodName_ExampleApi_OneInManyOut  vsrpc.Method = "vsrpc.ExampleApi.OneInManyOut"
-	vsrpcMethodName_ExampleApi_ManyInZeroOut vsrpc.Method = "vsrpc.ExampleApi.ManyInZeroOut"
-	vsrpcMethodName_ExampleApi_ManyInOneOut  vsrpc.Method = "vsrpc.ExampleApi.ManyInOneOut"
-	vsrpcMethodName_ExampleApi_ManyInManyOut vsrpc.Method = "vsrpc.ExampleApi.ManyInManyOut"
+	ExampleApi_ZeroInZeroOut_FullMethodName vsrpc.Method = "vsrpc.ExampleApi.ZeroInZeroOut"
+	ExampleApi_ZeroInOneOut_FullMethodName  vsrpc.Method = "vsrpc.ExampleApi.ZeroInOneOut"
+	ExampleApi_ZeroInManyOut_FullMethodName vsrpc.Method = "vsrpc.ExampleApi.ZeroInManyOut"
+	ExampleApi_OneInZeroOut_FullMethodName  vsrpc.Method = "vsrpc.ExampleApi.OneInZeroOut"
+	ExampleApi_OneInOneOut_FullMethodName   vsrpc.Method = "vsrpc.ExampleApi.OneInOneOut"
+	ExampleApi_OneInManyOut_FullMethodName  vsrpc.Method = "vsrpc.ExampleApi.OneInManyOut"
+	ExampleApi_ManyInZeroOut_FullMethodName vsrpc.Method = "vsrpc.ExampleApi.ManyInZeroOut"
+	ExampleApi_ManyInOneOut_FullMethodName  vsrpc.Method = "vsrpc.ExampleApi.ManyInOneOut"
+	ExampleApi_ManyInManyOut_FullMethodName vsrpc.Method = "vsrpc.ExampleApi.ManyInManyOut"
 )
 
+// ExampleApi_ServiceDesc describes the ExampleApi service.
+var ExampleApi_ServiceDesc = vsrpc.ServiceDesc{
+	Name: "vsrpc.ExampleApi",
+	Methods: []vsrpc.MethodDesc{
+		{
+			Name:     ExampleApi_ZeroInZeroOut_FullMethodName,
+			Request:  "google.protobuf.Empty",
+			Response: "google.protobuf.Empty",
+		},
+		{
+			Name:     ExampleApi_ZeroInOneOut_FullMethodName,
+			Request:  "google.protobuf.Empty",
+			Response: "vsrpc.ExampleResponse",
+		},
+		{
+			Name:            ExampleApi_ZeroInManyOut_FullMethodName,
+			ServerStreaming: true,
+			Request:         "google.protobuf.Empty",
+			Response:        "vsrpc.ExampleResponse",
+		},
+		{
+			Name:     ExampleApi_OneInZeroOut_FullMethodName,
+			Request:  "vsrpc.ExampleRequest",
+			Response: "google.protobuf.Empty",
+		},
+		{
+			Name:     ExampleApi_OneInOneOut_FullMethodName,
+			Request:  "vsrpc.ExampleRequest",
+			Response: "vsrpc.ExampleResponse",
+		},
+		{
+			Name:            ExampleApi_OneInManyOut_FullMethodName,
+			ServerStreaming: true,
+			Request:         "vsrpc.ExampleRequest",
+			Response:        "vsrpc.ExampleResponse",
+		},
+		{
+			Name:            ExampleApi_ManyInZeroOut_FullMethodName,
+			ClientStreaming: true,
+			Request:         "vsrpc.ExampleRequest",
+			Response:        "google.protobuf.Empty",
+		},
+		{
+			Name:            ExampleApi_ManyInOneOut_FullMethodName,
+			ClientStreaming: true,
+			Request:         "vsrpc.ExampleRequest",
+			Response:        "vsrpc.ExampleResponse",
+		},
+		{
+			Name:            ExampleApi_ManyInManyOut_FullMethodName,
+			ClientStreaming: true,
+			ServerStreaming: true,
+			Request:         "vsrpc.ExampleRequest",
+			Response:        "vsrpc.ExampleResponse",
+		},
+	},
+}
+
 // ExampleApiClient is the client API for ExampleApi service.
 type ExampleApiClient interface {
 	ZeroInZeroOut(ctx context.Context, options ...vsrpc.Option) error
 	ZeroInOneOut(ctx context.Context, resp *ExampleResponse, options ...vsrpc.Option) error
 	ZeroInManyOut(ctx context.Context, fn func(stream vsrpc.RecvStream[*ExampleResponse]) error, options ...vsrpc.Option) error
+	OpenZeroInManyOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientRecvStream[*ExampleResponse], error)
 	OneInZeroOut(ctx context.Context, req *ExampleRequest, options ...vsrpc.Option) error
 	OneInOneOut(ctx context.Context, req *ExampleRequest, resp *ExampleResponse, options ...vsrpc.Option) error
 	OneInManyOut(ctx context.Context, req *ExampleRequest, fn func(stream vsrpc.RecvStream[*ExampleResponse]) error, options ...vsrpc.Option) error
+	OpenOneInManyOut(ctx context.Context, req *ExampleRequest, options ...vsrpc.Option) (vsrpc.ClientRecvStream[*ExampleResponse], error)
 	ManyInZeroOut(ctx context.Context, fn func(stream vsrpc.SendStream[*ExampleRequest]) error, options ...vsrpc.Option) error
+	OpenManyInZeroOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientSendStream[*ExampleRequest, *emptypb.Empty], error)
 	ManyInOneOut(ctx context.Context, resp *ExampleResponse, fn func(stream vsrpc.SendStream[*ExampleRequest]) error, options ...vsrpc.Option) error
+	OpenManyInOneOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientSendStream[*ExampleRequest, *ExampleResponse], error)
 	ManyInManyOut(ctx context.Context, fn func(stream vsrpc.BiStream[*ExampleRequest, *ExampleResponse]) error, options ...vsrpc.Option) error
+	OpenManyInManyOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientBiStream[*ExampleRequest, *ExampleResponse], error)
 }
 
 func NewExampleApiClient(conn *vsrpc.Conn) ExampleApiClient {
@@ -51,7 +114,7 @@ func (client vsrpcClientImpl_ExampleApi) Conn() *vsrpc.Conn {
 }
 
 func (client vsrpcClientImpl_ExampleApi) ZeroInZeroOut(ctx context.Context, options ...vsrpc.Option) error {
-	call, err := client.Conn().Begin(ctx, vsrpcMethodName_ExampleApi_ZeroInZeroOut, options...)
+	call, err := client.Conn().Begin(ctx, ExampleApi_ZeroInZeroOut_FullMethodName, options...)
 	if err != nil {
 		return err
 	}
@@ -69,7 +132,7 @@ func (client vsrpcClientImpl_ExampleApi) ZeroInOneOut(ctx context.Context, resp
 	assert.NotNil(&resp)
 	resp.Reset()
 
-	call, err := client.Conn().Begin(ctx, vsrpcMethodName_ExampleApi_ZeroInOneOut, options...)
+	call, err := client.Conn().Begin(ctx, ExampleApi_ZeroInOneOut_FullMethodName, options...)
 	if err != nil {
 		return err
 	}
@@ -80,15 +143,20 @@ func (client vsrpcClientImpl_ExampleApi) ZeroInOneOut(ctx context.Context, resp
 	if err != nil {
 		return err
 	}
-	_, _, err = stream.Recv(true, resp)
+	var ok bool
+	ok, _, err = stream.Recv(true, resp)
 	if err != nil {
 		return err
 	}
-	return call.Wait().AsError()
+	err = call.Wait().AsError()
+	if err == nil && !ok {
+		err = vsrpc.ErrNoResponse
+	}
+	return err
 }
 
 func (client vsrpcClientImpl_ExampleApi) ZeroInManyOut(ctx context.Context, fn func(stream vsrpc.RecvStream[*ExampleResponse]) error, options ...vsrpc.Option) error {
-	call, err := client.Conn().Begin(ctx, vsrpcMethodName_ExampleApi_ZeroInManyOut, options...)
+	call, err := client.Conn().Begin(ctx, ExampleApi_ZeroInManyOut_FullMethodName, options...)
 	if err != nil {
 		return err
 	}
@@ -106,8 +174,23 @@ func (client vsrpcClientImpl_ExampleApi) ZeroInManyOut(ctx context.Context, fn f
 	return call.Wait().AsError()
 }
 
+func (client vsrpcClientImpl_ExampleApi) OpenZeroInManyOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientRecvStream[*ExampleResponse], error) {
+	call, err := client.Conn().Begin(ctx, ExampleApi_ZeroInManyOut_FullMethodName, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := vsrpc.NewClientStream[*emptypb.Empty, *ExampleResponse](call)
+	err = stream.CloseSend()
+	if err != nil {
+		_ = call.Close()
+		return nil, err
+	}
+	return stream, nil
+}
+
 func (client vsrpcClientImpl_ExampleApi) OneInZeroOut(ctx context.Context, req *ExampleRequest, options ...vsrpc.Option) error {
-	call, err := client.Conn().Begin(ctx, vsrpcMethodName_ExampleApi_OneInZeroOut, options...)
+	call, err := client.Conn().Begin(ctx, ExampleApi_OneInZeroOut_FullMethodName, options...)
 	if err != nil {
 		return err
 	}
@@ -129,7 +212,7 @@ func (client vsrpcClientImpl_ExampleApi) OneInOneOut(ctx context.Context, req *E
 	assert.NotNil(&resp)
 	resp.Reset()
 
-	call, err := client.Conn().Begin(ctx, vsrpcMethodName_ExampleApi_OneInOneOut, options...)
+	call, err := client.Conn().Begin(ctx, ExampleApi_OneInOneOut_FullMethodName, options...)
 	if err != nil {
 		return err
 	}
@@ -144,15 +227,20 @@ func (client vsrpcClientImpl_ExampleApi) OneInOneOut(ctx context.Context, req *E
 	if err != nil {
 		return err
 	}
-	_, _, err = stream.Recv(true, resp)
+	var ok bool
+	ok, _, err = stream.Recv(true, resp)
 	if err != nil {
 		return err
 	}
-	return call.Wait().AsError()
+	err = call.Wait().AsError()
+	if err == nil && !ok {
+		err = vsrpc.ErrNoResponse
+	}
+	return err
 }
 
 func (client vsrpcClientImpl_ExampleApi) OneInManyOut(ctx context.Context, req *ExampleRequest, fn func(stream vsrpc.RecvStream[*ExampleResponse]) error, options ...vsrpc.Option) error {
-	call, err := client.Conn().Begin(ctx, vsrpcMethodName_ExampleApi_OneInManyOut, options...)
+	call, err := client.Conn().Begin(ctx, ExampleApi_OneInManyOut_FullMethodName, options...)
 	if err != nil {
 		return err
 	}
@@ -174,8 +262,28 @@ func (client vsrpcClientImpl_ExampleApi) OneInManyOut(ctx context.Context, req *
 	return call.Wait().AsError()
 }
 
+func (client vsrpcClientImpl_ExampleApi) OpenOneInManyOut(ctx context.Context, req *ExampleRequest, options ...vsrpc.Option) (vsrpc.ClientRecvStream[*ExampleResponse], error) {
+	call, err := client.Conn().Begin(ctx, ExampleApi_OneInManyOut_FullMethodName, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := vsrpc.NewClientStream[*ExampleRequest, *ExampleResponse](call)
+	err = stream.Send(req)
+	if err != nil {
+		_ = call.Close()
+		return nil, err
+	}
+	err = stream.CloseSend()
+	if err != nil {
+		_ = call.Close()
+		return nil, err
+	}
+	return stream, nil
+}
+
 func (client vsrpcClientImpl_ExampleApi) ManyInZeroOut(ctx context.Context, fn func(stream vsrpc.SendStream[*ExampleRequest]) error, options ...vsrpc.Option) error {
-	call, err := client.Conn().Begin(ctx, vsrpcMethodName_ExampleApi_ManyInZeroOut, options...)
+	call, err := client.Conn().Begin(ctx, ExampleApi_ManyInZeroOut_FullMethodName, options...)
 	if err != nil {
 		return err
 	}
@@ -193,11 +301,20 @@ func (client vsrpcClientImpl_ExampleApi) ManyInZeroOut(ctx context.Context, fn f
 	return call.Wait().AsError()
 }
 
+func (client vsrpcClientImpl_ExampleApi) OpenManyInZeroOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientSendStream[*ExampleRequest, *emptypb.Empty], error) {
+	call, err := client.Conn().Begin(ctx, ExampleApi_ManyInZeroOut_FullMethodName, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return vsrpc.NewClientStream[*ExampleRequest, *emptypb.Empty](call), nil
+}
+
 func (client vsrpcClientImpl_ExampleApi) ManyInOneOut(ctx context.Context, resp *ExampleResponse, fn func(stream vsrpc.SendStream[*ExampleRequest]) error, options ...vsrpc.Option) error {
 	assert.NotNil(&resp)
 	resp.Reset()
 
-	call, err := client.Conn().Begin(ctx, vsrpcMethodName_ExampleApi_ManyInOneOut, options...)
+	call, err := client.Conn().Begin(ctx, ExampleApi_ManyInOneOut_FullMethodName, options...)
 	if err != nil {
 		return err
 	}
@@ -212,15 +329,29 @@ func (client vsrpcClientImpl_ExampleApi) ManyInOneOut(ctx context.Context, resp
 	if err != nil {
 		return err
 	}
-	_, _, err = stream.Recv(true, resp)
+	var ok bool
+	ok, _, err = stream.Recv(true, resp)
 	if err != nil {
 		return err
 	}
-	return call.Wait().AsError()
+	err = call.Wait().AsError()
+	if err == nil && !ok {
+		err = vsrpc.ErrNoResponse
+	}
+	return err
+}
+
+func (client vsrpcClientImpl_ExampleApi) OpenManyInOneOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientSendStream[*ExampleRequest, *ExampleResponse], error) {
+	call, err := client.Conn().Begin(ctx, ExampleApi_ManyInOneOut_FullMethodName, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return vsrpc.NewClientStream[*ExampleRequest, *ExampleResponse](call), nil
 }
 
 func (client vsrpcClientImpl_ExampleApi) ManyInManyOut(ctx context.Context, fn func(stream vsrpc.BiStream[*ExampleRequest, *ExampleResponse]) error, options ...vsrpc.Option) error {
-	call, err := client.Conn().Begin(ctx, vsrpcMethodName_ExampleApi_ManyInManyOut, options...)
+	call, err := client.Conn().Begin(ctx, ExampleApi_ManyInManyOut_FullMethodName, options...)
 	if err != nil {
 		return err
 	}
@@ -238,6 +369,15 @@ func (client vsrpcClientImpl_ExampleApi) ManyInManyOut(ctx context.Context, fn f
 	return call.Wait().AsError()
 }
 
+func (client vsrpcClientImpl_ExampleApi) OpenManyInManyOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientBiStream[*ExampleRequest, *ExampleResponse], error) {
+	call, err := client.Conn().Begin(ctx, ExampleApi_ManyInManyOut_FullMethodName, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return vsrpc.NewClientStream[*ExampleRequest, *ExampleResponse](call), nil
+}
+
 var _ ExampleApiClient = (*vsrpcClientImpl_ExampleApi)(nil)
 
 // ExampleApiServer is the server API for ExampleApi service.
@@ -253,6 +393,49 @@ type ExampleApiServer interface {
 	ManyInManyOut(ctx context.Context, stream vsrpc.BiStream[*ExampleResponse, *ExampleRequest]) error
 }
 
+// UnimplementedExampleApiServer should be embedded by implementations of ExampleApiServer.
+// Methods added to ExampleApi in the future will then fail with
+// UNIMPLEMENTED instead of breaking the build.
+type UnimplementedExampleApiServer struct{}
+
+func (UnimplementedExampleApiServer) ZeroInZeroOut(ctx context.Context) error {
+	return vsrpc.NoSuchMethodError{Method: ExampleApi_ZeroInZeroOut_FullMethodName}
+}
+
+func (UnimplementedExampleApiServer) ZeroInOneOut(ctx context.Context, resp *ExampleResponse) error {
+	return vsrpc.NoSuchMethodError{Method: ExampleApi_ZeroInOneOut_FullMethodName}
+}
+
+func (UnimplementedExampleApiServer) ZeroInManyOut(ctx context.Context, stream vsrpc.SendStream[*ExampleResponse]) error {
+	return vsrpc.NoSuchMethodError{Method: ExampleApi_ZeroInManyOut_FullMethodName}
+}
+
+func (UnimplementedExampleApiServer) OneInZeroOut(ctx context.Context, req *ExampleRequest) error {
+	return vsrpc.NoSuchMethodError{Method: ExampleApi_OneInZeroOut_FullMethodName}
+}
+
+func (UnimplementedExampleApiServer) OneInOneOut(ctx context.Context, req *ExampleRequest, resp *ExampleResponse) error {
+	return vsrpc.NoSuchMethodError{Method: ExampleApi_OneInOneOut_FullMethodName}
+}
+
+func (UnimplementedExampleApiServer) OneInManyOut(ctx context.Context, req *ExampleRequest, stream vsrpc.SendStream[*ExampleResponse]) error {
+	return vsrpc.NoSuchMethodError{Method: ExampleApi_OneInManyOut_FullMethodName}
+}
+
+func (UnimplementedExampleApiServer) ManyInZeroOut(ctx context.Context, stream vsrpc.RecvStream[*ExampleRequest]) error {
+	return vsrpc.NoSuchMethodError{Method: ExampleApi_ManyInZeroOut_FullMethodName}
+}
+
+func (UnimplementedExampleApiServer) ManyInOneOut(ctx context.Context, resp *ExampleResponse, stream vsrpc.RecvStream[*ExampleRequest]) error {
+	return vsrpc.NoSuchMethodError{Method: ExampleApi_ManyInOneOut_FullMethodName}
+}
+
+func (UnimplementedExampleApiServer) ManyInManyOut(ctx context.Context, stream vsrpc.BiStream[*ExampleResponse, *ExampleRequest]) error {
+	return vsrpc.NoSuchMethodError{Method: ExampleApi_ManyInManyOut_FullMethodName}
+}
+
+var _ ExampleApiServer = UnimplementedExampleApiServer{}
+
 func NewExampleApiHandler(impl ExampleApiServer) vsrpc.Handler {
 	return vsrpcHandler_ExampleApi{impl: impl}
 }
@@ -270,12 +453,12 @@ func (h vsrpcHandler_ExampleApi) Handle(call *vsrpc.Call) error {
 	}
 
 	switch method {
-	case vsrpcMethodName_ExampleApi_ZeroInZeroOut:
+	case ExampleApi_ZeroInZeroOut_FullMethodName:
 		if err := h.impl.ZeroInZeroOut(ctx); err != nil {
 			return err
 		}
 
-	case vsrpcMethodName_ExampleApi_ZeroInOneOut:
+	case ExampleApi_ZeroInOneOut_FullMethodName:
 		stream := vsrpc.NewStream[*ExampleResponse, *emptypb.Empty](call)
 		var resp ExampleResponse
 		if err := h.impl.ZeroInOneOut(ctx, &resp); err != nil {
@@ -285,13 +468,13 @@ func (h vsrpcHandler_ExampleApi) Handle(call *vsrpc.Call) error {
 			return err
 		}
 
-	case vsrpcMethodName_ExampleApi_ZeroInManyOut:
+	case ExampleApi_ZeroInManyOut_FullMethodName:
 		stream := vsrpc.NewStream[*ExampleResponse, *emptypb.Empty](call)
 		if err := h.impl.ZeroInManyOut(ctx, stream); err != nil {
 			return err
 		}
 
-	case vsrpcMethodName_ExampleApi_OneInZeroOut:
+	case ExampleApi_OneInZeroOut_FullMethodName:
 		stream := vsrpc.NewStream[*emptypb.Empty, *ExampleRequest](call)
 		var req ExampleRequest
 		if _, _, err := stream.Recv(true, &req); err != nil {
@@ -301,7 +484,7 @@ func (h vsrpcHandler_ExampleApi) Handle(call *vsrpc.Call) error {
 			return err
 		}
 
-	case vsrpcMethodName_ExampleApi_OneInOneOut:
+	case ExampleApi_OneInOneOut_FullMethodName:
 		stream := vsrpc.NewStream[*ExampleResponse, *ExampleRequest](call)
 		var req ExampleRequest
 		if _, _, err := stream.Recv(true, &req); err != nil {
@@ -315,7 +498,7 @@ func (h vsrpcHandler_ExampleApi) Handle(call *vsrpc.Call) error {
 			return err
 		}
 
-	case vsrpcMethodName_ExampleApi_OneInManyOut:
+	case ExampleApi_OneInManyOut_FullMethodName:
 		stream := vsrpc.NewStream[*ExampleResponse, *ExampleRequest](call)
 		var req ExampleRequest
 		if _, _, err := stream.Recv(true, &req); err != nil {
@@ -325,13 +508,13 @@ func (h vsrpcHandler_ExampleApi) Handle(call *vsrpc.Call) error {
 			return err
 		}
 
-	case vsrpcMethodName_ExampleApi_ManyInZeroOut:
+	case ExampleApi_ManyInZeroOut_FullMethodName:
 		stream := vsrpc.NewStream[*emptypb.Empty, *ExampleRequest](call)
 		if err := h.impl.ManyInZeroOut(ctx, stream); err != nil {
 			return err
 		}
 
-	case vsrpcMethodName_ExampleApi_ManyInOneOut:
+	case ExampleApi_ManyInOneOut_FullMethodName:
 		stream := vsrpc.NewStream[*ExampleResponse, *ExampleRequest](call)
 		var resp ExampleResponse
 		if err := h.impl.ManyInOneOut(ctx, &resp, stream); err != nil {
@@ -341,7 +524,7 @@ func (h vsrpcHandler_ExampleApi) Handle(call *vsrpc.Call) error {
 			return err
 		}
 
-	case vsrpcMethodName_ExampleApi_ManyInManyOut:
+	case ExampleApi_ManyInManyOut_FullMethodName:
 		stream := vsrpc.NewStream[*ExampleResponse, *ExampleRequest](call)
 		if err := h.impl.ManyInManyOut(ctx, stream); err != nil {
 			return err
@@ -354,3 +537,9 @@ func (h vsrpcHandler_ExampleApi) Handle(call *vsrpc.Call) error {
 }
 
 var _ vsrpc.Handler = vsrpcHandler_ExampleApi{}
+
+// RegisterExampleApiServer registers impl with mux under the exact names
+// of the ExampleApi methods.
+func RegisterExampleApiServer(mux *vsrpc.HandlerMux, impl ExampleApiServer) {
+	mux.AddService(&ExampleApi_ServiceDesc, NewExampleApiHandler(impl))
+}
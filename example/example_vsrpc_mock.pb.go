@@ -0,0 +1,266 @@
+// Code generated by protoc-gen-go-vsrpc. DO NOT EDIT.
+// Versions:
+// - protoc-gen-go-vsrpc: v0.2.0
+// - protoc: v4.22.3
+// Source: example.proto
+
+package example
+
+import (
+	context "context"
+	assert "github.com/chronos-tachyon/assert"
+	vsrpc "github.com/chronos-tachyon/vsrpc"
+	proto "google.golang.org/protobuf/proto"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// MockExampleApiClient is a programmable ExampleApiClient for use in tests.
+//
+// For each method M, if MFunc (or OpenMFunc) is set then it handles the call.
+// Otherwise, the call delivers MResponses and then returns MErr.  The n'th
+// call to a method with a single response gets MResponses[n], or the last
+// element once the list runs out; a call to a method with streamed responses
+// gets all of them.
+//
+// Every call is recorded along with its requests.  Calls handled by MFunc or
+// OpenMFunc record only the single request, if the method has one.
+type MockExampleApiClient struct {
+	vsrpc.MockRecorder
+
+	ZeroInZeroOutFunc func(ctx context.Context, options ...vsrpc.Option) error
+	ZeroInZeroOutErr  error
+
+	ZeroInOneOutFunc      func(ctx context.Context, resp *ExampleResponse, options ...vsrpc.Option) error
+	ZeroInOneOutResponses []*ExampleResponse
+	ZeroInOneOutErr       error
+
+	ZeroInManyOutFunc      func(ctx context.Context, fn func(stream vsrpc.RecvStream[*ExampleResponse]) error, options ...vsrpc.Option) error
+	OpenZeroInManyOutFunc  func(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientRecvStream[*ExampleResponse], error)
+	ZeroInManyOutResponses []*ExampleResponse
+	ZeroInManyOutErr       error
+
+	OneInZeroOutFunc func(ctx context.Context, req *ExampleRequest, options ...vsrpc.Option) error
+	OneInZeroOutErr  error
+
+	OneInOneOutFunc      func(ctx context.Context, req *ExampleRequest, resp *ExampleResponse, options ...vsrpc.Option) error
+	OneInOneOutResponses []*ExampleResponse
+	OneInOneOutErr       error
+
+	OneInManyOutFunc      func(ctx context.Context, req *ExampleRequest, fn func(stream vsrpc.RecvStream[*ExampleResponse]) error, options ...vsrpc.Option) error
+	OpenOneInManyOutFunc  func(ctx context.Context, req *ExampleRequest, options ...vsrpc.Option) (vsrpc.ClientRecvStream[*ExampleResponse], error)
+	OneInManyOutResponses []*ExampleResponse
+	OneInManyOutErr       error
+
+	ManyInZeroOutFunc     func(ctx context.Context, fn func(stream vsrpc.SendStream[*ExampleRequest]) error, options ...vsrpc.Option) error
+	OpenManyInZeroOutFunc func(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientSendStream[*ExampleRequest, *emptypb.Empty], error)
+	ManyInZeroOutErr      error
+
+	ManyInOneOutFunc      func(ctx context.Context, resp *ExampleResponse, fn func(stream vsrpc.SendStream[*ExampleRequest]) error, options ...vsrpc.Option) error
+	OpenManyInOneOutFunc  func(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientSendStream[*ExampleRequest, *ExampleResponse], error)
+	ManyInOneOutResponses []*ExampleResponse
+	ManyInOneOutErr       error
+
+	ManyInManyOutFunc      func(ctx context.Context, fn func(stream vsrpc.BiStream[*ExampleRequest, *ExampleResponse]) error, options ...vsrpc.Option) error
+	OpenManyInManyOutFunc  func(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientBiStream[*ExampleRequest, *ExampleResponse], error)
+	ManyInManyOutResponses []*ExampleResponse
+	ManyInManyOutErr       error
+}
+
+func (mock *MockExampleApiClient) ZeroInZeroOut(ctx context.Context, options ...vsrpc.Option) error {
+	if mock.ZeroInZeroOutFunc != nil {
+		mock.Record(ExampleApi_ZeroInZeroOut_FullMethodName)
+		return mock.ZeroInZeroOutFunc(ctx, options...)
+	}
+
+	mock.Record(ExampleApi_ZeroInZeroOut_FullMethodName)
+	return mock.ZeroInZeroOutErr
+}
+
+func (mock *MockExampleApiClient) ZeroInOneOut(ctx context.Context, resp *ExampleResponse, options ...vsrpc.Option) error {
+	assert.NotNil(&resp)
+	resp.Reset()
+
+	if mock.ZeroInOneOutFunc != nil {
+		mock.Record(ExampleApi_ZeroInOneOut_FullMethodName)
+		return mock.ZeroInOneOutFunc(ctx, resp, options...)
+	}
+
+	n := mock.Record(ExampleApi_ZeroInOneOut_FullMethodName)
+	if out, ok := vsrpc.MockResponse(mock.ZeroInOneOutResponses, n); ok {
+		proto.Merge(resp, out)
+	}
+	return mock.ZeroInOneOutErr
+}
+
+func (mock *MockExampleApiClient) ZeroInManyOut(ctx context.Context, fn func(stream vsrpc.RecvStream[*ExampleResponse]) error, options ...vsrpc.Option) error {
+	if mock.ZeroInManyOutFunc != nil {
+		mock.Record(ExampleApi_ZeroInManyOut_FullMethodName)
+		return mock.ZeroInManyOutFunc(ctx, fn, options...)
+	}
+
+	stream := vsrpc.NewMockStream[*emptypb.Empty, *ExampleResponse](ctx, ExampleApi_ZeroInManyOut_FullMethodName, mock.ZeroInManyOutResponses, nil)
+	mock.Record(ExampleApi_ZeroInManyOut_FullMethodName)
+	if err := fn(stream); err != nil {
+		return err
+	}
+	return mock.ZeroInManyOutErr
+}
+
+func (mock *MockExampleApiClient) OpenZeroInManyOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientRecvStream[*ExampleResponse], error) {
+	if mock.OpenZeroInManyOutFunc != nil {
+		mock.Record(ExampleApi_ZeroInManyOut_FullMethodName)
+		return mock.OpenZeroInManyOutFunc(ctx, options...)
+	}
+
+	stream := vsrpc.NewMockStream[*emptypb.Empty, *ExampleResponse](ctx, ExampleApi_ZeroInManyOut_FullMethodName, mock.ZeroInManyOutResponses, mock.ZeroInManyOutErr)
+	mock.Record(ExampleApi_ZeroInManyOut_FullMethodName)
+	return stream, nil
+}
+
+func (mock *MockExampleApiClient) OneInZeroOut(ctx context.Context, req *ExampleRequest, options ...vsrpc.Option) error {
+	if mock.OneInZeroOutFunc != nil {
+		mock.Record(ExampleApi_OneInZeroOut_FullMethodName, proto.Clone(req))
+		return mock.OneInZeroOutFunc(ctx, req, options...)
+	}
+
+	mock.Record(ExampleApi_OneInZeroOut_FullMethodName, proto.Clone(req))
+	return mock.OneInZeroOutErr
+}
+
+func (mock *MockExampleApiClient) OneInOneOut(ctx context.Context, req *ExampleRequest, resp *ExampleResponse, options ...vsrpc.Option) error {
+	assert.NotNil(&resp)
+	resp.Reset()
+
+	if mock.OneInOneOutFunc != nil {
+		mock.Record(ExampleApi_OneInOneOut_FullMethodName, proto.Clone(req))
+		return mock.OneInOneOutFunc(ctx, req, resp, options...)
+	}
+
+	n := mock.Record(ExampleApi_OneInOneOut_FullMethodName, proto.Clone(req))
+	if out, ok := vsrpc.MockResponse(mock.OneInOneOutResponses, n); ok {
+		proto.Merge(resp, out)
+	}
+	return mock.OneInOneOutErr
+}
+
+func (mock *MockExampleApiClient) OneInManyOut(ctx context.Context, req *ExampleRequest, fn func(stream vsrpc.RecvStream[*ExampleResponse]) error, options ...vsrpc.Option) error {
+	if mock.OneInManyOutFunc != nil {
+		mock.Record(ExampleApi_OneInManyOut_FullMethodName, proto.Clone(req))
+		return mock.OneInManyOutFunc(ctx, req, fn, options...)
+	}
+
+	stream := vsrpc.NewMockStream[*ExampleRequest, *ExampleResponse](ctx, ExampleApi_OneInManyOut_FullMethodName, mock.OneInManyOutResponses, nil)
+	mock.Record(ExampleApi_OneInManyOut_FullMethodName, proto.Clone(req))
+	if err := fn(stream); err != nil {
+		return err
+	}
+	return mock.OneInManyOutErr
+}
+
+func (mock *MockExampleApiClient) OpenOneInManyOut(ctx context.Context, req *ExampleRequest, options ...vsrpc.Option) (vsrpc.ClientRecvStream[*ExampleResponse], error) {
+	if mock.OpenOneInManyOutFunc != nil {
+		mock.Record(ExampleApi_OneInManyOut_FullMethodName, proto.Clone(req))
+		return mock.OpenOneInManyOutFunc(ctx, req, options...)
+	}
+
+	stream := vsrpc.NewMockStream[*ExampleRequest, *ExampleResponse](ctx, ExampleApi_OneInManyOut_FullMethodName, mock.OneInManyOutResponses, mock.OneInManyOutErr)
+	mock.Record(ExampleApi_OneInManyOut_FullMethodName, proto.Clone(req))
+	return stream, nil
+}
+
+func (mock *MockExampleApiClient) ManyInZeroOut(ctx context.Context, fn func(stream vsrpc.SendStream[*ExampleRequest]) error, options ...vsrpc.Option) error {
+	if mock.ManyInZeroOutFunc != nil {
+		mock.Record(ExampleApi_ManyInZeroOut_FullMethodName)
+		return mock.ManyInZeroOutFunc(ctx, fn, options...)
+	}
+
+	stream := vsrpc.NewMockStream[*ExampleRequest, *emptypb.Empty](ctx, ExampleApi_ManyInZeroOut_FullMethodName, nil, nil)
+	mock.RecordStream(ExampleApi_ManyInZeroOut_FullMethodName, stream)
+	if err := fn(stream); err != nil {
+		return err
+	}
+	return mock.ManyInZeroOutErr
+}
+
+func (mock *MockExampleApiClient) OpenManyInZeroOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientSendStream[*ExampleRequest, *emptypb.Empty], error) {
+	if mock.OpenManyInZeroOutFunc != nil {
+		mock.Record(ExampleApi_ManyInZeroOut_FullMethodName)
+		return mock.OpenManyInZeroOutFunc(ctx, options...)
+	}
+
+	stream := vsrpc.NewMockStream[*ExampleRequest, *emptypb.Empty](ctx, ExampleApi_ManyInZeroOut_FullMethodName, nil, mock.ManyInZeroOutErr)
+	mock.RecordStream(ExampleApi_ManyInZeroOut_FullMethodName, stream)
+	return stream, nil
+}
+
+func (mock *MockExampleApiClient) ManyInOneOut(ctx context.Context, resp *ExampleResponse, fn func(stream vsrpc.SendStream[*ExampleRequest]) error, options ...vsrpc.Option) error {
+	assert.NotNil(&resp)
+	resp.Reset()
+
+	if mock.ManyInOneOutFunc != nil {
+		mock.Record(ExampleApi_ManyInOneOut_FullMethodName)
+		return mock.ManyInOneOutFunc(ctx, resp, fn, options...)
+	}
+
+	stream := vsrpc.NewMockStream[*ExampleRequest, *ExampleResponse](ctx, ExampleApi_ManyInOneOut_FullMethodName, nil, nil)
+	n := mock.RecordStream(ExampleApi_ManyInOneOut_FullMethodName, stream)
+	if err := fn(stream); err != nil {
+		return err
+	}
+	if out, ok := vsrpc.MockResponse(mock.ManyInOneOutResponses, n); ok {
+		proto.Merge(resp, out)
+	}
+	return mock.ManyInOneOutErr
+}
+
+func (mock *MockExampleApiClient) OpenManyInOneOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientSendStream[*ExampleRequest, *ExampleResponse], error) {
+	if mock.OpenManyInOneOutFunc != nil {
+		mock.Record(ExampleApi_ManyInOneOut_FullMethodName)
+		return mock.OpenManyInOneOutFunc(ctx, options...)
+	}
+
+	stream := vsrpc.NewMockStream[*ExampleRequest, *ExampleResponse](ctx, ExampleApi_ManyInOneOut_FullMethodName, nil, mock.ManyInOneOutErr)
+	n := mock.RecordStream(ExampleApi_ManyInOneOut_FullMethodName, stream)
+	if out, ok := vsrpc.MockResponse(mock.ManyInOneOutResponses, n); ok {
+		stream.SetResponses(out)
+	}
+	return stream, nil
+}
+
+func (mock *MockExampleApiClient) ManyInManyOut(ctx context.Context, fn func(stream vsrpc.BiStream[*ExampleRequest, *ExampleResponse]) error, options ...vsrpc.Option) error {
+	if mock.ManyInManyOutFunc != nil {
+		mock.Record(ExampleApi_ManyInManyOut_FullMethodName)
+		return mock.ManyInManyOutFunc(ctx, fn, options...)
+	}
+
+	stream := vsrpc.NewMockStream[*ExampleRequest, *ExampleResponse](ctx, ExampleApi_ManyInManyOut_FullMethodName, mock.ManyInManyOutResponses, nil)
+	mock.RecordStream(ExampleApi_ManyInManyOut_FullMethodName, stream)
+	if err := fn(stream); err != nil {
+		return err
+	}
+	return mock.ManyInManyOutErr
+}
+
+func (mock *MockExampleApiClient) OpenManyInManyOut(ctx context.Context, options ...vsrpc.Option) (vsrpc.ClientBiStream[*ExampleRequest, *ExampleResponse], error) {
+	if mock.OpenManyInManyOutFunc != nil {
+		mock.Record(ExampleApi_ManyInManyOut_FullMethodName)
+		return mock.OpenManyInManyOutFunc(ctx, options...)
+	}
+
+	stream := vsrpc.NewMockStream[*ExampleRequest, *ExampleResponse](ctx, ExampleApi_ManyInManyOut_FullMethodName, mock.ManyInManyOutResponses, mock.ManyInManyOutErr)
+	mock.RecordStream(ExampleApi_ManyInManyOut_FullMethodName, stream)
+	return stream, nil
+}
+
+var _ ExampleApiClient = (*MockExampleApiClient)(nil)
+
+// NewExampleApiLoopback serves impl through the generated Handler over an
+// in-process connection, and returns a client that talks to it.  Close the
+// Loopback when done.
+func NewExampleApiLoopback(ctx context.Context, impl ExampleApiServer, options ...vsrpc.Option) (ExampleApiClient, *vsrpc.Loopback, error) {
+	lb, err := vsrpc.NewLoopback(ctx, NewExampleApiHandler(impl), options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewExampleApiClient(lb.Conn), lb, nil
+}
@@ -7,6 +7,7 @@ import (
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -47,6 +48,13 @@ type PacketWriter interface {
 	WritePacket(ctx context.Context, p []byte) error
 }
 
+// PacketBatchWriter is implemented by PacketConns that can write several
+// packets at once more cheaply than one by one, e.g. with a single writev.
+// The packets are written in order; an error applies to the whole batch.
+type PacketBatchWriter interface {
+	WritePackets(ctx context.Context, packets [][]byte) error
+}
+
 func WriteFrame(ctx context.Context, w PacketWriter, frame *Frame) error {
 	assert.NotNil(&ctx)
 	assert.NotNil(&w)
@@ -74,7 +82,7 @@ func WriteNoOp(ctx context.Context, w PacketWriter) error {
 	return WriteFrame(ctx, w, &frame)
 }
 
-func WriteBegin(ctx context.Context, w PacketWriter, id ID, method Method) error {
+func WriteBegin(ctx context.Context, w PacketWriter, id ID, method Method, priority uint32) error {
 	assert.NotNil(&ctx)
 	assert.NotNil(&w)
 
@@ -82,8 +90,10 @@ func WriteBegin(ctx context.Context, w PacketWriter, id ID, method Method) error
 	frame.Type = Frame_BEGIN
 	frame.CallId = uint32(id)
 	frame.Method = string(method)
+	frame.Priority = priority
 	if t, ok := ctx.Deadline(); ok {
 		frame.Deadline = timestamppb.New(t)
+		frame.Timeout = durationpb.New(t.Sub(ContextCall(ctx).Clock().Now()))
 	}
 	return WriteFrame(ctx, w, &frame)
 }
@@ -164,10 +174,22 @@ func WriteGoAway(ctx context.Context, w PacketWriter) error {
 	return WriteFrame(ctx, w, &frame)
 }
 
+func WriteHello(ctx context.Context, w PacketWriter, bidirectional bool) error {
+	assert.NotNil(&ctx)
+	assert.NotNil(&w)
+
+	var frame Frame
+	frame.Type = Frame_HELLO
+	frame.Bidirectional = bidirectional
+	return WriteFrame(ctx, w, &frame)
+}
+
 func expectZeroCallId(frameType Frame_Type) bool {
 	switch frameType {
 	case Frame_NO_OP:
 		fallthrough
+	case Frame_HELLO:
+		fallthrough
 	case Frame_SHUTDOWN:
 		fallthrough
 	case Frame_GO_AWAY:
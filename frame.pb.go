@@ -10,6 +10,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	anypb "google.golang.org/protobuf/types/known/anypb"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -56,9 +57,24 @@ const (
 	Frame_GO_AWAY Frame_Type = 2
 	// BEGIN creates a new RPC call.
 	//
-	// Direction: client to server
+	// On a bidirectional connection (see HELLO), the server may also send
+	// BEGIN, and the roles of client and server are swapped for that call:
+	// the server sends REQUEST, HALF_CLOSE and CANCEL, and the client sends
+	// RESPONSE and END.  Calls begun by the client have odd call IDs, and calls
+	// begun by the server have even call IDs.
+	//
+	// The priority sets the share of the connection that frames for this call
+	// get, in both directions, relative to other calls.  Zero means the
+	// default priority.
+	//
+	// If the call has a deadline, the sender sets both deadline and timeout,
+	// the time left until the deadline as of sending the frame.  The receiver
+	// should prefer timeout, since deadline is only meaningful if the clocks
+	// of the two hosts agree.
+	//
+	// Direction: client to server, or either way if bidirectional
 	// Required fields: type, call_id, method
-	// Optional fields: deadline
+	// Optional fields: deadline, timeout, priority
 	Frame_BEGIN Frame_Type = 3
 	// REQUEST sends a request body for an RPC call.
 	//
@@ -100,6 +116,17 @@ const (
 	// Required fields: type, call_id, status
 	// Optional fields: NONE
 	Frame_END Frame_Type = 8
+	// HELLO negotiates optional features of the connection.
+	//
+	// A client that wants a bidirectional connection sends HELLO with
+	// bidirectional set as its first frame.  The server replies with HELLO,
+	// setting bidirectional if it agrees.  Neither side sends HELLO more than
+	// once, and the server sends it only in reply.
+	//
+	// Direction: client to server, then server to client
+	// Required fields: type
+	// Optional fields: bidirectional
+	Frame_HELLO Frame_Type = 9
 )
 
 // Enum value maps for Frame_Type.
@@ -114,6 +141,7 @@ var (
 		6: "HALF_CLOSE",
 		7: "CANCEL",
 		8: "END",
+		9: "HELLO",
 	}
 	Frame_Type_value = map[string]int32{
 		"NO_OP":      0,
@@ -125,6 +153,7 @@ var (
 		"HALF_CLOSE": 6,
 		"CANCEL":     7,
 		"END":        8,
+		"HELLO":      9,
 	}
 )
 
@@ -160,12 +189,15 @@ type Frame struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Type     Frame_Type             `protobuf:"varint,1,opt,name=type,proto3,enum=vsrpc.Frame_Type" json:"type,omitempty"`
-	CallId   uint32                 `protobuf:"varint,2,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
-	Method   string                 `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`
-	Deadline *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=deadline,proto3" json:"deadline,omitempty"`
-	Payload  *anypb.Any             `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
-	Status   *Status                `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	Type          Frame_Type             `protobuf:"varint,1,opt,name=type,proto3,enum=vsrpc.Frame_Type" json:"type,omitempty"`
+	CallId        uint32                 `protobuf:"varint,2,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+	Method        string                 `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`
+	Deadline      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	Payload       *anypb.Any             `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+	Status        *Status                `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	Priority      uint32                 `protobuf:"varint,7,opt,name=priority,proto3" json:"priority,omitempty"`
+	Bidirectional bool                   `protobuf:"varint,8,opt,name=bidirectional,proto3" json:"bidirectional,omitempty"`
+	Timeout       *durationpb.Duration   `protobuf:"bytes,9,opt,name=timeout,proto3" json:"timeout,omitempty"`
 }
 
 func (x *Frame) Reset() {
@@ -242,16 +274,39 @@ func (x *Frame) GetStatus() *Status {
 	return nil
 }
 
+func (x *Frame) GetPriority() uint32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Frame) GetBidirectional() bool {
+	if x != nil {
+		return x.Bidirectional
+	}
+	return false
+}
+
+func (x *Frame) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
 var File_vsrpc_frame_proto protoreflect.FileDescriptor
 
 var file_vsrpc_frame_proto_rawDesc = []byte{
 	0x0a, 0x11, 0x76, 0x73, 0x72, 0x70, 0x63, 0x2f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x2e, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x12, 0x05, 0x76, 0x73, 0x72, 0x70, 0x63, 0x1a, 0x19, 0x67, 0x6f, 0x6f, 0x67,
 	0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x61, 0x6e, 0x79, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
 	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x12, 0x76, 0x73, 0x72, 0x70, 0x63, 0x2f, 0x73, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xe7, 0x02, 0x0a, 0x05, 0x46,
+	0x61, 0x74, 0x75, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xea, 0x03, 0x0a, 0x05, 0x46,
 	0x72, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01,
 	0x28, 0x0e, 0x32, 0x11, 0x2e, 0x76, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x46, 0x72, 0x61, 0x6d, 0x65,
 	0x2e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x63,
@@ -266,17 +321,26 @@ var file_vsrpc_frame_proto_rawDesc = []byte{
 	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x41, 0x6e, 0x79, 0x52, 0x07, 0x70, 0x61, 0x79,
 	0x6c, 0x6f, 0x61, 0x64, 0x12, 0x25, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x06,
 	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x76, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x77, 0x0a, 0x04, 0x54,
-	0x79, 0x70, 0x65, 0x12, 0x09, 0x0a, 0x05, 0x4e, 0x4f, 0x5f, 0x4f, 0x50, 0x10, 0x00, 0x12, 0x0c,
-	0x0a, 0x08, 0x53, 0x48, 0x55, 0x54, 0x44, 0x4f, 0x57, 0x4e, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07,
-	0x47, 0x4f, 0x5f, 0x41, 0x57, 0x41, 0x59, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x42, 0x45, 0x47,
-	0x49, 0x4e, 0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x10,
-	0x04, 0x12, 0x0c, 0x0a, 0x08, 0x52, 0x45, 0x53, 0x50, 0x4f, 0x4e, 0x53, 0x45, 0x10, 0x05, 0x12,
-	0x0e, 0x0a, 0x0a, 0x48, 0x41, 0x4c, 0x46, 0x5f, 0x43, 0x4c, 0x4f, 0x53, 0x45, 0x10, 0x06, 0x12,
-	0x0a, 0x0a, 0x06, 0x43, 0x41, 0x4e, 0x43, 0x45, 0x4c, 0x10, 0x07, 0x12, 0x07, 0x0a, 0x03, 0x45,
-	0x4e, 0x44, 0x10, 0x08, 0x42, 0x22, 0x5a, 0x20, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
-	0x6f, 0x6d, 0x2f, 0x63, 0x68, 0x72, 0x6f, 0x6e, 0x6f, 0x73, 0x2d, 0x74, 0x61, 0x63, 0x68, 0x79,
-	0x6f, 0x6e, 0x2f, 0x76, 0x73, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x70,
+	0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70,
+	0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x12, 0x24, 0x0a, 0x0d, 0x62, 0x69, 0x64, 0x69, 0x72,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d,
+	0x62, 0x69, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x12, 0x33, 0x0a,
+	0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f,
+	0x75, 0x74, 0x22, 0x82, 0x01, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x09, 0x0a, 0x05, 0x4e,
+	0x4f, 0x5f, 0x4f, 0x50, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x53, 0x48, 0x55, 0x54, 0x44, 0x4f,
+	0x57, 0x4e, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x47, 0x4f, 0x5f, 0x41, 0x57, 0x41, 0x59, 0x10,
+	0x02, 0x12, 0x09, 0x0a, 0x05, 0x42, 0x45, 0x47, 0x49, 0x4e, 0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07,
+	0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x10, 0x04, 0x12, 0x0c, 0x0a, 0x08, 0x52, 0x45, 0x53,
+	0x50, 0x4f, 0x4e, 0x53, 0x45, 0x10, 0x05, 0x12, 0x0e, 0x0a, 0x0a, 0x48, 0x41, 0x4c, 0x46, 0x5f,
+	0x43, 0x4c, 0x4f, 0x53, 0x45, 0x10, 0x06, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x41, 0x4e, 0x43, 0x45,
+	0x4c, 0x10, 0x07, 0x12, 0x07, 0x0a, 0x03, 0x45, 0x4e, 0x44, 0x10, 0x08, 0x12, 0x09, 0x0a, 0x05,
+	0x48, 0x45, 0x4c, 0x4c, 0x4f, 0x10, 0x09, 0x42, 0x22, 0x5a, 0x20, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x68, 0x72, 0x6f, 0x6e, 0x6f, 0x73, 0x2d, 0x74, 0x61,
+	0x63, 0x68, 0x79, 0x6f, 0x6e, 0x2f, 0x76, 0x73, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -299,17 +363,19 @@ var file_vsrpc_frame_proto_goTypes = []interface{}{
 	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
 	(*anypb.Any)(nil),             // 3: google.protobuf.Any
 	(*Status)(nil),                // 4: vsrpc.Status
+	(*durationpb.Duration)(nil),   // 5: google.protobuf.Duration
 }
 var file_vsrpc_frame_proto_depIdxs = []int32{
 	0, // 0: vsrpc.Frame.type:type_name -> vsrpc.Frame.Type
 	2, // 1: vsrpc.Frame.deadline:type_name -> google.protobuf.Timestamp
 	3, // 2: vsrpc.Frame.payload:type_name -> google.protobuf.Any
 	4, // 3: vsrpc.Frame.status:type_name -> vsrpc.Status
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	5, // 4: vsrpc.Frame.timeout:type_name -> google.protobuf.Duration
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_vsrpc_frame_proto_init() }
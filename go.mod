@@ -1,8 +1,9 @@
 module github.com/chronos-tachyon/vsrpc
 
-go 1.19
+go 1.23
 
 require (
+	github.com/bufbuild/protocompile v0.5.1
 	github.com/chronos-tachyon/assert v1.2.0
 	github.com/rs/zerolog v1.29.1
 	google.golang.org/protobuf v1.30.0
@@ -11,5 +12,6 @@ require (
 require (
 	github.com/mattn/go-colorable v0.1.12 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
+	golang.org/x/sync v0.1.0 // indirect
 	golang.org/x/sys v0.7.0 // indirect
 )
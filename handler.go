@@ -1,6 +1,7 @@
 package vsrpc
 
 import (
+	"sort"
 	"strings"
 	"sync"
 )
@@ -22,8 +23,9 @@ func (fn HandlerFunc) Handle(call *Call) error {
 var _ Handler = HandlerFunc(nil)
 
 type HandlerMux struct {
-	mu sync.Mutex
-	db map[Method]Handler
+	mu       sync.Mutex
+	db       map[Method]Handler
+	services map[string]*ServiceDesc
 }
 
 func (mux *HandlerMux) Reset() {
@@ -37,6 +39,11 @@ func (mux *HandlerMux) Reset() {
 			delete(mux.db, method)
 		}
 	}
+	if mux.services != nil {
+		for name := range mux.services {
+			delete(mux.services, name)
+		}
+	}
 	mux.mu.Unlock()
 }
 
@@ -54,13 +61,37 @@ func (mux *HandlerMux) Add(h Handler, methods ...Method) {
 	}
 
 	mux.mu.Lock()
+	mux.lockedAdd(h, methods)
+	mux.mu.Unlock()
+}
+
+// AddService registers h for each method of the service, by its exact name,
+// and remembers desc so that Services can report it.
+func (mux *HandlerMux) AddService(desc *ServiceDesc, h Handler) {
+	if mux == nil || desc == nil {
+		return
+	}
+
+	if h == nil {
+		h = HandlerFunc(nil)
+	}
+
+	mux.mu.Lock()
+	mux.lockedAdd(h, desc.MethodNames())
+	if mux.services == nil {
+		mux.services = make(map[string]*ServiceDesc, 16)
+	}
+	mux.services[desc.Name] = desc
+	mux.mu.Unlock()
+}
+
+func (mux *HandlerMux) lockedAdd(h Handler, methods []Method) {
 	if mux.db == nil {
 		mux.db = make(map[Method]Handler, 64)
 	}
 	for _, method := range methods {
 		mux.db[method] = h
 	}
-	mux.mu.Unlock()
 }
 
 func (mux *HandlerMux) Remove(methods ...Method) {
@@ -74,7 +105,78 @@ func (mux *HandlerMux) Remove(methods ...Method) {
 			delete(mux.db, method)
 		}
 	}
+	for name, desc := range mux.services {
+		if !mux.lockedServes(desc) {
+			delete(mux.services, name)
+		}
+	}
+	mux.mu.Unlock()
+}
+
+// Methods returns the method names and wildcard patterns registered with the
+// mux, sorted.
+func (mux *HandlerMux) Methods() []Method {
+	if mux == nil {
+		return nil
+	}
+
+	mux.mu.Lock()
+	out := make([]Method, 0, len(mux.db))
+	for method := range mux.db {
+		out = append(out, method)
+	}
+	mux.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Services returns the services registered with AddService that still have
+// at least one method registered, sorted by name.
+func (mux *HandlerMux) Services() []*ServiceDesc {
+	if mux == nil {
+		return nil
+	}
+
+	mux.mu.Lock()
+	out := make([]*ServiceDesc, 0, len(mux.services))
+	for _, desc := range mux.services {
+		out = append(out, desc)
+	}
 	mux.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// FindMethod returns the MethodDesc for method from the services registered
+// with AddService, or nil.
+func (mux *HandlerMux) FindMethod(method Method) *MethodDesc {
+	if mux == nil {
+		return nil
+	}
+
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+
+	if _, found := mux.db[method]; !found {
+		return nil
+	}
+	for _, desc := range mux.services {
+		if md := desc.FindMethod(method); md != nil {
+			return md
+		}
+	}
+	return nil
+}
+
+func (mux *HandlerMux) lockedServes(desc *ServiceDesc) bool {
+	for _, md := range desc.Methods {
+		if _, found := mux.db[md.Name]; found {
+			return true
+		}
+	}
+	return false
 }
 
 func (mux *HandlerMux) Find(method Method, inexact bool) Handler {
@@ -0,0 +1,53 @@
+package vsrpc
+
+import (
+	"context"
+
+	"github.com/chronos-tachyon/assert"
+)
+
+// Loopback is a Server and a Client joined by an in-process PipeDialer, with
+// one Conn already established between them.  It is mostly useful in tests,
+// where it exercises the real protocol without touching the filesystem or the
+// network.
+type Loopback struct {
+	Dialer *PipeDialer
+	Server *Server
+	Client *Client
+	Conn   *Conn
+}
+
+// NewLoopback starts a Server for h and dials it.  The options apply to both
+// the Server and the Client.
+func NewLoopback(ctx context.Context, h Handler, options ...Option) (*Loopback, error) {
+	assert.NotNil(&ctx)
+
+	pd := &PipeDialer{}
+	pl, err := pd.ListenPacket(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewServer(pl, h, options...)
+	c := NewClient(pd, options...)
+	conn, err := c.Dial(ctx, pl.Addr())
+	if err != nil {
+		_ = c.Close()
+		_ = s.Close()
+		return nil, err
+	}
+
+	return &Loopback{Dialer: pd, Server: s, Client: c, Conn: conn}, nil
+}
+
+// Close closes the Client and the Server.
+func (lb *Loopback) Close() error {
+	if lb == nil {
+		return nil
+	}
+	err := lb.Client.Close()
+	if err2 := lb.Server.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
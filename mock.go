@@ -0,0 +1,254 @@
+package vsrpc
+
+import (
+	"context"
+	"iter"
+	"sync"
+
+	"github.com/chronos-tachyon/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+// MockCall is one call recorded by a generated mock client.
+type MockCall struct {
+	Method   Method
+	Requests []proto.Message
+}
+
+// MockSender is implemented by MockStream.
+type MockSender interface {
+	Sent() []proto.Message
+}
+
+// MockRecorder records the calls made through a generated mock client.  The
+// zero value is ready to use.
+type MockRecorder struct {
+	mu      sync.Mutex
+	calls   []MockCall
+	senders []MockSender
+}
+
+// Record appends a call to method and returns the number of earlier calls
+// to the same method.
+func (r *MockRecorder) Record(method Method, requests ...proto.Message) uint {
+	return r.record(method, requests, nil)
+}
+
+// RecordStream is like Record, but the requests of the call are whatever has
+// been sent on stream by the time the calls are inspected.
+func (r *MockRecorder) RecordStream(method Method, stream MockSender) uint {
+	return r.record(method, nil, stream)
+}
+
+func (r *MockRecorder) record(method Method, requests []proto.Message, sender MockSender) uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n uint
+	for _, call := range r.calls {
+		if call.Method == method {
+			n++
+		}
+	}
+	r.calls = append(r.calls, MockCall{Method: method, Requests: requests})
+	r.senders = append(r.senders, sender)
+	return n
+}
+
+// Calls returns every call recorded so far, in order.
+func (r *MockRecorder) Calls() []MockCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]MockCall, len(r.calls))
+	for index := range r.calls {
+		out[index] = r.lockedCall(index)
+	}
+	return out
+}
+
+// CallsTo returns the calls to method recorded so far, in order.
+func (r *MockRecorder) CallsTo(method Method) []MockCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []MockCall
+	for index, call := range r.calls {
+		if call.Method == method {
+			out = append(out, r.lockedCall(index))
+		}
+	}
+	return out
+}
+
+// ResetCalls forgets every call recorded so far.
+func (r *MockRecorder) ResetCalls() {
+	r.mu.Lock()
+	r.calls = nil
+	r.senders = nil
+	r.mu.Unlock()
+}
+
+func (r *MockRecorder) lockedCall(index int) MockCall {
+	call := r.calls[index]
+	if sender := r.senders[index]; sender != nil {
+		call.Requests = sender.Sent()
+	}
+	return call
+}
+
+// MockResponse picks the response for the n'th call to a mocked method: the
+// n'th element of list, or the last element once list runs out.
+func MockResponse[U proto.Message](list []U, n uint) (out U, ok bool) {
+	length := uint(len(list))
+	if length <= 0 {
+		return
+	}
+	if n >= length {
+		n = length - 1
+	}
+	return list[n], true
+}
+
+// MockStream is a ClientStream that is not attached to any Call.  It records
+// what is sent on it, yields a fixed list of responses, and then ends with
+// err.  Call and Conn return nil.
+type MockStream[T proto.Message, U proto.Message] struct {
+	ctx    context.Context
+	method Method
+	err    error
+
+	mu         sync.Mutex
+	responses  []U
+	sent       []proto.Message
+	next       int
+	sendClosed bool
+}
+
+func NewMockStream[T proto.Message, U proto.Message](ctx context.Context, method Method, responses []U, err error) *MockStream[T, U] {
+	assert.NotNil(&ctx)
+	return &MockStream[T, U]{ctx: ctx, method: method, err: err, responses: responses}
+}
+
+// SetResponses replaces the responses that have yet to be received.
+func (stream *MockStream[T, U]) SetResponses(responses ...U) {
+	stream.mu.Lock()
+	stream.responses = responses
+	stream.next = 0
+	stream.mu.Unlock()
+}
+
+func (stream *MockStream[T, U]) Call() *Call {
+	return nil
+}
+
+func (stream *MockStream[T, U]) Conn() *Conn {
+	return nil
+}
+
+func (stream *MockStream[T, U]) Context() context.Context {
+	return stream.ctx
+}
+
+func (stream *MockStream[T, U]) ID() ID {
+	return 0
+}
+
+func (stream *MockStream[T, U]) Method() Method {
+	return stream.method
+}
+
+func (stream *MockStream[T, U]) Cancel() error {
+	return nil
+}
+
+func (stream *MockStream[T, U]) Send(in T) error {
+	assert.NotNil(&in)
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	if stream.sendClosed {
+		return ErrHalfClosed
+	}
+	stream.sent = append(stream.sent, proto.Clone(in))
+	return nil
+}
+
+func (stream *MockStream[T, U]) CloseSend() error {
+	stream.mu.Lock()
+	stream.sendClosed = true
+	stream.mu.Unlock()
+	return nil
+}
+
+func (stream *MockStream[T, U]) Recv(blocking bool, out U) (ok bool, done bool, err error) {
+	assert.NotNil(&out)
+	proto.Reset(out)
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	if stream.next >= len(stream.responses) {
+		return false, true, nil
+	}
+	proto.Merge(out, stream.responses[stream.next])
+	stream.next++
+	return true, stream.next >= len(stream.responses), nil
+}
+
+// RecvContext is the same as Recv, since a MockStream never has to wait.
+func (stream *MockStream[T, U]) RecvContext(ctx context.Context, out U) (ok bool, done bool, err error) {
+	assert.NotNil(&ctx)
+	return stream.Recv(true, out)
+}
+
+func (stream *MockStream[T, U]) Ready() <-chan struct{} {
+	return closedChan
+}
+
+func (stream *MockStream[T, U]) All(ctx context.Context) iter.Seq2[U, error] {
+	return RecvAll[U](ctx, stream)
+}
+
+func (stream *MockStream[T, U]) CloseAndRecv(out U) error {
+	assert.NotNil(&out)
+
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	ok, _, err := stream.Recv(true, out)
+	if err != nil {
+		return err
+	}
+	if stream.err == nil && !ok {
+		return ErrNoResponse
+	}
+	return stream.err
+}
+
+func (stream *MockStream[T, U]) CloseAndWait() error {
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	return stream.err
+}
+
+func (stream *MockStream[T, U]) Close() error {
+	return stream.err
+}
+
+// Sent returns copies of the messages sent on the stream so far.
+func (stream *MockStream[T, U]) Sent() []proto.Message {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	out := make([]proto.Message, len(stream.sent))
+	copy(out, stream.sent)
+	return out
+}
+
+var (
+	_ ClientStream[proto.Message, proto.Message] = (*MockStream[proto.Message, proto.Message])(nil)
+	_ MockSender                                 = (*MockStream[proto.Message, proto.Message])(nil)
+)
@@ -27,6 +27,19 @@ type Observer interface {
 	OnClose(conn *Conn, err error)
 }
 
+// PanicObserver is implemented by Observers that want to know about panics in
+// Handlers.  It is separate from Observer so that existing Observers need not
+// implement it.
+type PanicObserver interface {
+	OnPanic(call *Call, err PanicError)
+}
+
+// BudgetObserver is implemented by Observers that want to know how much of its
+// deadline each Call used.  Like PanicObserver, it is separate from Observer.
+type BudgetObserver interface {
+	OnBudget(call *Call, budget Budget)
+}
+
 type BaseObserver struct{}
 
 func (BaseObserver) OnAccept(conn *Conn)     {}
@@ -42,6 +55,8 @@ func (BaseObserver) OnResponse(call *Call, payload *anypb.Any) {}
 func (BaseObserver) OnHalfClose(call *Call)                    {}
 func (BaseObserver) OnCancel(call *Call)                       {}
 func (BaseObserver) OnEnd(call *Call, status *Status)          {}
+func (BaseObserver) OnPanic(call *Call, err PanicError)        {}
+func (BaseObserver) OnBudget(call *Call, budget Budget)        {}
 
 func (BaseObserver) OnShutdown(conn *Conn) {}
 func (BaseObserver) OnGoAway(conn *Conn)   {}
@@ -50,7 +65,11 @@ func (BaseObserver) OnReadError(conn *Conn, err error)  {}
 func (BaseObserver) OnWriteError(conn *Conn, err error) {}
 func (BaseObserver) OnClose(conn *Conn, err error)      {}
 
-var _ Observer = BaseObserver{}
+var (
+	_ Observer       = BaseObserver{}
+	_ PanicObserver  = BaseObserver{}
+	_ BudgetObserver = BaseObserver{}
+)
 
 type FuncObserver struct {
 	Accept         func(conn *Conn)
@@ -66,6 +85,8 @@ type FuncObserver struct {
 	HalfClose func(call *Call)
 	Cancel    func(call *Call)
 	End       func(call *Call, status *Status)
+	Panic     func(call *Call, err PanicError)
+	Budget    func(call *Call, budget Budget)
 
 	Shutdown func(conn *Conn)
 	GoAway   func(conn *Conn)
@@ -147,6 +168,18 @@ func (o *FuncObserver) OnEnd(call *Call, status *Status) {
 	}
 }
 
+func (o *FuncObserver) OnPanic(call *Call, err PanicError) {
+	if o != nil && o.Panic != nil {
+		o.Panic(call, err)
+	}
+}
+
+func (o *FuncObserver) OnBudget(call *Call, budget Budget) {
+	if o != nil && o.Budget != nil {
+		o.Budget(call, budget)
+	}
+}
+
 func (o *FuncObserver) OnShutdown(conn *Conn) {
 	if o != nil && o.Shutdown != nil {
 		o.Shutdown(conn)
@@ -177,8 +210,16 @@ func (o *FuncObserver) OnClose(conn *Conn, err error) {
 	}
 }
 
-var _ Observer = (*FuncObserver)(nil)
+var (
+	_ Observer       = (*FuncObserver)(nil)
+	_ PanicObserver  = (*FuncObserver)(nil)
+	_ BudgetObserver = (*FuncObserver)(nil)
+)
 
+// WithObserver adds an Observer.  Given to a Client or a Server, the Observer
+// sees the events of the Client or Server and of every Conn and Call that it
+// makes, since the options of each are passed down to the next; given to
+// Conn.Begin, it sees only the events of that Call.
 func WithObserver(o Observer) Option {
 	if o == nil {
 		return (*withObserver)(nil)
@@ -208,12 +249,14 @@ func (opt *withObserver) applyToConn(conn *Conn) {
 	if opt == nil || opt.o == nil || conn == nil {
 		return
 	}
+	conn.observers = append(conn.observers, opt.o)
 }
 
 func (opt *withObserver) applyToCall(call *Call) {
 	if opt == nil || opt.o == nil || call == nil {
 		return
 	}
+	call.observers = append(call.observers, opt.o)
 }
 
 var _ Option = (*withObserver)(nil)
@@ -290,6 +333,22 @@ func onEnd(observers []Observer, call *Call, status *Status) {
 	}
 }
 
+func onPanic(observers []Observer, call *Call, err PanicError) {
+	for _, o := range observers {
+		if po, ok := o.(PanicObserver); ok {
+			go po.OnPanic(call, err)
+		}
+	}
+}
+
+func onBudget(observers []Observer, call *Call, budget Budget) {
+	for _, o := range observers {
+		if bo, ok := o.(BudgetObserver); ok {
+			go bo.OnBudget(call, budget)
+		}
+	}
+}
+
 func onShutdown(observers []Observer, conn *Conn) {
 	for _, o := range observers {
 		go o.OnShutdown(conn)
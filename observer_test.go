@@ -0,0 +1,112 @@
+package vsrpc
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventLog records the events seen by a FuncObserver as "event method"
+// strings.
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (log *eventLog) add(event string, call *Call) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if call != nil {
+		event += " " + string(call.Method())
+	}
+	log.events = append(log.events, event)
+}
+
+func (log *eventLog) observer() *FuncObserver {
+	return &FuncObserver{
+		Accept: func(conn *Conn) { log.add("accept", nil) },
+		Dial:   func(conn *Conn) { log.add("dial", nil) },
+		Begin:  func(call *Call) { log.add("begin", call) },
+		End:    func(call *Call, status *Status) { log.add("end", call) },
+	}
+}
+
+// wait waits for the observers, which run in the background, to catch up.
+func (log *eventLog) wait(t *testing.T, n int) []string {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		log.mu.Lock()
+		if len(log.events) >= n {
+			events := append([]string(nil), log.events...)
+			log.mu.Unlock()
+			sort.Strings(events)
+			return events
+		}
+		log.mu.Unlock()
+	}
+	t.Fatalf("expected %d events, got %q", n, log.events)
+	return nil
+}
+
+func TestWithObserver_Scope(t *testing.T) {
+	var server, client, perCall eventLog
+
+	ctx := context.Background()
+	pd := &PipeDialer{}
+	pl, err := pd.ListenPacket(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(pl, HandlerFunc(func(call *Call) error { return nil }), WithObserver(server.observer()))
+	defer s.Close()
+	c := NewClient(pd, WithObserver(client.observer()))
+	defer c.Close()
+
+	conn, err := c.Dial(ctx, pl.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []Method{"One", "Two"} {
+		var options []Option
+		if method == "Two" {
+			options = append(options, WithObserver(perCall.observer()))
+		}
+		call, err := conn.Begin(ctx, method, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		call.Wait()
+	}
+
+	// An Observer given to a Client or a Server sees the events of every
+	// Conn and Call that it makes; one given to Conn.Begin sees only the
+	// events of that Call.
+	type testCase struct {
+		Name   string
+		Log    *eventLog
+		Expect []string
+	}
+
+	testCases := []testCase{
+		{Name: "server", Log: &server, Expect: []string{"accept", "begin One", "begin Two", "end One", "end Two"}},
+		{Name: "client", Log: &client, Expect: []string{"dial", "end One", "end Two"}},
+		{Name: "call", Log: &perCall, Expect: []string{"end Two"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			events := tc.Log.wait(t, len(tc.Expect))
+			if len(events) != len(tc.Expect) {
+				t.Fatalf("expected %q, got %q", tc.Expect, events)
+			}
+			for index := range events {
+				if events[index] != tc.Expect[index] {
+					t.Errorf("expected %q, got %q", tc.Expect, events)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package vsrpc
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/chronos-tachyon/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+// FaultRule describes one kind of fault to inject into the packets flowing
+// through a FaultyPacketConn.
+//
+// A rule applies to packets being read if OnRead is true, to packets being
+// written if OnWrite is true, and to both if neither is set.  If FrameTypes is
+// non-empty, the rule only applies to packets that decode as a Frame of one of
+// the listed types.  The first After matching packets are let through
+// untouched, then each subsequent matching packet is faulted with the given
+// Probability (if ProbabilityEnabled) until the rule has fired Limit times (if
+// Limit is non-zero).
+type FaultRule struct {
+	Type               FaultType
+	FrameTypes         []Frame_Type
+	Err                error
+	Delay              time.Duration
+	Probability        float64
+	After              uint
+	Limit              uint
+	TruncateTo         uint
+	OnRead             bool
+	OnWrite            bool
+	ProbabilityEnabled bool
+}
+
+func (rule *FaultRule) appliesTo(isWrite bool, frameType Frame_Type, frameOK bool) bool {
+	if rule.OnRead || rule.OnWrite {
+		if isWrite && !rule.OnWrite {
+			return false
+		}
+		if !isWrite && !rule.OnRead {
+			return false
+		}
+	}
+	if len(rule.FrameTypes) <= 0 {
+		return true
+	}
+	if !frameOK {
+		return false
+	}
+	for _, t := range rule.FrameTypes {
+		if t == frameType {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule *FaultRule) err(isWrite bool) error {
+	if rule.Err != nil {
+		return rule.Err
+	}
+	return FaultError{Type: rule.Type, IsWrite: isWrite}
+}
+
+func (rule *FaultRule) truncate(packet []byte) []byte {
+	n := uint(len(packet)) / 2
+	if rule.TruncateTo != 0 && rule.TruncateTo < uint(len(packet)) {
+		n = rule.TruncateTo
+	}
+	return packet[:n]
+}
+
+type FaultyDialer struct {
+	Dialer PacketDialer
+	Clock  Clock
+	Rules  []FaultRule
+	Seed   int64
+
+	mu sync.Mutex
+	n  int64
+}
+
+func (pd *FaultyDialer) DialPacket(ctx context.Context, addr net.Addr) (PacketConn, error) {
+	assert.NotNil(&ctx)
+	assert.NotNil(&addr)
+
+	pc, err := pd.dialer().DialPacket(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &FaultyPacketConn{Conn: pc, Clock: pd.Clock, Rules: pd.Rules, Seed: pd.nextSeed()}, nil
+}
+
+func (pd *FaultyDialer) ListenPacket(ctx context.Context, addr net.Addr) (PacketListener, error) {
+	assert.NotNil(&ctx)
+	assert.NotNil(&addr)
+
+	pl, err := pd.dialer().ListenPacket(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &FaultyListener{Listener: pl, Clock: pd.Clock, Rules: pd.Rules, Seed: pd.nextSeed()}, nil
+}
+
+func (pd *FaultyDialer) dialer() PacketDialer {
+	if pd != nil && pd.Dialer != nil {
+		return pd.Dialer
+	}
+	if DefaultPacketDialer == nil {
+		panic(fmt.Errorf("vsrpc.DefaultPacketDialer is nil"))
+	}
+	return DefaultPacketDialer
+}
+
+func (pd *FaultyDialer) nextSeed() int64 {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	seed := pd.Seed + pd.n
+	pd.n++
+	return seed
+}
+
+var _ PacketDialer = (*FaultyDialer)(nil)
+
+type FaultyListener struct {
+	Listener PacketListener
+	Clock    Clock
+	Rules    []FaultRule
+	Seed     int64
+
+	mu sync.Mutex
+	n  int64
+}
+
+func (pl *FaultyListener) AcceptPacket(ctx context.Context) (PacketConn, error) {
+	if pl == nil || pl.Listener == nil {
+		return nil, ErrConnClosed
+	}
+
+	pc, err := pl.Listener.AcceptPacket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pl.mu.Lock()
+	seed := pl.Seed + pl.n
+	pl.n++
+	pl.mu.Unlock()
+
+	return &FaultyPacketConn{Conn: pc, Clock: pl.Clock, Rules: pl.Rules, Seed: seed}, nil
+}
+
+func (pl *FaultyListener) Addr() net.Addr {
+	if pl == nil || pl.Listener == nil {
+		return nil
+	}
+	return pl.Listener.Addr()
+}
+
+func (pl *FaultyListener) Close() error {
+	if pl == nil || pl.Listener == nil {
+		return nil
+	}
+	return pl.Listener.Close()
+}
+
+var _ PacketListener = (*FaultyListener)(nil)
+
+// FaultyPacketConn wraps a PacketConn and injects faults according to Rules.
+//
+// Faults are chosen by a pseudo-random number generator seeded from Seed, so a
+// given sequence of packets always experiences the same sequence of faults.
+type FaultyPacketConn struct {
+	Conn  PacketConn
+	Clock Clock
+	Rules []FaultRule
+	Seed  int64
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	seen    []uint
+	fired   []uint
+	pending [][]byte
+}
+
+func (pc *FaultyPacketConn) ReadPacket(ctx context.Context) ([]byte, func(), error) {
+	assert.NotNil(&ctx)
+
+	if pc == nil || pc.Conn == nil {
+		return nil, nil, ErrConnClosed
+	}
+
+	for {
+		if packet, ok := pc.popPending(); ok {
+			return packet, func() {}, nil
+		}
+
+		packet, dispose, err := pc.Conn.ReadPacket(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rule, ok := pc.match(false, packet)
+		if !ok {
+			return packet, dispose, nil
+		}
+
+		switch rule.Type {
+		case FaultType_Delay:
+			if err := sleepContext(ctx, pc.Clock, rule.Delay); err != nil {
+				pc.pushPending(packet)
+				dispose()
+				return nil, nil, err
+			}
+			return packet, dispose, nil
+
+		case FaultType_Drop:
+			dispose()
+
+		case FaultType_Duplicate:
+			pc.pushPending(packet)
+			return packet, dispose, nil
+
+		case FaultType_Truncate:
+			return rule.truncate(packet), dispose, nil
+
+		case FaultType_Error:
+			pc.pushPending(packet)
+			dispose()
+			return nil, nil, rule.err(false)
+
+		case FaultType_Close:
+			dispose()
+			_ = pc.Conn.Close()
+			return nil, nil, rule.err(false)
+
+		default:
+			return packet, dispose, nil
+		}
+	}
+}
+
+func (pc *FaultyPacketConn) WritePacket(ctx context.Context, packet []byte) error {
+	assert.NotNil(&ctx)
+
+	if pc == nil || pc.Conn == nil {
+		return ErrConnClosed
+	}
+
+	rule, ok := pc.match(true, packet)
+	if !ok {
+		return pc.Conn.WritePacket(ctx, packet)
+	}
+
+	switch rule.Type {
+	case FaultType_Delay:
+		if err := sleepContext(ctx, pc.Clock, rule.Delay); err != nil {
+			return err
+		}
+		return pc.Conn.WritePacket(ctx, packet)
+
+	case FaultType_Drop:
+		return nil
+
+	case FaultType_Duplicate:
+		if err := pc.Conn.WritePacket(ctx, packet); err != nil {
+			return err
+		}
+		return pc.Conn.WritePacket(ctx, packet)
+
+	case FaultType_Truncate:
+		return pc.Conn.WritePacket(ctx, rule.truncate(packet))
+
+	case FaultType_Error:
+		return rule.err(true)
+
+	case FaultType_Close:
+		_ = pc.Conn.Close()
+		return rule.err(true)
+
+	default:
+		return pc.Conn.WritePacket(ctx, packet)
+	}
+}
+
+func (pc *FaultyPacketConn) LocalAddr() net.Addr {
+	if pc == nil || pc.Conn == nil {
+		return nil
+	}
+	return pc.Conn.LocalAddr()
+}
+
+func (pc *FaultyPacketConn) RemoteAddr() net.Addr {
+	if pc == nil || pc.Conn == nil {
+		return nil
+	}
+	return pc.Conn.RemoteAddr()
+}
+
+func (pc *FaultyPacketConn) Close() error {
+	if pc == nil || pc.Conn == nil {
+		return ErrConnClosed
+	}
+	return pc.Conn.Close()
+}
+
+func (pc *FaultyPacketConn) match(isWrite bool, packet []byte) (*FaultRule, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if len(pc.Rules) <= 0 {
+		return nil, false
+	}
+
+	if pc.rng == nil {
+		pc.rng = rand.New(rand.NewSource(pc.Seed))
+		pc.seen = make([]uint, len(pc.Rules))
+		pc.fired = make([]uint, len(pc.Rules))
+	}
+
+	var frame Frame
+	frameOK := (proto.Unmarshal(packet, &frame) == nil)
+
+	for i := range pc.Rules {
+		rule := &pc.Rules[i]
+		if !rule.appliesTo(isWrite, frame.Type, frameOK) {
+			continue
+		}
+
+		pc.seen[i]++
+		if pc.seen[i] <= rule.After {
+			continue
+		}
+		if rule.Limit != 0 && pc.fired[i] >= rule.Limit {
+			continue
+		}
+		if rule.ProbabilityEnabled && pc.rng.Float64() >= rule.Probability {
+			continue
+		}
+
+		pc.fired[i]++
+		return rule, true
+	}
+	return nil, false
+}
+
+func (pc *FaultyPacketConn) pushPending(packet []byte) {
+	dupe := make([]byte, len(packet))
+	copy(dupe, packet)
+
+	pc.mu.Lock()
+	pc.pending = append(pc.pending, dupe)
+	pc.mu.Unlock()
+}
+
+func (pc *FaultyPacketConn) popPending() ([]byte, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if len(pc.pending) <= 0 {
+		return nil, false
+	}
+
+	packet := pc.pending[0]
+	pc.pending[0] = nil
+	pc.pending = pc.pending[1:]
+	return packet, true
+}
+
+var _ PacketConn = (*FaultyPacketConn)(nil)
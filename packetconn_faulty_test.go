@@ -0,0 +1,245 @@
+package vsrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func StartFaulty(ctx context.Context, t *testing.T, rules ...FaultRule) (*Server, *Client, *Conn) {
+	addr, err := net.ResolveUnixAddr("unixpacket", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pd UnixDialer
+
+	pl, err := pd.ListenPacket(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer(pl, NewTestMux())
+	c := NewClient(&FaultyDialer{Dialer: &pd, Rules: rules, Seed: 42})
+
+	addr = s.Addr().(*net.UnixAddr)
+	conn, err := c.Dial(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, c, conn
+}
+
+func TestFaulty_RecoverableReadError(t *testing.T) {
+	ctx, cancel := ContextFromTest(t)
+	defer cancel()
+
+	s, c, conn := StartFaulty(ctx, t, FaultRule{
+		Type:       FaultType_Error,
+		FrameTypes: []Frame_Type{Frame_RESPONSE},
+		Err:        RecoverableError{Err: errors.New("transient")},
+		Limit:      1,
+		OnRead:     true,
+	})
+	defer s.Close()
+	defer c.Close()
+
+	if err := CaseSumThree(ctx, t, FooClientImpl{Conn: conn}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CaseAlwaysOK(ctx, t, FooClientImpl{Conn: conn}); err != nil {
+		t.Errorf("connection did not survive recoverable error: %v", err)
+	}
+}
+
+func TestFaulty_AbortsCalls(t *testing.T) {
+	type testCase struct {
+		Name string
+		Rule FaultRule
+	}
+
+	testCases := []testCase{
+		{"ReadError", FaultRule{Type: FaultType_Error, FrameTypes: []Frame_Type{Frame_END}, OnRead: true}},
+		{"ReadClose", FaultRule{Type: FaultType_Close, FrameTypes: []Frame_Type{Frame_END}, OnRead: true}},
+		{"ReadTruncate", FaultRule{Type: FaultType_Truncate, FrameTypes: []Frame_Type{Frame_END}, TruncateTo: 3, OnRead: true}},
+		{"WriteError", FaultRule{Type: FaultType_Error, FrameTypes: []Frame_Type{Frame_HALF_CLOSE}, OnWrite: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx, cancel := ContextFromTest(t)
+			defer cancel()
+
+			s, c, conn := StartFaulty(ctx, t, tc.Rule)
+			defer s.Close()
+			defer c.Close()
+
+			call, err := conn.Begin(ctx, FooServer_AlwaysOK)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_ = call.CloseSend()
+			status := call.Wait()
+			if expect := Status_ABORTED; status.GetCode() != expect {
+				t.Errorf("wrong status code: expected %v, got %v", expect, status.GetCode())
+			}
+
+			if _, err := conn.Begin(ctx, FooServer_AlwaysOK); !errors.Is(err, ErrConnClosed) {
+				t.Errorf("expected %v, got %v", ErrConnClosed, err)
+			}
+		})
+	}
+}
+
+type recordingPacketConn struct {
+	PacketConn
+	written [][]byte
+}
+
+func (pc *recordingPacketConn) WritePacket(ctx context.Context, packet []byte) error {
+	pc.written = append(pc.written, packet)
+	return nil
+}
+
+func TestFaulty_Deterministic(t *testing.T) {
+	run := func(seed int64) string {
+		rec := &recordingPacketConn{}
+		pc := &FaultyPacketConn{
+			Conn: rec,
+			Seed: seed,
+			Rules: []FaultRule{
+				{Type: FaultType_Drop, Probability: 0.5, ProbabilityEnabled: true},
+				{Type: FaultType_Duplicate, Probability: 0.25, ProbabilityEnabled: true},
+			},
+		}
+		for i := 0; i < 64; i++ {
+			if err := pc.WritePacket(context.Background(), []byte{byte(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return fmt.Sprint(rec.written)
+	}
+
+	a, b := run(1), run(1)
+	if a != b {
+		t.Errorf("same seed produced different schedules:\n%s\n%s", a, b)
+	}
+	if c := run(2); a == c {
+		t.Errorf("different seeds produced identical schedules:\n%s", a)
+	}
+}
+
+// brokenPacketConn fails every read with a recoverable error.
+type brokenPacketConn struct {
+	recordingConn
+	reads int
+}
+
+func (pc *brokenPacketConn) ReadPacket(ctx context.Context) ([]byte, func(), error) {
+	pc.reads++
+	return nil, nil, RecoverableError{Err: errors.New("transient")}
+}
+
+func TestFaulty_PersistentReadError(t *testing.T) {
+	pc := &brokenPacketConn{}
+	conn := newConn(ClientRole, nil, nil, pc, nil)
+
+	// readThread gives up instead of spinning on the same error forever.
+	conn.readThread()
+	if pc.reads != maxReadErrors {
+		t.Errorf("expected %d reads, got %d", maxReadErrors, pc.reads)
+	}
+	if _, err := conn.Begin(context.Background(), FooServer_AlwaysOK); !errors.Is(err, ErrConnClosed) {
+		t.Errorf("expected %v, got %v", ErrConnClosed, err)
+	}
+}
+
+func TestConn_CloseEndsCalls(t *testing.T) {
+	ctx, cancel := ContextFromTest(t)
+	defer cancel()
+
+	s, c, conn := StartFaulty(ctx, t)
+	defer s.Close()
+	defer c.Close()
+
+	call, err := conn.Begin(ctx, FooServer_AlwaysOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+
+	// The call has ended by the time Close returns, without waiting.
+	call.mu.Lock()
+	state, status := call.state, call.status
+	call.mu.Unlock()
+	if state < stateClosed {
+		t.Fatal("expected the call to have ended")
+	}
+	if expect := Status_ABORTED; status.GetCode() != expect {
+		t.Errorf("wrong status code: expected %v, got %v", expect, status.GetCode())
+	}
+}
+
+func TestFaulty_SenderGivesUp(t *testing.T) {
+	ctx, cancel := ContextFromTest(t)
+	defer cancel()
+
+	// The first REQUEST is held up in WritePacket until its sender gives up.
+	// The pipe transport keeps the many small packets cheap.
+	pd := &PipeDialer{}
+	pl, err := pd.ListenPacket(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(pl, NewTestMux())
+	defer s.Close()
+	c := NewClient(&FaultyDialer{Dialer: pd, Seed: 42, Rules: []FaultRule{{
+		Type:       FaultType_Delay,
+		FrameTypes: []Frame_Type{Frame_REQUEST},
+		Delay:      time.Minute,
+		Limit:      1,
+		OnWrite:    true,
+	}}})
+	defer c.Close()
+	conn, err := c.Dial(ctx, pl.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const calls = 32
+	errCh := make(chan error, calls)
+	go func() {
+		// Cancel rather than time out, so that the server does not end
+		// the call on a deadline of its own.
+		shortCtx, shortCancel := context.WithCancel(ctx)
+		defer shortCancel()
+		time.AfterFunc(50*time.Millisecond, shortCancel)
+		errCh <- CaseSumOne(shortCtx, t, FooClientImpl{Conn: conn})
+	}()
+	for i := 1; i < calls; i++ {
+		go func() { errCh <- CaseAlwaysOK(ctx, t, FooClientImpl{Conn: conn}) }()
+	}
+
+	failed := 0
+	for i := 0; i < calls; i++ {
+		if err := <-errCh; err != nil {
+			failed++
+			if code := StatusFromError(err).GetCode(); code != Status_CANCELLED {
+				t.Errorf("expected only the call that gave up to fail, got %v", err)
+			}
+		}
+	}
+	if failed != 1 {
+		t.Errorf("expected 1 failed call, got %d", failed)
+	}
+
+	// The Conn survives the sender giving up.
+	if err := CaseSumThree(ctx, t, FooClientImpl{Conn: conn}); err != nil {
+		t.Errorf("connection did not survive a sender giving up: %v", err)
+	}
+}
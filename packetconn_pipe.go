@@ -0,0 +1,281 @@
+package vsrpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chronos-tachyon/assert"
+)
+
+const pipeQueueLength = 256
+
+// PipeAddr is the address of a PipeListener.
+type PipeAddr string
+
+func (addr PipeAddr) Network() string {
+	return "pipe"
+}
+
+func (addr PipeAddr) String() string {
+	return string(addr)
+}
+
+var _ net.Addr = PipeAddr("")
+
+// PipeDialer is a PacketDialer whose connections never leave the process.
+// Each PipeDialer has its own namespace of PipeAddr values; listening on the
+// empty address picks a fresh, unused one.
+//
+// The zero value is ready to use.
+type PipeDialer struct {
+	mu        sync.Mutex
+	listeners map[PipeAddr]*PipeListener
+	counter   uint64
+}
+
+func (pd *PipeDialer) DialPacket(ctx context.Context, addr net.Addr) (PacketConn, error) {
+	assert.NotNil(&ctx)
+	assert.NotNil(&pd)
+
+	pl := pd.find(addr)
+	if pl == nil {
+		return nil, fmt.Errorf("dial pipe %v: no such listener", addr)
+	}
+
+	local := PipeAddr(fmt.Sprintf("%s.client-%d", pl.addr, atomic.AddUint64(&pl.counter, 1)))
+	client, server := newPipePair(local, pl.addr)
+
+	select {
+	case pl.ch <- server:
+		return client, nil
+	case <-pl.done:
+		return nil, ErrConnClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (pd *PipeDialer) ListenPacket(ctx context.Context, addr net.Addr) (PacketListener, error) {
+	assert.NotNil(&ctx)
+	assert.NotNil(&pd)
+
+	var name PipeAddr
+	if addr != nil {
+		name = PipeAddr(addr.String())
+	}
+
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+
+	if name == "" {
+		pd.counter++
+		name = PipeAddr(fmt.Sprintf("pipe-%d", pd.counter))
+	}
+	if _, found := pd.listeners[name]; found {
+		return nil, fmt.Errorf("listen pipe %s: address already in use", name)
+	}
+	if pd.listeners == nil {
+		pd.listeners = make(map[PipeAddr]*PipeListener, 4)
+	}
+
+	pl := &PipeListener{
+		pd:   pd,
+		addr: name,
+		ch:   make(chan *PipeConn),
+		done: make(chan struct{}),
+	}
+	pd.listeners[name] = pl
+	return pl, nil
+}
+
+func (pd *PipeDialer) find(addr net.Addr) *PipeListener {
+	if addr == nil {
+		return nil
+	}
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	return pd.listeners[PipeAddr(addr.String())]
+}
+
+func (pd *PipeDialer) forget(pl *PipeListener) {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	if pd.listeners[pl.addr] == pl {
+		delete(pd.listeners, pl.addr)
+	}
+}
+
+var _ PacketDialer = (*PipeDialer)(nil)
+
+type PipeListener struct {
+	pd      *PipeDialer
+	addr    PipeAddr
+	ch      chan *PipeConn
+	done    chan struct{}
+	once    sync.Once
+	counter uint64
+}
+
+func (pl *PipeListener) AcceptPacket(ctx context.Context) (PacketConn, error) {
+	assert.NotNil(&ctx)
+
+	if pl == nil {
+		return nil, ErrConnClosed
+	}
+
+	select {
+	case pc := <-pl.ch:
+		return pc, nil
+	case <-pl.done:
+		return nil, ErrConnClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (pl *PipeListener) Addr() net.Addr {
+	if pl == nil {
+		return nil
+	}
+	return pl.addr
+}
+
+func (pl *PipeListener) Close() error {
+	if pl == nil {
+		return nil
+	}
+	pl.once.Do(func() {
+		pl.pd.forget(pl)
+		close(pl.done)
+	})
+	return nil
+}
+
+var _ PacketListener = (*PipeListener)(nil)
+
+// PipeConn is one end of an in-process packet pipe.  Packets are queued, so
+// WritePacket only blocks when the peer has fallen far behind.
+type PipeConn struct {
+	local  PipeAddr
+	remote PipeAddr
+	in     chan []byte
+	out    chan []byte
+	state  *pipeState
+	closed chan struct{}
+	once   sync.Once
+}
+
+type pipeState struct {
+	done chan struct{}
+	once sync.Once
+}
+
+func newPipePair(clientAddr PipeAddr, serverAddr PipeAddr) (client *PipeConn, server *PipeConn) {
+	state := &pipeState{done: make(chan struct{})}
+	c2s := make(chan []byte, pipeQueueLength)
+	s2c := make(chan []byte, pipeQueueLength)
+	client = &PipeConn{
+		local:  clientAddr,
+		remote: serverAddr,
+		in:     s2c,
+		out:    c2s,
+		state:  state,
+		closed: make(chan struct{}),
+	}
+	server = &PipeConn{
+		local:  serverAddr,
+		remote: clientAddr,
+		in:     c2s,
+		out:    s2c,
+		state:  state,
+		closed: make(chan struct{}),
+	}
+	return
+}
+
+func (pc *PipeConn) ReadPacket(ctx context.Context) (packet []byte, dispose func(), err error) {
+	assert.NotNil(&ctx)
+
+	if pc == nil {
+		return nil, nil, ErrConnClosed
+	}
+
+	select {
+	case <-pc.closed:
+		return nil, nil, ErrConnClosed
+	default:
+	}
+
+	select {
+	case packet = <-pc.in:
+		return packet, func() {}, nil
+	case <-pc.closed:
+		return nil, nil, ErrConnClosed
+	case <-pc.state.done:
+		select {
+		case packet = <-pc.in:
+			return packet, func() {}, nil
+		default:
+			return nil, nil, io.EOF
+		}
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (pc *PipeConn) WritePacket(ctx context.Context, packet []byte) error {
+	assert.NotNil(&ctx)
+
+	if pc == nil {
+		return ErrConnClosed
+	}
+
+	select {
+	case <-pc.state.done:
+		return ErrConnClosed
+	default:
+	}
+
+	dupe := make([]byte, len(packet))
+	copy(dupe, packet)
+
+	select {
+	case pc.out <- dupe:
+		return nil
+	case <-pc.state.done:
+		return ErrConnClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (pc *PipeConn) LocalAddr() net.Addr {
+	if pc == nil {
+		return nil
+	}
+	return pc.local
+}
+
+func (pc *PipeConn) RemoteAddr() net.Addr {
+	if pc == nil {
+		return nil
+	}
+	return pc.remote
+}
+
+// Close closes both ends of the pipe.  The peer can still read any packets
+// that were already queued for it, after which it sees io.EOF.
+func (pc *PipeConn) Close() error {
+	if pc == nil {
+		return ErrConnClosed
+	}
+	pc.once.Do(func() { close(pc.closed) })
+	pc.state.once.Do(func() { close(pc.state.done) })
+	return nil
+}
+
+var _ PacketConn = (*PipeConn)(nil)
@@ -0,0 +1,980 @@
+package vsrpc
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chronos-tachyon/assert"
+)
+
+const DefaultWebSocketMaxPacketSize = (1 << 24)
+
+// WebSocketSubprotocol is the Sec-WebSocket-Protocol value that identifies
+// vsrpc.  Browser clients should request it when opening the WebSocket.
+const WebSocketSubprotocol = "vsrpc"
+
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const webSocketCloseTimeout = time.Second
+
+const (
+	wsOpContinuation byte = 0x0
+	wsOpText         byte = 0x1
+	wsOpBinary       byte = 0x2
+	wsOpClose        byte = 0x8
+	wsOpPing         byte = 0x9
+	wsOpPong         byte = 0xa
+)
+
+// WebSocketAddr is the URL of a WebSocket endpoint, such as
+// "ws://example.com:8080/vsrpc" or "wss://example.com/vsrpc".
+type WebSocketAddr string
+
+func (addr WebSocketAddr) Network() string {
+	if strings.HasPrefix(strings.ToLower(string(addr)), "wss:") {
+		return "wss"
+	}
+	return "ws"
+}
+
+func (addr WebSocketAddr) String() string {
+	return string(addr)
+}
+
+var _ net.Addr = WebSocketAddr("")
+
+// WebSocketDialer is a PacketDialer that carries each packet as one binary
+// WebSocket message, so that vsrpc can pass through HTTP infrastructure.
+//
+// DialPacket performs the client side of the handshake itself, and
+// ListenPacket runs an http.Server that accepts WebSocket connections at the
+// path of its address.  To serve vsrpc from an existing http.Server instead,
+// see WebSocketHandler.
+type WebSocketDialer struct {
+	Dialer              *net.Dialer
+	ListenConfig        *net.ListenConfig
+	TLSConfig           *tls.Config
+	Header              http.Header
+	CheckOrigin         func(r *http.Request) bool
+	Now                 func() time.Time
+	MaxPacketSize       uint
+	ReadTimeout         time.Duration
+	WriteTimeout        time.Duration
+	ReadTimeoutEnabled  bool
+	WriteTimeoutEnabled bool
+}
+
+func (pd *WebSocketDialer) checkSupport(addr net.Addr) (*url.URL, error) {
+	wsAddr, ok := addr.(WebSocketAddr)
+	if !ok {
+		return nil, fmt.Errorf("vsrpc.WebSocketDialer only supports vsrpc.WebSocketAddr addresses")
+	}
+	u, err := url.Parse(string(wsAddr))
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, fmt.Errorf("vsrpc.WebSocketDialer only supports \"ws\" and \"wss\" URLs")
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u, nil
+}
+
+func (pd *WebSocketDialer) DialPacket(ctx context.Context, addr net.Addr) (PacketConn, error) {
+	assert.NotNil(&ctx)
+	assert.NotNil(&addr)
+
+	u, err := pd.checkSupport(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var zeroDialer net.Dialer
+	dialer := &zeroDialer
+	if pd != nil && pd.Dialer != nil {
+		dialer = pd.Dialer
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "wss" {
+		var config *tls.Config
+		if pd != nil && pd.TLSConfig != nil {
+			config = pd.TLSConfig.Clone()
+		} else {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	br, err := pd.handshake(ctx, conn, u)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	pc := newWebSocketConn(conn, br, true)
+	if pd != nil {
+		pc.Now = pd.Now
+		pc.MaxPacketSize = pd.MaxPacketSize
+		pc.ReadTimeout = pd.ReadTimeout
+		pc.WriteTimeout = pd.WriteTimeout
+		pc.ReadTimeoutEnabled = pd.ReadTimeoutEnabled
+		pc.WriteTimeoutEnabled = pd.WriteTimeoutEnabled
+	}
+	return pc, nil
+}
+
+func (pd *WebSocketDialer) handshake(ctx context.Context, conn net.Conn, u *url.URL) (br *bufio.Reader, err error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header, 8),
+		Host:       u.Host,
+	}
+	if pd != nil {
+		for name, values := range pd.Header {
+			req.Header[name] = values
+		}
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Protocol", WebSocketSubprotocol)
+
+	if t, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(t); err != nil {
+			return nil, err
+		}
+	}
+
+	err = Watch(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	}, func() error {
+		if err := req.Write(conn); err != nil {
+			return err
+		}
+
+		br = bufio.NewReader(conn)
+		resp, err := http.ReadResponse(br, req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			return fmt.Errorf("websocket: handshake with %s failed: %s", u, resp.Status)
+		}
+		if !headerHasToken(resp.Header, "Upgrade", "websocket") || !headerHasToken(resp.Header, "Connection", "upgrade") {
+			return fmt.Errorf("websocket: handshake with %s failed: server did not upgrade the connection", u)
+		}
+		if resp.Header.Get("Sec-WebSocket-Accept") != webSocketAccept(key) {
+			return fmt.Errorf("websocket: handshake with %s failed: wrong Sec-WebSocket-Accept", u)
+		}
+		if proto := resp.Header.Get("Sec-WebSocket-Protocol"); proto != "" && proto != WebSocketSubprotocol {
+			return fmt.Errorf("websocket: handshake with %s failed: unexpected subprotocol %q", u, proto)
+		}
+		return nil
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return nil, err
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	return br, nil
+}
+
+func (pd *WebSocketDialer) ListenPacket(ctx context.Context, addr net.Addr) (PacketListener, error) {
+	assert.NotNil(&ctx)
+	assert.NotNil(&addr)
+
+	u, err := pd.checkSupport(addr)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "wss" && (pd == nil || pd.TLSConfig == nil) {
+		return nil, fmt.Errorf("vsrpc.WebSocketDialer requires a TLSConfig to listen on \"wss\" URLs")
+	}
+
+	var zeroConfig net.ListenConfig
+	config := &zeroConfig
+	if pd != nil && pd.ListenConfig != nil {
+		config = pd.ListenConfig
+	}
+
+	listener, err := config.Listen(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "wss" {
+		listener = tls.NewListener(listener, pd.TLSConfig)
+	}
+
+	bound := *u
+	bound.Host = listener.Addr().String()
+
+	pl := &WebSocketListener{
+		addr: WebSocketAddr(bound.String()),
+		path: u.Path,
+		ch:   make(chan *WebSocketConn),
+		done: make(chan struct{}),
+	}
+	if pd != nil {
+		pl.h.CheckOrigin = pd.CheckOrigin
+		pl.h.Now = pd.Now
+		pl.h.MaxPacketSize = pd.MaxPacketSize
+		pl.h.ReadTimeout = pd.ReadTimeout
+		pl.h.WriteTimeout = pd.WriteTimeout
+		pl.h.ReadTimeoutEnabled = pd.ReadTimeoutEnabled
+		pl.h.WriteTimeoutEnabled = pd.WriteTimeoutEnabled
+	}
+	pl.server = &http.Server{Handler: http.HandlerFunc(pl.serveHTTP)}
+	go func() { _ = pl.server.Serve(listener) }()
+	return pl, nil
+}
+
+var _ PacketDialer = (*WebSocketDialer)(nil)
+
+// WebSocketListener is the PacketListener returned by
+// WebSocketDialer.ListenPacket.
+type WebSocketListener struct {
+	h      WebSocketHandler
+	server *http.Server
+	addr   WebSocketAddr
+	path   string
+	ch     chan *WebSocketConn
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (pl *WebSocketListener) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != pl.path {
+		http.NotFound(w, r)
+		return
+	}
+
+	pc, err := pl.h.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	select {
+	case pl.ch <- pc:
+	case <-pl.done:
+		_ = pc.Close()
+	}
+}
+
+func (pl *WebSocketListener) AcceptPacket(ctx context.Context) (PacketConn, error) {
+	assert.NotNil(&ctx)
+
+	if pl == nil {
+		return nil, ErrConnClosed
+	}
+
+	select {
+	case pc := <-pl.ch:
+		return pc, nil
+	case <-pl.done:
+		return nil, ErrConnClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (pl *WebSocketListener) Addr() net.Addr {
+	if pl == nil {
+		return nil
+	}
+	return pl.addr
+}
+
+func (pl *WebSocketListener) Close() error {
+	if pl == nil {
+		return nil
+	}
+	var err error
+	pl.once.Do(func() {
+		close(pl.done)
+		err = pl.server.Close()
+	})
+	return err
+}
+
+var _ PacketListener = (*WebSocketListener)(nil)
+
+// WebSocketHandler is an http.Handler that upgrades each request to a
+// WebSocket and hands the resulting connection to Server.AcceptExisting.
+//
+// Requests whose Origin header names a different host than the request itself
+// are refused, unless CheckOrigin is set and allows them.
+type WebSocketHandler struct {
+	Server              *Server
+	Options             []Option
+	CheckOrigin         func(r *http.Request) bool
+	Now                 func() time.Time
+	MaxPacketSize       uint
+	ReadTimeout         time.Duration
+	WriteTimeout        time.Duration
+	ReadTimeoutEnabled  bool
+	WriteTimeoutEnabled bool
+}
+
+func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pc, err := h.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	var s *Server
+	var options []Option
+	if h != nil {
+		s = h.Server
+		options = h.Options
+	}
+	if err := s.AcceptExisting(pc, options...); err != nil {
+		_ = pc.Close()
+	}
+}
+
+// Upgrade performs the server side of the WebSocket handshake and takes over
+// the connection.  If the request cannot be upgraded, Upgrade writes an HTTP
+// error response and returns a non-nil error.
+func (h *WebSocketHandler) Upgrade(w http.ResponseWriter, r *http.Request) (*WebSocketConn, error) {
+	fail := func(code int, format string, args ...any) (*WebSocketConn, error) {
+		err := fmt.Errorf("websocket: "+format, args...)
+		http.Error(w, err.Error(), code)
+		return nil, err
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		return fail(http.StatusMethodNotAllowed, "handshake must use GET, not %s", r.Method)
+	}
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		return fail(http.StatusUpgradeRequired, "request is not a WebSocket handshake")
+	}
+	if version := r.Header.Get("Sec-WebSocket-Version"); version != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return fail(http.StatusUpgradeRequired, "unsupported version %q", version)
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if nonce, err := base64.StdEncoding.DecodeString(key); err != nil || len(nonce) != 16 {
+		return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key %q", key)
+	}
+
+	checkOrigin := sameOrigin
+	if h != nil && h.CheckOrigin != nil {
+		checkOrigin = h.CheckOrigin
+	}
+	if !checkOrigin(r) {
+		return fail(http.StatusForbidden, "origin %q is not allowed", r.Header.Get("Origin"))
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, "%v", err)
+	}
+
+	// Undo any timeouts that the http.Server applied to the request.
+	_ = conn.SetDeadline(time.Time{})
+
+	var sb strings.Builder
+	sb.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	sb.WriteString("Upgrade: websocket\r\n")
+	sb.WriteString("Connection: Upgrade\r\n")
+	sb.WriteString("Sec-WebSocket-Accept: " + webSocketAccept(key) + "\r\n")
+	if headerHasToken(r.Header, "Sec-WebSocket-Protocol", WebSocketSubprotocol) {
+		sb.WriteString("Sec-WebSocket-Protocol: " + WebSocketSubprotocol + "\r\n")
+	}
+	sb.WriteString("\r\n")
+
+	if _, err := io.WriteString(conn, sb.String()); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	pc := newWebSocketConn(conn, brw.Reader, false)
+	if h != nil {
+		pc.Now = h.Now
+		pc.MaxPacketSize = h.MaxPacketSize
+		pc.ReadTimeout = h.ReadTimeout
+		pc.WriteTimeout = h.WriteTimeout
+		pc.ReadTimeoutEnabled = h.ReadTimeoutEnabled
+		pc.WriteTimeoutEnabled = h.WriteTimeoutEnabled
+	}
+	return pc, nil
+}
+
+var _ http.Handler = (*WebSocketHandler)(nil)
+
+// WebSocketConn is a PacketConn over a WebSocket connection.  Each packet is
+// one binary message; fragmented messages are reassembled, pings are
+// answered, and text messages are refused with a close frame.
+type WebSocketConn struct {
+	Now                 func() time.Time
+	MaxPacketSize       uint
+	ReadTimeout         time.Duration
+	WriteTimeout        time.Duration
+	ReadTimeoutEnabled  bool
+	WriteTimeoutEnabled bool
+
+	conn     net.Conn
+	br       *bufio.Reader
+	isClient bool
+	closed   atomic.Bool
+	once     sync.Once
+
+	rmu     sync.Mutex
+	rerr    error
+	partial []byte
+	started bool
+
+	wmu        sync.Mutex
+	closeSent  bool
+	controlBuf [125]byte
+}
+
+func newWebSocketConn(conn net.Conn, br *bufio.Reader, isClient bool) *WebSocketConn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	return &WebSocketConn{conn: conn, br: br, isClient: isClient}
+}
+
+func (pc *WebSocketConn) ReadPacket(ctx context.Context) (packet []byte, dispose func(), err error) {
+	assert.NotNil(&ctx)
+
+	if pc == nil || pc.conn == nil || pc.closed.Load() {
+		return nil, nil, ErrConnClosed
+	}
+
+	pc.rmu.Lock()
+	defer pc.rmu.Unlock()
+
+	if pc.rerr != nil {
+		return nil, nil, pc.rerr
+	}
+
+	var deadline time.Time
+	now := pc.now()
+	if pc.ReadTimeoutEnabled {
+		deadline = now.Add(pc.ReadTimeout)
+	}
+	if t, ok := ctx.Deadline(); ok {
+		if deadline.IsZero() || t.Before(deadline) {
+			deadline = t
+		}
+	}
+
+	err = pc.conn.SetReadDeadline(deadline)
+	if err != nil {
+		return
+	}
+
+	err = Watch(ctx, func() {
+		_ = pc.conn.SetReadDeadline(now)
+	}, func() error {
+		var err error
+		packet, err = pc.lockedReadMessage()
+		return err
+	})
+	if err != nil {
+		if pc.closed.Load() {
+			err = ErrConnClosed
+		}
+		return nil, nil, err
+	}
+	return packet, func() {}, nil
+}
+
+// lockedReadMessage reads frames until a binary message is complete.  An
+// error while waiting for the first byte of a frame leaves the connection
+// usable, so that a ReadPacket deadline can expire harmlessly; any error
+// partway through a frame is fatal.
+func (pc *WebSocketConn) lockedReadMessage() ([]byte, error) {
+	for {
+		if _, err := pc.br.Peek(1); err != nil {
+			if errors.Is(err, io.EOF) {
+				pc.rerr = io.ErrUnexpectedEOF
+				return nil, pc.rerr
+			}
+			return nil, err
+		}
+
+		packet, done, err := pc.lockedReadFrame()
+		if err != nil {
+			pc.lockedFail(err)
+			return nil, pc.rerr
+		}
+		if done {
+			return packet, nil
+		}
+	}
+}
+
+func (pc *WebSocketConn) lockedReadFrame() (packet []byte, done bool, err error) {
+	var hdr [14]byte
+	if _, err = io.ReadFull(pc.br, hdr[:2]); err != nil {
+		return
+	}
+
+	fin := (hdr[0] & 0x80) != 0
+	opcode := hdr[0] & 0x0f
+	masked := (hdr[1] & 0x80) != 0
+	length := uint64(hdr[1] & 0x7f)
+
+	if (hdr[0] & 0x70) != 0 {
+		return nil, false, WebSocketError{Code: WebSocketCloseProtocolError, Text: "reserved bits are set"}
+	}
+	if masked == pc.isClient {
+		return nil, false, WebSocketError{Code: WebSocketCloseProtocolError, Text: "wrong frame masking"}
+	}
+
+	switch length {
+	case 126:
+		if _, err = io.ReadFull(pc.br, hdr[2:4]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(hdr[2:4]))
+	case 127:
+		if _, err = io.ReadFull(pc.br, hdr[2:10]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(hdr[2:10])
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(pc.br, mask[:]); err != nil {
+			return
+		}
+	}
+
+	if (opcode & 0x8) != 0 {
+		if !fin || length > 125 {
+			return nil, false, WebSocketError{Code: WebSocketCloseProtocolError, Text: "invalid control frame"}
+		}
+		payload := make([]byte, length)
+		if _, err = io.ReadFull(pc.br, payload); err != nil {
+			return
+		}
+		maskBytes(mask, payload)
+		return nil, false, pc.handleControl(opcode, payload)
+	}
+
+	switch opcode {
+	case wsOpBinary:
+		if pc.started {
+			return nil, false, WebSocketError{Code: WebSocketCloseProtocolError, Text: "expected a continuation frame"}
+		}
+	case wsOpContinuation:
+		if !pc.started {
+			return nil, false, WebSocketError{Code: WebSocketCloseProtocolError, Text: "unexpected continuation frame"}
+		}
+	case wsOpText:
+		return nil, false, WebSocketError{Code: WebSocketCloseUnsupportedData, Text: "text messages are not supported"}
+	default:
+		return nil, false, WebSocketError{Code: WebSocketCloseProtocolError, Text: fmt.Sprintf("unknown opcode %#x", opcode)}
+	}
+
+	size := pc.MaxPacketSize
+	if size == 0 {
+		size = DefaultWebSocketMaxPacketSize
+	}
+	total := uint64(len(pc.partial)) + length
+	if length > uint64(size) || total > uint64(size) {
+		return nil, false, WebSocketError{Code: WebSocketCloseMessageTooBig, Text: fmt.Sprintf("message exceeds %d bytes", size)}
+	}
+
+	start := len(pc.partial)
+	if !pc.started && fin {
+		pc.partial = make([]byte, length)
+	} else {
+		pc.partial = append(pc.partial, make([]byte, length)...)
+	}
+	pc.started = true
+	if _, err = io.ReadFull(pc.br, pc.partial[start:]); err != nil {
+		return
+	}
+	maskBytes(mask, pc.partial[start:])
+
+	if !fin {
+		return nil, false, nil
+	}
+
+	packet = pc.partial
+	pc.partial = nil
+	pc.started = false
+	return packet, true, nil
+}
+
+func (pc *WebSocketConn) handleControl(opcode byte, payload []byte) error {
+	switch opcode {
+	case wsOpPing:
+		return pc.writeControl(wsOpPong, payload)
+
+	case wsOpPong:
+		return nil
+
+	case wsOpClose:
+		code := WebSocketCloseNoStatus
+		var text string
+		if len(payload) >= 2 {
+			code = binary.BigEndian.Uint16(payload[:2])
+			text = string(payload[2:])
+		}
+		_ = pc.writeClose(code, "")
+		switch code {
+		case WebSocketCloseNormal, WebSocketCloseGoingAway, WebSocketCloseNoStatus:
+			return io.EOF
+		}
+		return WebSocketError{Code: code, Text: text}
+
+	default:
+		return WebSocketError{Code: WebSocketCloseProtocolError, Text: fmt.Sprintf("unknown opcode %#x", opcode)}
+	}
+}
+
+// lockedFail records a fatal read error.  Errors that are the peer's fault
+// are reported to the peer with a close frame.
+func (pc *WebSocketConn) lockedFail(err error) {
+	pc.rerr = err
+	pc.partial = nil
+	pc.started = false
+
+	var wsErr WebSocketError
+	if errors.As(err, &wsErr) {
+		_ = pc.writeClose(wsErr.Code, wsErr.Text)
+	}
+}
+
+func (pc *WebSocketConn) WritePacket(ctx context.Context, packet []byte) error {
+	assert.NotNil(&ctx)
+
+	if pc == nil || pc.conn == nil || pc.closed.Load() {
+		return ErrConnClosed
+	}
+
+	size := pc.MaxPacketSize
+	if size == 0 {
+		size = DefaultWebSocketMaxPacketSize
+	}
+	if uint(len(packet)) > size {
+		return fmt.Errorf("websocket: %d-byte packet exceeds the maximum of %d bytes", len(packet), size)
+	}
+
+	pc.wmu.Lock()
+	defer pc.wmu.Unlock()
+
+	if pc.closeSent {
+		return ErrConnClosed
+	}
+
+	var deadline time.Time
+	now := pc.now()
+	if pc.WriteTimeoutEnabled {
+		deadline = now.Add(pc.WriteTimeout)
+	}
+	if t, ok := ctx.Deadline(); ok {
+		if deadline.IsZero() || t.Before(deadline) {
+			deadline = t
+		}
+	}
+
+	err := pc.conn.SetWriteDeadline(deadline)
+	if err != nil {
+		return err
+	}
+
+	err = Watch(ctx, func() {
+		_ = pc.conn.SetWriteDeadline(now)
+	}, func() error {
+		return pc.lockedWriteFrame(wsOpBinary, packet)
+	})
+	if err != nil && pc.closed.Load() {
+		err = ErrConnClosed
+	}
+	return err
+}
+
+// WritePackets sends several packets as consecutive binary frames with a
+// single write to the underlying connection.
+func (pc *WebSocketConn) WritePackets(ctx context.Context, packets [][]byte) error {
+	assert.NotNil(&ctx)
+
+	if pc == nil || pc.conn == nil || pc.closed.Load() {
+		return ErrConnClosed
+	}
+
+	size := pc.MaxPacketSize
+	if size == 0 {
+		size = DefaultWebSocketMaxPacketSize
+	}
+	total := 0
+	for _, packet := range packets {
+		if uint(len(packet)) > size {
+			return fmt.Errorf("websocket: %d-byte packet exceeds the maximum of %d bytes", len(packet), size)
+		}
+		total += 14 + len(packet)
+	}
+
+	buf := make([]byte, 0, total)
+	for _, packet := range packets {
+		var err error
+		buf, err = pc.appendFrame(buf, wsOpBinary, packet)
+		if err != nil {
+			return err
+		}
+	}
+
+	pc.wmu.Lock()
+	defer pc.wmu.Unlock()
+
+	if pc.closeSent {
+		return ErrConnClosed
+	}
+
+	var deadline time.Time
+	now := pc.now()
+	if pc.WriteTimeoutEnabled {
+		deadline = now.Add(pc.WriteTimeout)
+	}
+	if t, ok := ctx.Deadline(); ok {
+		if deadline.IsZero() || t.Before(deadline) {
+			deadline = t
+		}
+	}
+
+	err := pc.conn.SetWriteDeadline(deadline)
+	if err != nil {
+		return err
+	}
+
+	err = Watch(ctx, func() {
+		_ = pc.conn.SetWriteDeadline(now)
+	}, func() error {
+		_, err := pc.conn.Write(buf)
+		return err
+	})
+	if err != nil && pc.closed.Load() {
+		err = ErrConnClosed
+	}
+	return err
+}
+
+func (pc *WebSocketConn) writeControl(opcode byte, payload []byte) error {
+	pc.wmu.Lock()
+	defer pc.wmu.Unlock()
+
+	if pc.closeSent {
+		return nil
+	}
+	_ = pc.conn.SetWriteDeadline(pc.now().Add(webSocketCloseTimeout))
+	return pc.lockedWriteFrame(opcode, payload)
+}
+
+// writeClose sends a close frame, unless one was sent already.
+func (pc *WebSocketConn) writeClose(code uint16, text string) error {
+	pc.wmu.Lock()
+	defer pc.wmu.Unlock()
+	return pc.lockedWriteClose(code, text)
+}
+
+func (pc *WebSocketConn) lockedWriteClose(code uint16, text string) error {
+	if pc.closeSent {
+		return nil
+	}
+	pc.closeSent = true
+
+	payload := pc.controlBuf[:0]
+	if code != WebSocketCloseNoStatus {
+		payload = binary.BigEndian.AppendUint16(payload, code)
+		if len(text) > len(pc.controlBuf)-2 {
+			text = text[:len(pc.controlBuf)-2]
+		}
+		payload = append(payload, text...)
+	}
+
+	_ = pc.conn.SetWriteDeadline(pc.now().Add(webSocketCloseTimeout))
+	return pc.lockedWriteFrame(wsOpClose, payload)
+}
+
+func (pc *WebSocketConn) lockedWriteFrame(opcode byte, payload []byte) error {
+	buf, err := pc.appendFrame(make([]byte, 0, 14+len(payload)), opcode, payload)
+	if err != nil {
+		return err
+	}
+	_, err = pc.conn.Write(buf)
+	return err
+}
+
+func (pc *WebSocketConn) appendFrame(buf []byte, opcode byte, payload []byte) ([]byte, error) {
+	length := len(payload)
+	buf = append(buf, 0x80|opcode)
+
+	var maskBit byte
+	if pc.isClient {
+		maskBit = 0x80
+	}
+
+	switch {
+	case length < 126:
+		buf = append(buf, maskBit|byte(length))
+	case length <= 0xffff:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(length))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(length))
+	}
+
+	var mask [4]byte
+	if pc.isClient {
+		if _, err := rand.Read(mask[:]); err != nil {
+			return buf, err
+		}
+		buf = append(buf, mask[:]...)
+	}
+
+	start := len(buf)
+	buf = append(buf, payload...)
+	maskBytes(mask, buf[start:])
+	return buf, nil
+}
+
+func (pc *WebSocketConn) LocalAddr() net.Addr {
+	if pc == nil || pc.conn == nil {
+		return nil
+	}
+	return pc.conn.LocalAddr()
+}
+
+func (pc *WebSocketConn) RemoteAddr() net.Addr {
+	if pc == nil || pc.conn == nil {
+		return nil
+	}
+	return pc.conn.RemoteAddr()
+}
+
+// Close sends a close frame, if no write is in progress, and then closes the
+// underlying connection without waiting for the peer's reply.
+func (pc *WebSocketConn) Close() error {
+	if pc == nil || pc.conn == nil {
+		return ErrConnClosed
+	}
+
+	err := ErrConnClosed
+	pc.once.Do(func() {
+		pc.closed.Store(true)
+		if pc.wmu.TryLock() {
+			_ = pc.lockedWriteClose(WebSocketCloseNormal, "")
+			pc.wmu.Unlock()
+		}
+		err = pc.conn.Close()
+	})
+	return err
+}
+
+func (pc *WebSocketConn) now() time.Time {
+	var fn func() time.Time = time.Now
+	if pc != nil && pc.Now != nil {
+		fn = pc.Now
+	}
+	return fn()
+}
+
+var (
+	_ PacketConn        = (*WebSocketConn)(nil)
+	_ PacketBatchWriter = (*WebSocketConn)(nil)
+)
+
+func maskBytes(mask [4]byte, data []byte) {
+	if mask == [4]byte{} {
+		return
+	}
+	for i := range data {
+		data[i] ^= mask[i&3]
+	}
+}
+
+func webSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerHasToken(h http.Header, name string, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, item := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
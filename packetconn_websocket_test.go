@@ -0,0 +1,291 @@
+package vsrpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebSocket(t *testing.T) {
+	ctx, cancel := ContextFromTest(t)
+	defer cancel()
+
+	s := NewServer(nil, NewTestMux())
+	defer s.Close()
+
+	hs := httptest.NewServer(&WebSocketHandler{Server: s})
+	defer hs.Close()
+
+	c := NewClient(&WebSocketDialer{})
+	defer c.Close()
+
+	conn, err := c.Dial(ctx, WebSocketAddr("ws"+strings.TrimPrefix(hs.URL, "http")+"/vsrpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	Run(ctx, t, FooClientImpl{Conn: conn}, Cases)
+}
+
+func TestWebSocketHandler_Reject(t *testing.T) {
+	type testCase struct {
+		Name   string
+		Method string
+		Header map[string]string
+		Expect int
+	}
+
+	upgrade := map[string]string{
+		"Connection":            "keep-alive, Upgrade",
+		"Upgrade":               "websocket",
+		"Sec-WebSocket-Version": "13",
+		"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+	}
+	with := func(name, value string) map[string]string {
+		out := make(map[string]string, len(upgrade)+1)
+		for k, v := range upgrade {
+			out[k] = v
+		}
+		out[name] = value
+		return out
+	}
+
+	testCases := []testCase{
+		{Name: "post", Method: http.MethodPost, Header: upgrade, Expect: http.StatusMethodNotAllowed},
+		{Name: "plain-get", Method: http.MethodGet, Expect: http.StatusUpgradeRequired},
+		{Name: "old-version", Method: http.MethodGet, Header: with("Sec-WebSocket-Version", "8"), Expect: http.StatusUpgradeRequired},
+		{Name: "bad-key", Method: http.MethodGet, Header: with("Sec-WebSocket-Key", "short"), Expect: http.StatusBadRequest},
+		{Name: "cross-origin", Method: http.MethodGet, Header: with("Origin", "http://evil.example"), Expect: http.StatusForbidden},
+	}
+
+	s := NewServer(nil, NewTestMux())
+	defer s.Close()
+
+	hs := httptest.NewServer(&WebSocketHandler{Server: s})
+	defer hs.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.Method, hs.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, value := range tc.Header {
+				req.Header.Set(name, value)
+			}
+
+			resp, err := hs.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tc.Expect {
+				t.Errorf("expected %d, got %d", tc.Expect, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestWebSocketConn_Frames(t *testing.T) {
+	ctx, cancel := ContextFromTest(t)
+	defer cancel()
+
+	var pd WebSocketDialer
+	pl, err := pd.ListenPacket(ctx, WebSocketAddr("ws://127.0.0.1:0/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pl.Close()
+
+	type result struct {
+		pc  PacketConn
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		pc, err := pl.AcceptPacket(ctx)
+		ch <- result{pc, err}
+	}()
+
+	pc, err := pd.DialPacket(ctx, pl.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	client := pc.(*WebSocketConn)
+
+	r := <-ch
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	server := r.pc
+	defer server.Close()
+
+	// A fragmented message with a ping in the middle.
+	err = writeRawFrame(client, wsOpBinary, []byte("hello, "))
+	if err == nil {
+		err = writeRawFrame(client, 0x80|wsOpPing, []byte("ping"))
+	}
+	if err == nil {
+		err = writeRawFrame(client, 0x80|wsOpContinuation, []byte("world"))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packet, dispose, err := server.ReadPacket(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "hello, world"; string(packet) != expect {
+		t.Errorf("expected %q, got %q", expect, packet)
+	}
+	dispose()
+
+	// The pong is consumed by ReadPacket, so look at the raw stream.
+	var hdr [2]byte
+	if _, err := io.ReadFull(client.br, hdr[:]); err != nil {
+		t.Fatal(err)
+	}
+	pong := make([]byte, hdr[1]&0x7f)
+	if _, err := io.ReadFull(client.br, pong); err != nil {
+		t.Fatal(err)
+	}
+	if hdr[0] != 0x80|wsOpPong || string(pong) != "ping" {
+		t.Errorf("expected pong \"ping\", got opcode %#x payload %q", hdr[0]&0x0f, pong)
+	}
+
+	// Text messages are refused with a close frame.
+	if err := writeRawFrame(client, 0x80|wsOpText, []byte("text")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = server.ReadPacket(ctx)
+	var wsErr WebSocketError
+	if !errors.As(err, &wsErr) || wsErr.Code != WebSocketCloseUnsupportedData {
+		t.Errorf("expected a WebSocketError with code %d, got %v", WebSocketCloseUnsupportedData, err)
+	}
+
+	_, _, err = client.ReadPacket(ctx)
+	if !errors.As(err, &wsErr) || wsErr.Code != WebSocketCloseUnsupportedData {
+		t.Errorf("expected the client to see close code %d, got %v", WebSocketCloseUnsupportedData, err)
+	}
+}
+
+func TestWebSocketConn_MaxPacketSize(t *testing.T) {
+	ctx, cancel := ContextFromTest(t)
+	defer cancel()
+
+	pd := WebSocketDialer{MaxPacketSize: 1024}
+	pl, err := pd.ListenPacket(ctx, WebSocketAddr("ws://127.0.0.1:0/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pl.Close()
+
+	go func() {
+		pc, err := pl.AcceptPacket(ctx)
+		if err == nil {
+			packet := bytes.Repeat([]byte{0x5a}, 1024)
+			_ = pc.WritePacket(ctx, packet)
+			_ = writeRawFrame(pc.(*WebSocketConn), 0x80|wsOpBinary, append(packet, 0))
+		}
+	}()
+
+	pc, err := (&WebSocketDialer{MaxPacketSize: 1024}).DialPacket(ctx, pl.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	if err := pc.WritePacket(ctx, make([]byte, 1025)); err == nil {
+		t.Error("expected WritePacket to refuse an oversized packet")
+	}
+
+	packet, dispose, err := pc.ReadPacket(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(packet); n != 1024 {
+		t.Errorf("expected 1024 bytes, got %d", n)
+	}
+	dispose()
+
+	_, _, err = pc.ReadPacket(ctx)
+	var wsErr WebSocketError
+	if !errors.As(err, &wsErr) || wsErr.Code != WebSocketCloseMessageTooBig {
+		t.Errorf("expected a WebSocketError with code %d, got %v", WebSocketCloseMessageTooBig, err)
+	}
+}
+
+// writeRawFrame writes a single frame with the given first header byte,
+// bypassing the checks in WebSocketConn.  Client frames use an all-zero mask.
+func writeRawFrame(pc *WebSocketConn, b0 byte, payload []byte) error {
+	var maskBit byte
+	if pc.isClient {
+		maskBit = 0x80
+	}
+
+	buf := []byte{b0}
+	switch {
+	case len(payload) < 126:
+		buf = append(buf, maskBit|byte(len(payload)))
+	default:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(payload)))
+	}
+	if pc.isClient {
+		buf = append(buf, 0, 0, 0, 0)
+	}
+	buf = append(buf, payload...)
+
+	pc.wmu.Lock()
+	defer pc.wmu.Unlock()
+	_, err := pc.conn.Write(buf)
+	return err
+}
+
+func TestWebSocketConn_WritePackets(t *testing.T) {
+	ctx, cancel := ContextFromTest(t)
+	defer cancel()
+
+	var pd WebSocketDialer
+	pl, err := pd.ListenPacket(ctx, WebSocketAddr("ws://127.0.0.1:0/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pl.Close()
+
+	packets := [][]byte{[]byte("a"), bytes.Repeat([]byte{0x5a}, 300), {}, []byte("d")}
+
+	go func() {
+		pc, err := pl.AcceptPacket(ctx)
+		if err == nil {
+			_ = pc.(PacketBatchWriter).WritePackets(ctx, packets)
+		}
+	}()
+
+	pc, err := pd.DialPacket(ctx, pl.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	for index, expect := range packets {
+		packet, dispose, err := pc.ReadPacket(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(packet, expect) {
+			t.Errorf("packet #%d: expected %d bytes, got %d", index, len(expect), len(packet))
+		}
+		dispose()
+	}
+}
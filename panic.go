@@ -0,0 +1,99 @@
+package vsrpc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PanicPolicy controls what happens when a Handler panics.  Whatever the
+// policy, the panic is reported to the Observers with OnPanic and the call
+// ends with Status_INTERNAL.
+type PanicPolicy struct {
+	// SendStack adds a DebugInfo detail holding the stack to the status that
+	// is sent to the client.  It is off by default, because the stack
+	// reveals details of the server's internals.
+	SendStack bool
+
+	// Crash ends the process once the panic has been reported, as an
+	// unrecovered panic would, for deployments that prefer to fail fast.
+	Crash bool
+}
+
+func WithPanicPolicy(policy *PanicPolicy) Option {
+	if policy == nil {
+		return (*withPanicPolicy)(nil)
+	}
+	return &withPanicPolicy{policy: *policy}
+}
+
+type withPanicPolicy struct {
+	policy PanicPolicy
+}
+
+func (opt *withPanicPolicy) applyToClient(c *Client) {}
+
+func (opt *withPanicPolicy) applyToServer(s *Server) {}
+
+func (opt *withPanicPolicy) applyToConn(conn *Conn) {
+	if opt == nil || conn == nil {
+		return
+	}
+	conn.panicPolicy = opt.policy
+}
+
+func (opt *withPanicPolicy) applyToCall(call *Call) {}
+
+var _ Option = (*withPanicPolicy)(nil)
+
+// crashProcess ends the process after a panic, unless replaced by a test.
+var crashProcess = func(err PanicError) {
+	fmt.Fprintf(os.Stderr, "panic: %v\n\n%s", err.Err, err.Stack)
+	os.Exit(2)
+}
+
+func (conn *Conn) gotPanic(call *Call, err PanicError, status *Status) {
+	policy := conn.panicPolicy
+
+	if policy.SendStack {
+		_ = status.AddDetails(panicDebugInfo(err))
+	}
+
+	if !policy.Crash {
+		onPanic(call.observers, call, err)
+		return
+	}
+
+	// The process is about to exit, so the observers cannot run in the
+	// background.
+	for _, o := range call.observers {
+		if po, ok := o.(PanicObserver); ok {
+			neverPanic(func() { po.OnPanic(call, err) })
+		}
+	}
+}
+
+// panicDebugInfo converts the output of debug.Stack into a DebugInfo, leaving
+// out the frames of the recovery machinery above the panicking function.
+func panicDebugInfo(err PanicError) *DebugInfo {
+	lines := strings.Split(string(bytes.TrimSpace(err.Stack)), "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "goroutine ") {
+		lines = lines[1:]
+	}
+
+	entries := make([]string, 0, len(lines)/2)
+	for i := 0; i < len(lines); i += 2 {
+		entry := lines[i]
+		if i+1 < len(lines) {
+			entry += " (" + strings.TrimSpace(lines[i+1]) + ")"
+		}
+		if strings.HasPrefix(entry, "panic(") {
+			entries = entries[:0]
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return &DebugInfo{Detail: fmt.Sprint(err.Err), StackEntries: entries}
+}
@@ -0,0 +1,100 @@
+package vsrpc
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// plainObserver implements Observer but not PanicObserver, like an Observer
+// written before PanicObserver existed.
+type plainObserver struct {
+	Observer
+}
+
+func TestPanicPolicy(t *testing.T) {
+	type testCase struct {
+		Name   string
+		Policy *PanicPolicy
+	}
+
+	testCases := []testCase{
+		{Name: "default", Policy: nil},
+		{Name: "send-stack", Policy: &PanicPolicy{SendStack: true}},
+		{Name: "crash", Policy: &PanicPolicy{Crash: true}},
+	}
+
+	handler := HandlerFunc(func(call *Call) error {
+		panic("boom")
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			panicCh := make(chan PanicError, 1)
+			crashCh := make(chan PanicError, 1)
+
+			saved := crashProcess
+			crashProcess = func(err PanicError) { crashCh <- err }
+			defer func() { crashProcess = saved }()
+
+			lb, err := NewLoopback(
+				context.Background(),
+				handler,
+				WithPanicPolicy(tc.Policy),
+				WithObserver(&FuncObserver{Panic: func(call *Call, err PanicError) { panicCh <- err }}),
+				WithObserver(plainObserver{BaseObserver{}}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = lb.Close() }()
+
+			call, err := lb.Conn.Begin(context.Background(), FooServer_AlwaysOK)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := call.CloseSend(); err != nil {
+				t.Fatal(err)
+			}
+
+			status := call.Wait()
+			if status.Code != Status_INTERNAL || status.Text != "panic! boom" {
+				t.Errorf("expected INTERNAL \"panic! boom\", got %v", status)
+			}
+
+			sendStack := tc.Policy != nil && tc.Policy.SendStack
+			crash := tc.Policy != nil && tc.Policy.Crash
+
+			info := status.DebugInfo()
+			switch {
+			case sendStack && info == nil:
+				t.Error("expected a DebugInfo detail")
+			case sendStack && (len(info.StackEntries) == 0 || !strings.Contains(info.StackEntries[0], "TestPanicPolicy")):
+				t.Errorf("expected the stack to start in the handler, got %q", info.StackEntries)
+			case !sendStack && info != nil:
+				t.Errorf("expected no DebugInfo detail, got %v", info)
+			}
+
+			select {
+			case perr := <-panicCh:
+				if !bytes.Contains(perr.Stack, []byte("panic_test.go")) {
+					t.Errorf("expected the stack to mention panic_test.go, got:\n%s", perr.Stack)
+				}
+			case <-time.After(5 * time.Second):
+				t.Error("OnPanic was not called")
+			}
+
+			if crash {
+				select {
+				case <-crashCh:
+				case <-time.After(5 * time.Second):
+					t.Error("the process was not crashed")
+				}
+			} else if len(crashCh) != 0 {
+				t.Error("the process was crashed")
+			}
+		})
+	}
+}
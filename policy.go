@@ -0,0 +1,266 @@
+package vsrpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	defaultInitialBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff        = 10 * time.Second
+	defaultBackoffMultiplier = 2.0
+)
+
+// WithMethodPolicy attaches a MethodPolicy to a Call.  The policy's timeout
+// becomes the deadline of the call's Context (unless an earlier deadline is
+// already in effect), and its size limits are checked on every Send and Recv.
+//
+// Generated client stubs pass the (vsrpc.policy) option of each method to
+// Begin this way, ahead of the caller's own options.
+func WithMethodPolicy(policy *MethodPolicy) Option {
+	if policy == nil {
+		return (*withMethodPolicy)(nil)
+	}
+	return &withMethodPolicy{policy: policy}
+}
+
+type withMethodPolicy struct {
+	policy *MethodPolicy
+}
+
+func (opt *withMethodPolicy) applyToClient(c *Client) {}
+
+func (opt *withMethodPolicy) applyToServer(s *Server) {}
+
+func (opt *withMethodPolicy) applyToConn(conn *Conn) {}
+
+func (opt *withMethodPolicy) applyToCall(call *Call) {
+	if opt == nil || call == nil {
+		return
+	}
+	call.policy = opt.policy
+}
+
+var _ Option = (*withMethodPolicy)(nil)
+
+// Authorizer decides whether a call may proceed under the AuthPolicy of its
+// method.  A non-nil error rejects the call; a StatusError is sent to the
+// client as-is, and any other error becomes PERMISSION_DENIED.
+type Authorizer interface {
+	Authorize(call *Call, auth *AuthPolicy) error
+}
+
+// AuthorizerFunc adapts a function to the Authorizer interface.
+type AuthorizerFunc func(call *Call, auth *AuthPolicy) error
+
+func (fn AuthorizerFunc) Authorize(call *Call, auth *AuthPolicy) error {
+	return fn(call, auth)
+}
+
+var _ Authorizer = AuthorizerFunc(nil)
+
+// WithAuthorizer sets the Authorizer that Call.ApplyPolicy consults for
+// methods whose policy requires authorization.
+func WithAuthorizer(a Authorizer) Option {
+	if a == nil {
+		return (*withAuthorizer)(nil)
+	}
+	return &withAuthorizer{a: a}
+}
+
+type withAuthorizer struct {
+	a Authorizer
+}
+
+func (opt *withAuthorizer) applyToClient(c *Client) {}
+
+func (opt *withAuthorizer) applyToServer(s *Server) {}
+
+func (opt *withAuthorizer) applyToConn(conn *Conn) {}
+
+func (opt *withAuthorizer) applyToCall(call *Call) {
+	if opt == nil || call == nil {
+		return
+	}
+	call.authorizer = opt.a
+}
+
+var _ Option = (*withAuthorizer)(nil)
+
+// IsAuthRequired reports whether the policy asks the server to authorize the
+// caller.
+func (policy *MethodPolicy) IsAuthRequired() bool {
+	auth := policy.GetAuth()
+	return auth.GetRequired() || len(auth.GetScopes()) > 0
+}
+
+// IsRetryable reports whether a call that failed with status may be retried
+// under the policy.
+func (policy *RetryPolicy) IsRetryable(status *Status) bool {
+	if status.IsOK() {
+		return false
+	}
+	if status.CanRetry {
+		return true
+	}
+	codes := policy.GetRetryableCodes()
+	if len(codes) <= 0 {
+		return status.Code == Status_UNAVAILABLE
+	}
+	for _, code := range codes {
+		if status.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the delay before the given retry, counting from 1.
+func (policy *RetryPolicy) Backoff(retry uint) time.Duration {
+	initial := defaultInitialBackoff
+	if d := policy.GetInitialBackoff(); d != nil {
+		initial = d.AsDuration()
+	}
+
+	limit := defaultMaxBackoff
+	if d := policy.GetMaxBackoff(); d != nil {
+		limit = d.AsDuration()
+	}
+
+	multiplier := defaultBackoffMultiplier
+	if m := policy.GetBackoffMultiplier(); m > 0 {
+		multiplier = m
+	}
+
+	d := float64(initial)
+	for i := uint(1); i < retry && d < float64(limit); i++ {
+		d *= multiplier
+	}
+	if d > float64(limit) {
+		return limit
+	}
+	return time.Duration(d)
+}
+
+// Retry calls fn until it succeeds, until it fails with an error that the
+// RetryPolicy of policy does not consider retryable, or until the attempts
+// run out.  The delays between attempts are measured by clock.  Generated
+// client stubs use Retry for unary methods that are declared idempotent.
+func Retry(ctx context.Context, clock Clock, policy *MethodPolicy, fn func() error) error {
+	retry := policy.GetRetry()
+	attempts := uint(retry.GetMaxAttempts())
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := uint(1); attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			if sleepContext(ctx, clock, retry.Backoff(attempt-1)) != nil {
+				return err
+			}
+		}
+
+		err = fn()
+		if err == nil || !retry.IsRetryable(StatusFromError(err)) {
+			return err
+		}
+	}
+	return err
+}
+
+// Policy returns the MethodPolicy that applies to the call, or nil.
+func (call *Call) Policy() *MethodPolicy {
+	if call == nil {
+		return nil
+	}
+
+	call.mu.Lock()
+	defer call.mu.Unlock()
+	return call.policy
+}
+
+// ApplyPolicy puts the call under policy after it has begun.  Generated
+// server handlers call it with the (vsrpc.policy) option of the method before
+// invoking the implementation: the policy's timeout tightens the deadline of
+// the call's Context, its size limits are enforced from then on, and for a
+// server call, its AuthPolicy is checked with the call's Authorizer.
+func (call *Call) ApplyPolicy(policy *MethodPolicy) error {
+	if call == nil {
+		return ErrCallClosed
+	}
+	if policy == nil {
+		return nil
+	}
+
+	call.mu.Lock()
+	call.policy = policy
+	if d := policy.GetTimeout(); d != nil {
+		call.lockedSetDeadline(call.Clock().Now().Add(d.AsDuration()))
+	}
+	authorizer := call.authorizer
+	call.mu.Unlock()
+
+	if call.role != ServerRole || !policy.IsAuthRequired() {
+		return nil
+	}
+	if authorizer == nil {
+		return AuthError{Method: call.method}
+	}
+	if err := authorizer.Authorize(call, policy.GetAuth()); err != nil {
+		return AuthError{Method: call.method, Err: err}
+	}
+	return nil
+}
+
+func (call *Call) lockedSetDeadline(t time.Time) {
+	if !call.deadline.IsZero() && !t.Before(call.deadline) {
+		return
+	}
+
+	call.deadline = t
+	ctx, cancel := WithContextDeadline(call.ctxInner, call.clock, t)
+	parentCancel := call.cancel
+	call.ctxInner = ctx
+	call.cancel = func() {
+		cancel()
+		parentCancel()
+	}
+	call.lockedWatchChildren()
+}
+
+// checkSize enforces the size limit of the policy on a payload that is being
+// sent (outgoing) or received by the call.
+func (call *Call) checkSize(payload *anypb.Any, outgoing bool) error {
+	call.mu.Lock()
+	defer call.mu.Unlock()
+	return call.lockedCheckSize(payload, outgoing)
+}
+
+func (call *Call) lockedCheckSize(payload *anypb.Any, outgoing bool) error {
+	policy := call.policy
+	if policy == nil {
+		return nil
+	}
+
+	sender := call.role
+	if !outgoing {
+		sender = ClientRole
+		if call.role == ClientRole {
+			sender = ServerRole
+		}
+	}
+
+	limit := policy.GetMaxRequestBytes()
+	if sender == ServerRole {
+		limit = policy.GetMaxResponseBytes()
+	}
+
+	size := uint64(len(payload.GetValue()))
+	if limit <= 0 || size <= limit {
+		return nil
+	}
+	return MessageSizeError{Method: call.method, Role: sender, Size: size, Limit: limit}
+}
@@ -0,0 +1,246 @@
+package vsrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	type testCase struct {
+		Name   string
+		Policy *RetryPolicy
+		Retry  uint
+		Expect time.Duration
+	}
+
+	testCases := []testCase{
+		{Name: "default-1", Policy: nil, Retry: 1, Expect: 100 * time.Millisecond},
+		{Name: "default-3", Policy: nil, Retry: 3, Expect: 400 * time.Millisecond},
+		{Name: "default-capped", Policy: nil, Retry: 20, Expect: 10 * time.Second},
+		{
+			Name: "custom",
+			Policy: &RetryPolicy{
+				InitialBackoff:    durationpb.New(10 * time.Millisecond),
+				MaxBackoff:        durationpb.New(50 * time.Millisecond),
+				BackoffMultiplier: 3,
+			},
+			Retry:  2,
+			Expect: 30 * time.Millisecond,
+		},
+		{
+			Name: "custom-capped",
+			Policy: &RetryPolicy{
+				InitialBackoff:    durationpb.New(10 * time.Millisecond),
+				MaxBackoff:        durationpb.New(50 * time.Millisecond),
+				BackoffMultiplier: 3,
+			},
+			Retry:  3,
+			Expect: 50 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if actual := tc.Policy.Backoff(tc.Retry); actual != tc.Expect {
+				t.Errorf("expected %v, got %v", tc.Expect, actual)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	policy := &MethodPolicy{
+		Idempotency: MethodPolicy_IDEMPOTENT,
+		Retry: &RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: durationpb.New(time.Millisecond),
+			RetryableCodes: []Status_Code{Status_ABORTED},
+		},
+	}
+
+	type testCase struct {
+		Name     string
+		Errors   []error
+		Attempts int
+		Expect   Status_Code
+	}
+
+	aborted := (&Status{Code: Status_ABORTED}).AsError()
+	internal := (&Status{Code: Status_INTERNAL}).AsError()
+	canRetry := (&Status{Code: Status_INTERNAL, CanRetry: true}).AsError()
+
+	testCases := []testCase{
+		{Name: "ok", Errors: []error{nil}, Attempts: 1, Expect: Status_OK},
+		{Name: "recovers", Errors: []error{aborted, aborted, nil}, Attempts: 3, Expect: Status_OK},
+		{Name: "exhausted", Errors: []error{aborted, aborted, aborted, nil}, Attempts: 3, Expect: Status_ABORTED},
+		{Name: "not-retryable", Errors: []error{internal, nil}, Attempts: 1, Expect: Status_INTERNAL},
+		{Name: "can-retry", Errors: []error{canRetry, nil}, Attempts: 2, Expect: Status_OK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int
+			err := Retry(context.Background(), nil, policy, func() error {
+				err := tc.Errors[attempts]
+				attempts++
+				return err
+			})
+			if attempts != tc.Attempts {
+				t.Errorf("expected %d attempts, got %d", tc.Attempts, attempts)
+			}
+			if code := StatusFromError(err).Code; code != tc.Expect {
+				t.Errorf("expected %v, got %v", tc.Expect, code)
+			}
+		})
+	}
+}
+
+func TestCall_ApplyPolicy_Auth(t *testing.T) {
+	policy := &MethodPolicy{Auth: &AuthPolicy{Scopes: []string{"sum"}}}
+	handler := HandlerFunc(func(call *Call) error {
+		return call.ApplyPolicy(policy)
+	})
+
+	type testCase struct {
+		Name    string
+		Options []Option
+		Expect  Status_Code
+	}
+
+	testCases := []testCase{
+		{Name: "no-authorizer", Expect: Status_UNAUTHENTICATED},
+		{
+			Name: "denied",
+			Options: []Option{WithAuthorizer(AuthorizerFunc(func(call *Call, auth *AuthPolicy) error {
+				return errors.New("nope")
+			}))},
+			Expect: Status_PERMISSION_DENIED,
+		},
+		{
+			Name: "allowed",
+			Options: []Option{WithAuthorizer(AuthorizerFunc(func(call *Call, auth *AuthPolicy) error {
+				if len(auth.Scopes) != 1 || auth.Scopes[0] != "sum" {
+					return errors.New("wrong scopes")
+				}
+				return nil
+			}))},
+			Expect: Status_OK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			lb, err := NewLoopback(context.Background(), handler, tc.Options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = lb.Close() }()
+
+			call, err := lb.Conn.Begin(context.Background(), FooServer_Sum)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := call.CloseSend(); err != nil {
+				t.Fatal(err)
+			}
+			if code := call.Wait().Code; code != tc.Expect {
+				t.Errorf("expected %v, got %v", tc.Expect, code)
+			}
+		})
+	}
+}
+
+func TestCall_ApplyPolicy_Timeout(t *testing.T) {
+	errCh := make(chan error, 1)
+	lb := newTestLoopback(t, func(call *Call) error {
+		err := call.ApplyPolicy(&MethodPolicy{Timeout: durationpb.New(20 * time.Millisecond)})
+		if err == nil {
+			<-call.Context().Done()
+			err = call.Context().Err()
+		}
+		errCh <- err
+		return err
+	})
+
+	call, err := lb.Conn.Begin(context.Background(), FooServer_Sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = call.Close() }()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the policy timeout did not expire the call's Context")
+	}
+}
+
+func TestCall_MaxMessageBytes(t *testing.T) {
+	policy := &MethodPolicy{MaxRequestBytes: 4, MaxResponseBytes: 4}
+	lb := newTestLoopback(t, func(call *Call) error {
+		if err := call.ApplyPolicy(policy); err != nil {
+			return err
+		}
+		stream := NewStream[*SumResponse, *SumRequest](call)
+		var req SumRequest
+		if _, _, err := stream.Recv(true, &req); err != nil {
+			return err
+		}
+		var sum int32
+		for _, n := range req.Input {
+			sum += n
+		}
+		return stream.Send(&SumResponse{Output: sum})
+	})
+
+	ctx := context.Background()
+
+	call, err := lb.Conn.Begin(ctx, FooServer_Sum, WithMethodPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := NewStream[*SumRequest, *SumResponse](call)
+	err = stream.Send(&SumRequest{Input: []int32{1, 2, 3, 4, 5}})
+	var serr MessageSizeError
+	if !errors.As(err, &serr) || serr.Role != ClientRole || serr.Limit != 4 {
+		t.Errorf("expected a MessageSizeError for the request, got %v", err)
+	}
+	_ = call.Close()
+
+	// The server enforces the request limit even if the client does not.
+	call, err = lb.Conn.Begin(ctx, FooServer_Sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream = NewStream[*SumRequest, *SumResponse](call)
+	if err := stream.Send(&SumRequest{Input: []int32{1, 2, 3, 4, 5}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if code := call.Wait().Code; code != Status_RESOURCE_EXHAUSTED {
+		t.Errorf("expected %v, got %v", Status_RESOURCE_EXHAUSTED, code)
+	}
+
+	// The response fits into 4 bytes.
+	call, err = lb.Conn.Begin(ctx, FooServer_Sum, WithMethodPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream = NewStream[*SumRequest, *SumResponse](call)
+	if err := stream.Send(&SumRequest{Input: []int32{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	var resp SumResponse
+	if err := NewClientStream[*SumRequest, *SumResponse](call).CloseAndRecv(&resp); err != nil || resp.Output != 3 {
+		t.Errorf("expected 3, got %d (err=%v)", resp.Output, err)
+	}
+}
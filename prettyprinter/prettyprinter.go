@@ -21,6 +21,15 @@ func (NoOp) PrettyPrintTo(buf []byte, detail *anypb.Any) []byte {
 
 var _ PrettyPrinter = NoOp{}
 
+// Func adapts an ordinary function into a PrettyPrinter.
+type Func func(buf []byte, detail *anypb.Any) []byte
+
+func (fn Func) PrettyPrintTo(buf []byte, detail *anypb.Any) []byte {
+	return fn(buf, detail)
+}
+
+var _ PrettyPrinter = Func(nil)
+
 type Registry struct {
 	mu sync.Mutex
 	db map[protoreflect.FullName]PrettyPrinter
@@ -90,6 +99,17 @@ var _ PrettyPrinter = (*Registry)(nil)
 
 var GlobalRegistry atomic.Pointer[Registry]
 
+// Global returns the Registry in GlobalRegistry, installing an empty one
+// first if there is none.
+func Global() *Registry {
+	for {
+		if reg := GlobalRegistry.Load(); reg != nil {
+			return reg
+		}
+		GlobalRegistry.CompareAndSwap(nil, &Registry{})
+	}
+}
+
 func PrettyPrintTo(buf []byte, detail *anypb.Any) []byte {
 	return GlobalRegistry.Load().PrettyPrintTo(buf, detail)
 }
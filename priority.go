@@ -0,0 +1,55 @@
+package vsrpc
+
+// DefaultPriority is the priority of a Call that does not set one.
+const DefaultPriority uint32 = 16
+
+// MaxPriority is the highest priority a Call may have.  Higher priorities,
+// whether set locally or sent by the peer in BEGIN, are lowered to it, so that
+// no Call can take more than 64 times the share of a Call with
+// DefaultPriority.
+const MaxPriority uint32 = 64 * DefaultPriority
+
+// WithPriority sets the priority of a Call.  The calls on a Conn share its
+// outbound frames in proportion to their priorities, so a call with priority
+// 64 gets four times the share of a call with DefaultPriority.  Zero means
+// DefaultPriority, and values above MaxPriority mean MaxPriority.
+//
+// The client sends the priority in the BEGIN frame, so that the server
+// schedules the RESPONSE frames of the call the same way.
+func WithPriority(priority uint32) Option {
+	return &withPriority{priority: priority}
+}
+
+type withPriority struct {
+	priority uint32
+}
+
+func (opt *withPriority) applyToClient(c *Client) {}
+
+func (opt *withPriority) applyToServer(s *Server) {}
+
+func (opt *withPriority) applyToConn(conn *Conn) {}
+
+func (opt *withPriority) applyToCall(call *Call) {
+	if opt == nil || call == nil {
+		return
+	}
+	call.priority = clampPriority(opt.priority)
+}
+
+var _ Option = (*withPriority)(nil)
+
+// Priority returns the priority of the Call.
+func (call *Call) Priority() uint32 {
+	if call == nil || call.priority == 0 {
+		return DefaultPriority
+	}
+	return clampPriority(call.priority)
+}
+
+func clampPriority(priority uint32) uint32 {
+	if priority > MaxPriority {
+		return MaxPriority
+	}
+	return priority
+}
@@ -0,0 +1,137 @@
+package vsrpc
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPriority_UnaryDuringBulk(t *testing.T) {
+	const (
+		bulkSize    = 32 << 10
+		bulkCalls   = 6
+		unaryCalls  = 10
+		unaryFrames = 5
+		unarySize   = 16 << 10
+	)
+
+	// Drive the writer by hand: each frame it hands out stands for one write
+	// on a congested link, so the number of bulk frames written before the
+	// last frame of a unary call measures how long the unary call waited.
+	run := func(priority uint32) int {
+		conn := newConn(ClientRole, nil, nil, &recordingConn{}, nil)
+		defer conn.writer.close()
+
+		ctx := context.Background()
+		bulk := strings.Repeat("b", bulkSize)
+		for i := 0; i < bulkCalls; i++ {
+			call := &Call{}
+			for j := 0; j < unaryCalls*unaryFrames; j++ {
+				queueRaw(t, conn.writer, ctx, call, bulk)
+			}
+		}
+
+		// Let the bulk transfer get going.
+		for i := 0; i < bulkCalls; i++ {
+			conn.writer.next(nil)
+		}
+
+		worst := 0
+		for i := 0; i < unaryCalls; i++ {
+			unary := &Call{priority: priority}
+			for j := 0; j < unaryFrames; j++ {
+				queueRaw(t, conn.writer, ctx, unary, strings.Repeat("u", unarySize))
+			}
+
+			waited, left := 0, unaryFrames
+			for left > 0 {
+				if batch := conn.writer.next(nil); batch[0].call == unary {
+					left--
+				} else {
+					waited++
+				}
+			}
+			conn.writer.forget(unary)
+			worst = max(worst, waited)
+		}
+		return worst
+	}
+
+	// With the default priority, the unary call takes turns with every bulk
+	// call; with a high priority, it waits behind at most one bulk frame.
+	if worst := run(0); worst < bulkCalls {
+		t.Errorf("expected the default priority to wait behind at least %d bulk frames, got %d", bulkCalls, worst)
+	}
+	if worst := run(64 * DefaultPriority); worst > 1 {
+		t.Errorf("expected a high priority to wait behind at most 1 bulk frame, got %d", worst)
+	}
+}
+
+func TestPriority_Clamp(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Priority uint32
+		Expect   uint32
+	}
+
+	testCases := []testCase{
+		{Name: "zero", Priority: 0, Expect: DefaultPriority},
+		{Name: "one", Priority: 1, Expect: 1},
+		{Name: "max", Priority: MaxPriority, Expect: MaxPriority},
+		{Name: "huge", Priority: math.MaxUint32, Expect: MaxPriority},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			call := newCall(context.Background(), ClientRole, nil, 1, "Test", nil, nil, 0, []Option{WithPriority(tc.Priority)})
+			defer call.cancel()
+			if priority := call.Priority(); priority != tc.Expect {
+				t.Errorf("expected %d, got %d", tc.Expect, priority)
+			}
+		})
+	}
+}
+
+func TestPriority_Begin(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Priority uint32
+		Expect   uint32
+	}
+
+	testCases := []testCase{
+		{Name: "default", Priority: 0, Expect: DefaultPriority},
+		{Name: "low", Priority: 1, Expect: 1},
+		{Name: "high", Priority: 64 * DefaultPriority, Expect: 64 * DefaultPriority},
+		{Name: "huge", Priority: math.MaxUint32, Expect: MaxPriority},
+	}
+
+	// The priority travels in BEGIN, so that the server schedules the
+	// frames of its side of the call, such as RESPONSE, with the same
+	// weight as the client does.
+	priorities := make(chan uint32, 1)
+	lb, err := NewLoopback(context.Background(), HandlerFunc(func(call *Call) error {
+		priorities <- call.Priority()
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			call, err := lb.Conn.Begin(context.Background(), "Test", WithPriority(tc.Priority))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status := call.Wait(); status.Code != Status_OK {
+				t.Fatal(status)
+			}
+			if priority := <-priorities; priority != tc.Expect {
+				t.Errorf("expected the server to see priority %d, got %d", tc.Expect, priority)
+			}
+		})
+	}
+}
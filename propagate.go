@@ -0,0 +1,173 @@
+package vsrpc
+
+import (
+	"context"
+	"time"
+)
+
+// PropagationConfig controls what a Call begun by a handler inherits from the
+// Call being handled, which is found with ContextCall in the context passed
+// to Conn.Begin.  The Call being handled is the parent, and the Call begun by
+// the handler is its child.
+type PropagationConfig struct {
+	// Margin is held back from the deadline of the parent, so that the
+	// handler has time to use the results of the child before its own
+	// deadline passes.
+	Margin time.Duration
+
+	// Cancel makes the children of a parent Call be cancelled when the peer
+	// cancels the parent, when the parent ends, or when the deadline of the
+	// parent passes.
+	Cancel bool
+}
+
+// WithPropagation sets the PropagationConfig of each Call.  Without it, a
+// child Call still shares the deadline and the context of its parent, but
+// with no margin, and it is not cancelled with its parent.
+func WithPropagation(config *PropagationConfig) Option {
+	if config == nil {
+		return (*withPropagation)(nil)
+	}
+	return &withPropagation{config: *config}
+}
+
+type withPropagation struct {
+	config PropagationConfig
+}
+
+func (opt *withPropagation) applyToClient(c *Client) {}
+
+func (opt *withPropagation) applyToServer(s *Server) {}
+
+func (opt *withPropagation) applyToConn(conn *Conn) {}
+
+func (opt *withPropagation) applyToCall(call *Call) {
+	if opt == nil || call == nil {
+		return
+	}
+	call.propagation = &opt.config
+}
+
+var _ Option = (*withPropagation)(nil)
+
+// OutgoingContext returns a context for calls made while handling the Call in
+// ctx, which ends margin before the deadline of that Call.  If ctx holds no
+// Call, or the Call has no deadline, the context only adds a CancelFunc.
+func OutgoingContext(ctx context.Context, margin time.Duration) (context.Context, context.CancelFunc) {
+	call := ContextCall(ctx)
+	if deadline, ok := call.Deadline(); ok {
+		return WithContextDeadline(ctx, call.Clock(), deadline.Add(-margin))
+	}
+	return context.WithCancel(ctx)
+}
+
+// Budget describes how much of the time before its deadline a Call used.
+type Budget struct {
+	// Total is the time from the start of the Call to its deadline.
+	Total time.Duration
+
+	// Used is the time from the start of the Call to its end.
+	Used time.Duration
+
+	// Margin is the time held back from the deadline of the parent of the
+	// Call, if any.
+	Margin time.Duration
+}
+
+// Remaining returns the time that was left before the deadline when the Call
+// ended.  It is negative if the Call overran its deadline.
+func (b Budget) Remaining() time.Duration {
+	return b.Total - b.Used
+}
+
+// Parent returns the Call that was being handled when this Call was begun, or
+// nil if there is none.
+func (call *Call) Parent() *Call {
+	if call == nil {
+		return nil
+	}
+	return call.parent
+}
+
+// Children returns the Calls begun while handling this Call that will be
+// cancelled along with it, and have not yet ended.
+func (call *Call) Children() []*Call {
+	if call == nil {
+		return nil
+	}
+
+	call.mu.Lock()
+	defer call.mu.Unlock()
+
+	children := make([]*Call, 0, len(call.children))
+	for child := range call.children {
+		children = append(children, child)
+	}
+	return children
+}
+
+// lockedBudget returns the Budget of a Call that is ending now.
+func (call *Call) lockedBudget() Budget {
+	var margin time.Duration
+	if call.parent != nil && call.propagation != nil {
+		margin = call.propagation.Margin
+	}
+	return Budget{
+		Total:  call.deadline.Sub(call.start),
+		Used:   call.Clock().Now().Sub(call.start),
+		Margin: margin,
+	}
+}
+
+// addChild records a child Call to be cancelled with its parent, which
+// happens when the peer cancels the parent, when the parent ends, or when the
+// context of the parent is done, e.g. because its deadline has passed.  It
+// returns false if the parent is past that point already.
+func (call *Call) addChild(child *Call) bool {
+	call.mu.Lock()
+	defer call.mu.Unlock()
+
+	if call.state >= stateGoingAway || call.ctxInner.Err() != nil {
+		return false
+	}
+	if call.children == nil {
+		call.children = make(map[*Call]void, 4)
+	}
+	call.children[child] = void{}
+	if call.stopChildren == nil {
+		call.stopChildren = context.AfterFunc(call.ctxInner, call.cancelChildren)
+	}
+	return true
+}
+
+func (call *Call) forgetChild(child *Call) {
+	call.mu.Lock()
+	defer call.mu.Unlock()
+	delete(call.children, child)
+}
+
+func (call *Call) cancelChildren() {
+	call.mu.Lock()
+	defer call.mu.Unlock()
+	call.lockedCancelChildren()
+}
+
+func (call *Call) lockedCancelChildren() {
+	for child := range call.children {
+		go child.Cancel()
+	}
+	call.children = nil
+	if call.stopChildren != nil {
+		call.stopChildren()
+		call.stopChildren = nil
+	}
+}
+
+// lockedWatchChildren moves the watch for the context of the Call being done
+// to a new context, after ApplyPolicy has shortened the deadline.
+func (call *Call) lockedWatchChildren() {
+	if call.stopChildren != nil {
+		call.stopChildren()
+		call.stopChildren = context.AfterFunc(call.ctxInner, call.cancelChildren)
+	}
+}
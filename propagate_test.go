@@ -0,0 +1,171 @@
+package vsrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestOutgoingContext(t *testing.T) {
+	call := newCall(context.Background(), ServerRole, nil, 1, "Test", nil, durationpb.New(time.Minute), 0, nil)
+	defer call.cancel()
+
+	ctx, cancel := OutgoingContext(call.Context(), 10*time.Second)
+	defer cancel()
+	parent, _ := call.Deadline()
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(parent.Add(-10*time.Second)) {
+		t.Errorf("expected %v, got %v ok=%v", parent.Add(-10*time.Second), deadline, ok)
+	}
+
+	ctx, cancel = OutgoingContext(context.Background(), 10*time.Second)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); ok {
+		t.Errorf("expected no deadline, got %v", deadline)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	backend, err := NewLoopback(context.Background(), HandlerFunc(func(call *Call) error {
+		<-call.Context().Done()
+		return call.Context().Err()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+
+	type result struct {
+		parent *Call
+		child  *Call
+	}
+	results := make(chan result, 1)
+	budgets := make(chan Budget, 1)
+	propagate := WithPropagation(&PropagationConfig{Margin: 10 * time.Second, Cancel: true})
+	observe := WithObserver(&FuncObserver{Budget: func(call *Call, budget Budget) { budgets <- budget }})
+
+	frontend, err := NewLoopback(context.Background(), HandlerFunc(func(call *Call) error {
+		child, err := backend.Conn.Begin(call.Context(), "Slow", propagate, observe)
+		if err != nil {
+			return err
+		}
+		results <- result{parent: call, child: child}
+		return StatusError{Status: child.Wait()}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer frontend.Close()
+
+	call, err := frontend.Conn.Begin(context.Background(), "Fast", WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := <-results
+
+	if r.child.Parent() != r.parent {
+		t.Errorf("expected the parent of the child to be %v, got %v", r.parent, r.child.Parent())
+	}
+	if children := r.parent.Children(); len(children) != 1 || children[0] != r.child {
+		t.Errorf("expected the parent to have the child, got %v", children)
+	}
+	parentDeadline, _ := r.parent.Deadline()
+	if childDeadline, ok := r.child.Deadline(); !ok || !childDeadline.Equal(parentDeadline.Add(-10*time.Second)) {
+		t.Errorf("expected %v, got %v ok=%v", parentDeadline.Add(-10*time.Second), childDeadline, ok)
+	}
+
+	// Cancelling the call to the frontend cancels its call to the backend.
+	if err := call.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	if status := r.child.Wait(); status.Code != Status_CANCELLED {
+		t.Errorf("expected %v, got %v", Status_CANCELLED, status)
+	}
+	if status := call.Wait(); status.Code != Status_CANCELLED {
+		t.Errorf("expected %v, got %v", Status_CANCELLED, status)
+	}
+
+	budget := <-budgets
+	if budget.Margin != 10*time.Second {
+		t.Errorf("expected a margin of %v, got %v", 10*time.Second, budget.Margin)
+	}
+	if budget.Total > 50*time.Second || budget.Total < 49*time.Second {
+		t.Errorf("expected a total of about %v, got %v", 50*time.Second, budget.Total)
+	}
+	if remaining := budget.Remaining(); remaining <= 0 || remaining > budget.Total {
+		t.Errorf("expected some of the budget to remain, got %v", remaining)
+	}
+}
+
+func TestPropagation_CancelChildren(t *testing.T) {
+	// The backend only ends its calls when they are cancelled.
+	backend, err := NewLoopback(context.Background(), HandlerFunc(func(call *Call) error {
+		<-call.Queue().Ready()
+		return context.Canceled
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+
+	propagate := WithPropagation(&PropagationConfig{Cancel: true})
+
+	type testCase struct {
+		Name   string
+		Policy *MethodPolicy
+	}
+
+	// Without a policy, the parent ends as soon as the child has begun; with
+	// one, the parent waits for the child until its own deadline passes.  The
+	// deadline comes after the child has begun, so the child does not share it.
+	testCases := []testCase{
+		{Name: "end"},
+		{Name: "deadline", Policy: &MethodPolicy{Timeout: durationpb.New(100 * time.Millisecond)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			children := make(chan *Call, 1)
+			frontend, err := NewLoopback(context.Background(), HandlerFunc(func(call *Call) error {
+				child, err := backend.Conn.Begin(call.Context(), "Slow", propagate)
+				if err != nil {
+					return err
+				}
+				children <- child
+				if tc.Policy == nil {
+					return nil
+				}
+				if err := call.ApplyPolicy(tc.Policy); err != nil {
+					return err
+				}
+				return StatusError{Status: child.Wait()}
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer frontend.Close()
+
+			call, err := frontend.Conn.Begin(context.Background(), "Fast")
+			if err != nil {
+				t.Fatal(err)
+			}
+			child := <-children
+			if _, ok := child.Deadline(); ok {
+				t.Fatal("expected the child to have no deadline")
+			}
+
+			statuses := make(chan *Status, 1)
+			go func() { statuses <- child.Wait() }()
+			select {
+			case status := <-statuses:
+				if status.Code != Status_CANCELLED {
+					t.Errorf("expected %v, got %v", Status_CANCELLED, status)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected the child to be cancelled with its parent")
+			}
+			call.Wait()
+		})
+	}
+}
@@ -1,48 +1,185 @@
 package vsrpc
 
 import (
+	"context"
 	"sync"
 
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+const minQueueRing = 4
+
+// QueueOverflow selects what a bounded Queue does with a payload that does
+// not fit.
+type QueueOverflow uint8
+
+const (
+	// QueueBlock makes Push wait until there is room, or until the Queue is
+	// done.  For the Queue of a Call, this stalls the read thread of the
+	// Conn, and with it every Call on the Conn, until the reader catches up.
+	QueueBlock QueueOverflow = iota
+
+	// QueueDrop discards the payload, counts it in QueueStats.Dropped, and
+	// returns a QueueFullError from PushErr.  The Call carries on.
+	QueueDrop
+
+	// QueueClose discards the payload, marks the Queue done, and returns a
+	// QueueFullError from PushErr.  The Call ends with RESOURCE_EXHAUSTED.
+	QueueClose
+)
+
+// QueueConfig bounds the memory held by a Queue.
+type QueueConfig struct {
+	// MaxLen is the most payloads the Queue will hold, or 0 for no limit.
+	MaxLen uint
+
+	// MaxBytes is the most bytes of payload values the Queue will hold, or
+	// 0 for no limit.  A payload larger than MaxBytes is still accepted by
+	// an empty Queue, so that it cannot wedge the Queue forever.
+	MaxBytes uint64
+
+	// Overflow is the policy for payloads that exceed MaxLen or MaxBytes.
+	Overflow QueueOverflow
+
+	// NoClone makes Push store payloads as given instead of cloning them.
+	// The caller must not modify a payload after pushing it.  The Queue of
+	// a Call never clones, since its payloads are freshly unmarshaled.
+	NoClone bool
+}
+
+// WithQueueConfig sets the QueueConfig of the Queue of each Call.
+func WithQueueConfig(config *QueueConfig) Option {
+	if config == nil {
+		return (*withQueueConfig)(nil)
+	}
+	return &withQueueConfig{config: *config}
+}
+
+type withQueueConfig struct {
+	config QueueConfig
+}
+
+func (opt *withQueueConfig) applyToClient(c *Client) {}
+
+func (opt *withQueueConfig) applyToServer(s *Server) {}
+
+func (opt *withQueueConfig) applyToConn(conn *Conn) {}
+
+func (opt *withQueueConfig) applyToCall(call *Call) {
+	if opt == nil || call == nil {
+		return
+	}
+	call.queueConfig = opt.config
+}
+
+var _ Option = (*withQueueConfig)(nil)
+
+// QueueStats is a snapshot of a Queue, for metrics.
+type QueueStats struct {
+	Len     uint
+	Bytes   uint64
+	Dropped uint64
+}
+
+// Queue holds the payloads received by a Call until they are read.
+//
+// Waiting is done on channels rather than on a sync.Cond, so that a blocked
+// receive can be abandoned when a context is cancelled, and so that a reader
+// can wait for the Queue in a select statement (see Ready).
+//
+// The payloads are kept in a ring buffer, which grows as needed up to the
+// MaxLen of the QueueConfig.
 type Queue struct {
-	mu   sync.Mutex
-	list []*anypb.Any
-	cv1  *sync.Cond
-	cv2  *sync.Cond
-	done bool
+	config QueueConfig
+
+	mu      sync.Mutex
+	ring    []*anypb.Any
+	head    uint
+	len     uint
+	bytes   uint64
+	dropped uint64
+	wake    chan struct{}
+	room    chan struct{}
+	done    bool
 }
 
 func NewQueue() *Queue {
-	q := new(Queue)
-	q.cv1 = sync.NewCond(&q.mu)
-	q.cv2 = sync.NewCond(&q.mu)
-	return q
+	return new(Queue)
+}
+
+func NewQueueWithConfig(config QueueConfig) *Queue {
+	return &Queue{config: config}
 }
 
+// Push adds a payload to the Queue, and reports whether it was accepted.  Use
+// PushErr to find out why a payload was refused.
 func (q *Queue) Push(value *anypb.Any) bool {
+	return q.PushErr(value) == nil
+}
+
+// PushErr is like Push, but returns ErrCallClosed if the Queue is done, or a
+// QueueFullError if the payload was refused for lack of room.
+func (q *Queue) PushErr(value *anypb.Any) error {
 	if q == nil {
-		return false
+		return ErrCallClosed
 	}
+	return q.push(value, !q.config.NoClone)
+}
+
+func (q *Queue) push(value *anypb.Any, clone bool) error {
+	if value != nil && clone {
+		value = proto.Clone(value).(*anypb.Any)
+	}
+	size := uint64(len(value.GetValue()))
 
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	if q.done {
-		return false
-	}
+	for {
+		if q.done {
+			return ErrCallClosed
+		}
+		if q.lockedHasRoom(size) {
+			break
+		}
 
-	if value != nil {
-		value = proto.Clone(value).(*anypb.Any)
+		switch q.config.Overflow {
+		case QueueDrop:
+			q.dropped++
+			return QueueFullError{Len: q.len, Bytes: q.bytes, Size: size}
+
+		case QueueClose:
+			q.dropped++
+			err := QueueFullError{Len: q.len, Bytes: q.bytes, Size: size}
+			q.done = true
+			q.lockedWake()
+			return err
+		}
+
+		if q.room == nil {
+			q.room = make(chan struct{})
+		}
+		room := q.room
+		q.mu.Unlock()
+		<-room
+		q.mu.Lock()
 	}
 
-	q.list = append(q.list, value)
-	if q.cv1 != nil {
-		q.cv1.Signal()
+	if q.len >= uint(len(q.ring)) {
+		q.lockedGrow()
 	}
-	return true
+	q.ring[(q.head+q.len)%uint(len(q.ring))] = value
+	q.len++
+	q.bytes += size
+	q.lockedWake()
+	return nil
 }
 
 func (q *Queue) Done() {
@@ -58,41 +195,132 @@ func (q *Queue) Done() {
 	}
 
 	q.done = true
-	if q.cv1 != nil {
-		q.cv1.Broadcast()
+	q.lockedWake()
+	q.lockedMakeRoom()
+}
+
+// Ready returns a channel that is closed once Recv(false) would return an
+// item or report that the Queue is done.
+func (q *Queue) Ready() <-chan struct{} {
+	if q == nil {
+		return closedChan
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.len > 0 || q.done {
+		return closedChan
 	}
-	if q.cv2 != nil {
-		q.cv2.Broadcast()
+	if q.wake == nil {
+		q.wake = make(chan struct{})
 	}
+	return q.wake
 }
 
-func (q *Queue) Recv(blocking bool) (*anypb.Any, bool, bool) {
+// Stats returns the current length and size of the Queue, and the number of
+// payloads that it has refused.
+func (q *Queue) Stats() QueueStats {
 	if q == nil {
-		return nil, false, true
+		return QueueStats{}
 	}
 
 	q.mu.Lock()
 	defer q.mu.Unlock()
+	return QueueStats{Len: q.len, Bytes: q.bytes, Dropped: q.dropped}
+}
 
-	if q.done {
+func (q *Queue) Recv(blocking bool) (*anypb.Any, bool, bool) {
+	if blocking {
+		item, ok, done, _ := q.RecvContext(context.Background())
+		return item, ok, done
+	}
+
+	if q == nil {
 		return nil, false, true
 	}
 
-	if blocking && len(q.list) <= 0 {
-		if q.cv1 == nil {
-			q.cv1 = sync.NewCond(&q.mu)
-		}
-		for len(q.list) <= 0 && !q.done {
-			q.cv1.Wait()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.lockedPop()
+}
+
+// RecvContext is like Recv(true), but gives up and returns ctx.Err() if ctx
+// is cancelled first.  Items already in the Queue take precedence over the
+// cancellation.
+func (q *Queue) RecvContext(ctx context.Context) (*anypb.Any, bool, bool, error) {
+	for {
+		select {
+		case <-q.Ready():
+			item, ok, done := q.Recv(false)
+			if ok || done {
+				return item, ok, done, nil
+			}
+
+		case <-ctx.Done():
+			item, ok, done := q.Recv(false)
+			if ok || done {
+				return item, ok, done, nil
+			}
+			return nil, false, false, ctx.Err()
 		}
 	}
+}
+
+func (q *Queue) lockedHasRoom(size uint64) bool {
+	if q.len <= 0 {
+		return true
+	}
+	if q.config.MaxLen > 0 && q.len >= q.config.MaxLen {
+		return false
+	}
+	if q.config.MaxBytes > 0 && q.bytes+size > q.config.MaxBytes {
+		return false
+	}
+	return true
+}
+
+func (q *Queue) lockedGrow() {
+	n := uint(len(q.ring)) * 2
+	if n < minQueueRing {
+		n = minQueueRing
+	}
+	if limit := q.config.MaxLen; limit > 0 && n > limit {
+		n = limit
+	}
+
+	ring := make([]*anypb.Any, n)
+	for i := uint(0); i < q.len; i++ {
+		ring[i] = q.ring[(q.head+i)%uint(len(q.ring))]
+	}
+	q.ring = ring
+	q.head = 0
+}
 
-	if len(q.list) <= 0 {
+func (q *Queue) lockedPop() (*anypb.Any, bool, bool) {
+	if q.len <= 0 {
 		return nil, false, q.done
 	}
 
-	item := q.list[0]
-	q.list[0] = nil
-	q.list = q.list[1:]
-	return item, true, q.done
+	item := q.ring[q.head]
+	q.ring[q.head] = nil
+	q.head = (q.head + 1) % uint(len(q.ring))
+	q.len--
+	q.bytes -= uint64(len(item.GetValue()))
+	q.lockedMakeRoom()
+	return item, true, q.done && q.len <= 0
+}
+
+func (q *Queue) lockedWake() {
+	if q.wake != nil {
+		close(q.wake)
+		q.wake = nil
+	}
+}
+
+func (q *Queue) lockedMakeRoom() {
+	if q.room != nil {
+		close(q.room)
+		q.room = nil
+	}
 }
@@ -0,0 +1,290 @@
+package vsrpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestQueue_Ready(t *testing.T) {
+	q := NewQueue()
+
+	ready := q.Ready()
+	if isClosed(ready) {
+		t.Fatal("empty Queue is ready")
+	}
+
+	q.Push(&anypb.Any{TypeUrl: "a"})
+	if !isClosed(ready) {
+		t.Fatal("Ready channel not closed by Push")
+	}
+	if !isClosed(q.Ready()) {
+		t.Fatal("non-empty Queue is not ready")
+	}
+
+	if _, ok, done := q.Recv(false); !ok || done {
+		t.Fatalf("Recv: expected ok=true done=false, got ok=%v done=%v", ok, done)
+	}
+
+	ready = q.Ready()
+	if isClosed(ready) {
+		t.Fatal("drained Queue is ready")
+	}
+
+	q.Done()
+	if !isClosed(ready) {
+		t.Fatal("Ready channel not closed by Done")
+	}
+	if _, ok, done := q.Recv(false); ok || !done {
+		t.Fatalf("Recv: expected ok=false done=true, got ok=%v done=%v", ok, done)
+	}
+}
+
+func TestQueue_RecvContext(t *testing.T) {
+	q := NewQueue()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, ok, done, err := q.RecvContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got ok=%v done=%v err=%v", context.DeadlineExceeded, ok, done, err)
+	}
+
+	// Items that are already queued win over a cancelled context.
+	q.Push(&anypb.Any{TypeUrl: "a"})
+	q.Done()
+	item, ok, done, err := q.RecvContext(ctx)
+	if err != nil || !ok || !done || item.GetTypeUrl() != "a" {
+		t.Fatalf("expected item a with ok=true done=true, got %v ok=%v done=%v err=%v", item, ok, done, err)
+	}
+}
+
+func TestQueue_RecvContext_Wake(t *testing.T) {
+	q := NewQueue()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Push(&anypb.Any{TypeUrl: "a"})
+	}()
+
+	item, ok, done, err := q.RecvContext(context.Background())
+	if err != nil || !ok || done || item.GetTypeUrl() != "a" {
+		t.Fatalf("expected item a with ok=true done=false, got %v ok=%v done=%v err=%v", item, ok, done, err)
+	}
+}
+
+func TestQueue_Ring(t *testing.T) {
+	q := NewQueue()
+
+	// Interleave pushes and pops so that the ring wraps around and grows
+	// while it is wrapped.
+	var next, want int
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 7; i++ {
+			if err := q.PushErr(&anypb.Any{TypeUrl: strconv.Itoa(next)}); err != nil {
+				t.Fatal(err)
+			}
+			next++
+		}
+		for i := 0; i < 5; i++ {
+			item, ok, _ := q.Recv(false)
+			if !ok || item.GetTypeUrl() != strconv.Itoa(want) {
+				t.Fatalf("expected item %d, got %v ok=%v", want, item, ok)
+			}
+			want++
+		}
+	}
+	if stats := q.Stats(); stats.Len != uint(next-want) {
+		t.Errorf("expected %d items, got %d", next-want, stats.Len)
+	}
+}
+
+func TestQueue_Overflow(t *testing.T) {
+	type testCase struct {
+		Name   string
+		Config QueueConfig
+		Expect []bool
+		Stats  QueueStats
+		Done   bool
+	}
+
+	testCases := []testCase{
+		{
+			Name:   "unbounded",
+			Expect: []bool{true, true, true},
+			Stats:  QueueStats{Len: 3, Bytes: 12},
+		},
+		{
+			Name:   "drop-len",
+			Config: QueueConfig{MaxLen: 2, Overflow: QueueDrop},
+			Expect: []bool{true, true, false},
+			Stats:  QueueStats{Len: 2, Bytes: 8, Dropped: 1},
+		},
+		{
+			Name:   "drop-bytes",
+			Config: QueueConfig{MaxBytes: 6, Overflow: QueueDrop},
+			Expect: []bool{true, false, false},
+			Stats:  QueueStats{Len: 1, Bytes: 4, Dropped: 2},
+		},
+		{
+			Name:   "close",
+			Config: QueueConfig{MaxLen: 1, Overflow: QueueClose},
+			Expect: []bool{true, false, false},
+			Stats:  QueueStats{Len: 1, Bytes: 4, Dropped: 1},
+			Done:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			q := NewQueueWithConfig(tc.Config)
+			for index, expect := range tc.Expect {
+				err := q.PushErr(&anypb.Any{Value: []byte("abcd")})
+				if expect && err != nil {
+					t.Errorf("Push #%d: unexpected error %v", index, err)
+				}
+				if !expect && !errors.Is(err, ErrResourceExhausted) && err != ErrCallClosed {
+					t.Errorf("Push #%d: expected an error, got %v", index, err)
+				}
+			}
+			if stats := q.Stats(); stats != tc.Stats {
+				t.Errorf("expected %+v, got %+v", tc.Stats, stats)
+			}
+
+			// A Queue closed by overflow still yields what it holds.
+			for range tc.Stats.Len {
+				if _, ok, _ := q.Recv(false); !ok {
+					t.Fatal("Recv: expected an item")
+				}
+			}
+			if _, _, done := q.Recv(false); done != tc.Done {
+				t.Errorf("expected done=%v, got %v", tc.Done, done)
+			}
+		})
+	}
+}
+
+func TestQueue_Block(t *testing.T) {
+	q := NewQueueWithConfig(QueueConfig{MaxLen: 1})
+	if err := q.PushErr(&anypb.Any{TypeUrl: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pushed := make(chan error)
+	go func() {
+		pushed <- q.PushErr(&anypb.Any{TypeUrl: "b"})
+	}()
+
+	select {
+	case err := <-pushed:
+		t.Fatalf("Push did not block, returned %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if item, ok, _ := q.Recv(false); !ok || item.GetTypeUrl() != "a" {
+		t.Fatalf("expected item a, got %v ok=%v", item, ok)
+	}
+	if err := <-pushed; err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		pushed <- q.PushErr(&anypb.Any{TypeUrl: "c"})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Done()
+	if err := <-pushed; err != ErrCallClosed {
+		t.Errorf("expected %v, got %v", ErrCallClosed, err)
+	}
+}
+
+func TestQueue_NoClone(t *testing.T) {
+	in := &anypb.Any{TypeUrl: "a"}
+
+	q := NewQueue()
+	_ = q.Push(in)
+	if out, _, _ := q.Recv(false); out == in {
+		t.Error("expected Push to clone")
+	}
+
+	q = NewQueueWithConfig(QueueConfig{NoClone: true})
+	_ = q.Push(in)
+	if out, _, _ := q.Recv(false); out != in {
+		t.Error("expected Push not to clone")
+	}
+}
+
+func TestWithQueueConfig(t *testing.T) {
+	handler := HandlerFunc(func(call *Call) error {
+		<-call.Context().Done()
+		return call.Context().Err()
+	})
+
+	lb, err := NewLoopback(context.Background(), handler, WithQueueConfig(&QueueConfig{MaxLen: 1, Overflow: QueueClose}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+
+	call, err := lb.Conn.Begin(context.Background(), "Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := call.Send(&anypb.Any{TypeUrl: "a"}); err != nil && err != ErrCallClosed {
+			t.Fatal(err)
+		}
+	}
+	if code := call.Wait().Code; code != Status_RESOURCE_EXHAUSTED {
+		t.Errorf("expected %v, got %v", Status_RESOURCE_EXHAUSTED, code)
+	}
+}
+
+func BenchmarkQueue(b *testing.B) {
+	payload := &anypb.Any{TypeUrl: "type.googleapis.com/vsrpc.Test", Value: make([]byte, 256)}
+
+	for _, config := range []QueueConfig{{}, {NoClone: true}} {
+		name := "clone"
+		if config.NoClone {
+			name = "no-clone"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			q := NewQueueWithConfig(config)
+			for i := 0; i < b.N; i++ {
+				_ = q.Push(payload)
+				_ = q.Push(payload)
+				q.Recv(false)
+				q.Recv(false)
+			}
+		})
+	}
+}
+
+func TestQueue_PushBool(t *testing.T) {
+	q := NewQueueWithConfig(QueueConfig{MaxLen: 1, Overflow: QueueDrop})
+	if !q.Push(&anypb.Any{TypeUrl: "a"}) {
+		t.Error("expected the first Push to be accepted")
+	}
+	if q.Push(&anypb.Any{TypeUrl: "b"}) {
+		t.Error("expected Push to a full Queue to be refused")
+	}
+	q.Done()
+	if q.Push(&anypb.Any{TypeUrl: "c"}) {
+		t.Error("expected Push to a done Queue to be refused")
+	}
+}
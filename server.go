@@ -11,6 +11,7 @@ import (
 type Server struct {
 	options   []Option
 	observers []Observer
+	clock     Clock
 	pl        PacketListener
 	h         Handler
 
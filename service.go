@@ -0,0 +1,68 @@
+package vsrpc
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// ServiceDesc describes a service, as generated by protoc-gen-go-vsrpc.
+type ServiceDesc struct {
+	// Name is the full protobuf name of the service, e.g. "pkg.Service".
+	Name string
+
+	Methods []MethodDesc
+}
+
+// MethodDesc describes one method of a ServiceDesc.
+//
+// The request and response types are recorded by name, because the generated
+// descriptors are built before the message types are initialized; see
+// RequestType and ResponseType.
+type MethodDesc struct {
+	Name            Method
+	ClientStreaming bool
+	ServerStreaming bool
+	Request         protoreflect.FullName
+	Response        protoreflect.FullName
+
+	// Policy is the (vsrpc.policy) option of the method, or nil.
+	Policy *MethodPolicy
+}
+
+// RequestType looks up the request type in the global registry.
+func (md *MethodDesc) RequestType() (protoreflect.MessageType, error) {
+	return protoregistry.GlobalTypes.FindMessageByName(md.Request)
+}
+
+// ResponseType looks up the response type in the global registry.
+func (md *MethodDesc) ResponseType() (protoreflect.MessageType, error) {
+	return protoregistry.GlobalTypes.FindMessageByName(md.Response)
+}
+
+// MethodNames returns the names of the methods of the service, in order.
+func (desc *ServiceDesc) MethodNames() []Method {
+	if desc == nil {
+		return nil
+	}
+
+	out := make([]Method, len(desc.Methods))
+	for index := range desc.Methods {
+		out[index] = desc.Methods[index].Name
+	}
+	return out
+}
+
+// FindMethod returns the MethodDesc for method, or nil if the service has no
+// such method.
+func (desc *ServiceDesc) FindMethod(method Method) *MethodDesc {
+	if desc == nil {
+		return nil
+	}
+
+	for index := range desc.Methods {
+		if md := &desc.Methods[index]; md.Name == method {
+			return md
+		}
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package vsrpc
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 
 	anypb "google.golang.org/protobuf/types/known/anypb"
 )
@@ -58,11 +61,57 @@ func (status *Status) FromError(err error) {
 		return
 	}
 
+	if mappers := errorMappers.Load(); mappers != nil {
+		for _, fn := range *mappers {
+			if mapped := fn(err); !mapped.IsOK() {
+				status.CopyFrom(mapped)
+				return
+			}
+		}
+	}
+
 	status.Code = Status_UNKNOWN
+	switch {
+	case errors.Is(err, context.Canceled):
+		status.Code = Status_CANCELLED
+	case errors.Is(err, context.DeadlineExceeded):
+		status.Code = Status_DEADLINE_EXCEEDED
+	}
 	status.Text = err.Error()
 	status.Details = AppendDetails(nil, err)
 }
 
+// ErrorMapper converts errors that it recognizes into a Status, and returns
+// nil for all others.
+type ErrorMapper func(err error) *Status
+
+var (
+	errorMappersMu sync.Mutex
+	errorMappers   atomic.Pointer[[]ErrorMapper]
+)
+
+// AddErrorMapper installs a hook that Status.FromError consults for errors
+// that do not carry a Status of their own.  Mappers are tried in the order
+// in which they were added; errors that no mapper recognizes become
+// Status_UNKNOWN, or Status_CANCELLED and Status_DEADLINE_EXCEEDED for the
+// context errors.
+func AddErrorMapper(fn ErrorMapper) {
+	if fn == nil {
+		return
+	}
+
+	errorMappersMu.Lock()
+	defer errorMappersMu.Unlock()
+
+	var mappers []ErrorMapper
+	if old := errorMappers.Load(); old != nil {
+		mappers = make([]ErrorMapper, len(*old), len(*old)+1)
+		copy(mappers, *old)
+	}
+	mappers = append(mappers, fn)
+	errorMappers.Store(&mappers)
+}
+
 func StatusFromError(err error) *Status {
 	status := &Status{}
 	status.FromError(err)
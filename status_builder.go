@@ -0,0 +1,81 @@
+package vsrpc
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// StatusBuilder builds a Status one piece at a time:
+//
+//	return vsrpc.NewStatus(vsrpc.Status_UNAVAILABLE).
+//		Textf("shard %d is overloaded", shard).
+//		RetryAfter(2 * time.Second).
+//		Err()
+type StatusBuilder struct {
+	status Status
+}
+
+// NewStatus starts building a Status with the given code.
+func NewStatus(code Status_Code) *StatusBuilder {
+	b := &StatusBuilder{}
+	b.status.Code = code
+	return b
+}
+
+// Errorf is shorthand for NewStatus(code).Textf(format, args...).Err().
+func Errorf(code Status_Code, format string, args ...any) error {
+	return NewStatus(code).Textf(format, args...).Err()
+}
+
+func (b *StatusBuilder) Text(text string) *StatusBuilder {
+	b.status.Text = text
+	return b
+}
+
+func (b *StatusBuilder) Textf(format string, args ...any) *StatusBuilder {
+	b.status.Text = fmt.Sprintf(format, args...)
+	return b
+}
+
+// Details appends each message as a detail.  Messages that cannot be
+// marshaled are left out.
+func (b *StatusBuilder) Details(details ...proto.Message) *StatusBuilder {
+	for _, detail := range details {
+		_ = b.status.AddDetails(detail)
+	}
+	return b
+}
+
+// AnyDetails appends details that are already packed into Any messages.
+func (b *StatusBuilder) AnyDetails(details ...*anypb.Any) *StatusBuilder {
+	b.status.Details = append(b.status.Details, details...)
+	return b
+}
+
+// Retryable marks the Status as safe to retry.
+func (b *StatusBuilder) Retryable() *StatusBuilder {
+	b.status.CanRetry = true
+	return b
+}
+
+// RetryAfter marks the Status as safe to retry and adds a RetryInfo detail
+// with the given delay.
+func (b *StatusBuilder) RetryAfter(delay time.Duration) *StatusBuilder {
+	b.status.CanRetry = true
+	return b.Details(NewRetryInfo(delay))
+}
+
+// Status returns a copy of the Status built so far.
+func (b *StatusBuilder) Status() *Status {
+	out := &Status{}
+	out.CopyFrom(&b.status)
+	return out
+}
+
+// Err returns the Status built so far as an error, or nil if its code is OK.
+func (b *StatusBuilder) Err() error {
+	return b.Status().AsError()
+}
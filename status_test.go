@@ -0,0 +1,86 @@
+package vsrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStatusBuilder(t *testing.T) {
+	err := NewStatus(Status_UNAVAILABLE).
+		Textf("shard %d is overloaded", 7).
+		Details(NewErrorInfo("OVERLOADED", "example.com", nil)).
+		RetryAfter(2 * time.Second).
+		Err()
+
+	status := StatusFromError(err)
+	if status.Code != Status_UNAVAILABLE || status.Text != "shard 7 is overloaded" || !status.CanRetry {
+		t.Errorf("unexpected status %v", status)
+	}
+	if info := status.RetryInfo(); info == nil || info.RetryDelay.AsDuration() != 2*time.Second {
+		t.Errorf("expected a 2s RetryInfo, got %v", info)
+	}
+	if info := status.ErrorInfo(); info == nil || info.Reason != "OVERLOADED" {
+		t.Errorf("expected an ErrorInfo with reason OVERLOADED, got %v", info)
+	}
+
+	if err := NewStatus(Status_OK).Text("fine").Err(); err != nil {
+		t.Errorf("expected nil for OK, got %v", err)
+	}
+}
+
+func TestStatusError_Is(t *testing.T) {
+	type testCase struct {
+		Name   string
+		Err    error
+		Target error
+		Expect bool
+	}
+
+	notFound := Errorf(Status_NOT_FOUND, "no such user %q", "alice")
+
+	testCases := []testCase{
+		{Name: "same-code", Err: notFound, Target: ErrNotFound, Expect: true},
+		{Name: "other-code", Err: notFound, Target: ErrInternal, Expect: false},
+		{Name: "wrapped", Err: fmt.Errorf("lookup: %w", notFound), Target: ErrNotFound, Expect: true},
+		{Name: "sentinel", Err: ErrAborted, Target: ErrAborted, Expect: true},
+		{Name: "not-a-sentinel", Err: notFound, Target: Errorf(Status_NOT_FOUND, "other"), Expect: false},
+		{Name: "no-such-method", Err: NoSuchMethodError{Method: "foo.Bar"}, Target: ErrUnimplemented, Expect: true},
+		{Name: "auth", Err: AuthError{Method: "foo.Bar"}, Target: ErrUnauthenticated, Expect: true},
+		{Name: "auth-denied", Err: AuthError{Method: "foo.Bar", Err: errors.New("nope")}, Target: ErrPermissionDenied, Expect: true},
+		{Name: "plain", Err: errors.New("boom"), Target: ErrUnknown, Expect: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if actual := errors.Is(tc.Err, tc.Target); actual != tc.Expect {
+				t.Errorf("expected %t, got %t", tc.Expect, actual)
+			}
+		})
+	}
+}
+
+func TestStatusFromError_Context(t *testing.T) {
+	type testCase struct {
+		Name   string
+		Err    error
+		Expect Status_Code
+	}
+
+	testCases := []testCase{
+		{Name: "canceled", Err: context.Canceled, Expect: Status_CANCELLED},
+		{Name: "deadline", Err: context.DeadlineExceeded, Expect: Status_DEADLINE_EXCEEDED},
+		{Name: "wrapped", Err: fmt.Errorf("read: %w", context.DeadlineExceeded), Expect: Status_DEADLINE_EXCEEDED},
+		{Name: "other", Err: errors.New("boom"), Expect: Status_UNKNOWN},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if code := StatusFromError(tc.Err).Code; code != tc.Expect {
+				t.Errorf("expected %v, got %v", tc.Expect, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package statusconv
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+const (
+	vsrpcPrefix  = "vsrpc."
+	googlePrefix = "google.rpc."
+)
+
+// The standard vsrpc details have the same field shapes as their namesakes
+// in google/rpc/error_details.proto, so only the type URL needs rewriting.
+var standardDetails = []string{
+	"RetryInfo",
+	"BadRequest",
+	"QuotaFailure",
+	"ResourceInfo",
+	"DebugInfo",
+	"ErrorInfo",
+}
+
+// MarshalGoogleStatus encodes status as a google.rpc.Status message, the
+// format that gRPC sends in its "grpc-status-details-bin" trailer.
+//
+// The two messages share field numbers and types for the code, the text
+// (google.rpc.Status calls it "message") and the details.  The standard
+// vsrpc details are renamed to their google.rpc equivalents.  CanRetry has no
+// equivalent and is dropped; use a RetryInfo detail to carry it.
+func MarshalGoogleStatus(status *vsrpc.Status) ([]byte, error) {
+	if status.IsOK() {
+		return nil, nil
+	}
+
+	out := &vsrpc.Status{
+		Code:    status.Code,
+		Text:    status.Text,
+		Details: renameDetails(status.Details, vsrpcPrefix, googlePrefix),
+	}
+	return proto.Marshal(out)
+}
+
+// UnmarshalGoogleStatus decodes a google.rpc.Status message, as written by
+// MarshalGoogleStatus or by gRPC.  The standard google.rpc details are renamed
+// to their vsrpc equivalents, and CanRetry is set if there is a RetryInfo.
+func UnmarshalGoogleStatus(raw []byte) (*vsrpc.Status, error) {
+	status := &vsrpc.Status{}
+	if err := proto.Unmarshal(raw, status); err != nil {
+		return nil, err
+	}
+	status.Details = renameDetails(status.Details, googlePrefix, vsrpcPrefix)
+	status.CanRetry = status.RetryInfo() != nil
+	return status, nil
+}
+
+func renameDetails(details []*anypb.Any, from string, to string) []*anypb.Any {
+	if len(details) == 0 {
+		return nil
+	}
+
+	out := make([]*anypb.Any, len(details))
+	for index, detail := range details {
+		out[index] = detail
+		url := detail.GetTypeUrl()
+		slash := strings.LastIndexByte(url, '/')
+		name := url[slash+1:]
+		for _, suffix := range standardDetails {
+			if name == from+suffix {
+				out[index] = &anypb.Any{
+					TypeUrl: url[:slash+1] + to + suffix,
+					Value:   detail.GetValue(),
+				}
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package statusconv
+
+import (
+	"net/http"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+// StatusClientClosedRequest is the non-standard HTTP status code that is used
+// for CANCELLED, following nginx.
+const StatusClientClosedRequest = 499
+
+var httpStatusByCode = map[vsrpc.Status_Code]int{
+	vsrpc.Status_OK:                  http.StatusOK,
+	vsrpc.Status_CANCELLED:           StatusClientClosedRequest,
+	vsrpc.Status_UNKNOWN:             http.StatusInternalServerError,
+	vsrpc.Status_INVALID_ARGUMENT:    http.StatusBadRequest,
+	vsrpc.Status_DEADLINE_EXCEEDED:   http.StatusGatewayTimeout,
+	vsrpc.Status_NOT_FOUND:           http.StatusNotFound,
+	vsrpc.Status_ALREADY_EXISTS:      http.StatusConflict,
+	vsrpc.Status_PERMISSION_DENIED:   http.StatusForbidden,
+	vsrpc.Status_RESOURCE_EXHAUSTED:  http.StatusTooManyRequests,
+	vsrpc.Status_FAILED_PRECONDITION: http.StatusBadRequest,
+	vsrpc.Status_ABORTED:             http.StatusConflict,
+	vsrpc.Status_OUT_OF_RANGE:        http.StatusBadRequest,
+	vsrpc.Status_UNIMPLEMENTED:       http.StatusNotImplemented,
+	vsrpc.Status_INTERNAL:            http.StatusInternalServerError,
+	vsrpc.Status_UNAVAILABLE:         http.StatusServiceUnavailable,
+	vsrpc.Status_DATA_LOSS:           http.StatusInternalServerError,
+	vsrpc.Status_UNAUTHENTICATED:     http.StatusUnauthorized,
+}
+
+// The reverse mapping is not a true inverse, since several codes share one
+// HTTP status; each HTTP status maps to its most likely code.
+var codeByHTTPStatus = map[int]vsrpc.Status_Code{
+	http.StatusBadRequest:                   vsrpc.Status_INVALID_ARGUMENT,
+	http.StatusUnauthorized:                 vsrpc.Status_UNAUTHENTICATED,
+	http.StatusForbidden:                    vsrpc.Status_PERMISSION_DENIED,
+	http.StatusNotFound:                     vsrpc.Status_NOT_FOUND,
+	http.StatusMethodNotAllowed:             vsrpc.Status_UNIMPLEMENTED,
+	http.StatusRequestTimeout:               vsrpc.Status_DEADLINE_EXCEEDED,
+	http.StatusConflict:                     vsrpc.Status_ABORTED,
+	http.StatusPreconditionFailed:           vsrpc.Status_FAILED_PRECONDITION,
+	http.StatusRequestEntityTooLarge:        vsrpc.Status_RESOURCE_EXHAUSTED,
+	http.StatusRequestedRangeNotSatisfiable: vsrpc.Status_OUT_OF_RANGE,
+	http.StatusTooManyRequests:              vsrpc.Status_RESOURCE_EXHAUSTED,
+	StatusClientClosedRequest:               vsrpc.Status_CANCELLED,
+	http.StatusInternalServerError:          vsrpc.Status_INTERNAL,
+	http.StatusNotImplemented:               vsrpc.Status_UNIMPLEMENTED,
+	http.StatusBadGateway:                   vsrpc.Status_UNAVAILABLE,
+	http.StatusServiceUnavailable:           vsrpc.Status_UNAVAILABLE,
+	http.StatusGatewayTimeout:               vsrpc.Status_DEADLINE_EXCEEDED,
+}
+
+// HTTPStatus returns the HTTP status code that corresponds to a vsrpc status
+// code.
+func HTTPStatus(code vsrpc.Status_Code) int {
+	if status, found := httpStatusByCode[code]; found {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeFromHTTPStatus returns the vsrpc status code that corresponds to an
+// HTTP status code.  Unlisted 2xx codes map to OK, 4xx codes to
+// FAILED_PRECONDITION, 5xx codes to INTERNAL, and anything else to UNKNOWN.
+func CodeFromHTTPStatus(status int) vsrpc.Status_Code {
+	if code, found := codeByHTTPStatus[status]; found {
+		return code
+	}
+	switch {
+	case status >= 200 && status < 300:
+		return vsrpc.Status_OK
+	case status >= 400 && status < 500:
+		return vsrpc.Status_FAILED_PRECONDITION
+	case status >= 500 && status < 600:
+		return vsrpc.Status_INTERNAL
+	default:
+		return vsrpc.Status_UNKNOWN
+	}
+}
+
+// FromHTTPStatus returns a Status for an HTTP response status, using the
+// standard status text as the text of the Status.
+func FromHTTPStatus(status int) *vsrpc.Status {
+	code := CodeFromHTTPStatus(status)
+	if code == vsrpc.Status_OK {
+		return &vsrpc.Status{}
+	}
+	return &vsrpc.Status{Code: code, Text: http.StatusText(status)}
+}
@@ -0,0 +1,140 @@
+package statusconv
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+var codeByErrno = map[syscall.Errno]vsrpc.Status_Code{
+	syscall.ENOENT:       vsrpc.Status_NOT_FOUND,
+	syscall.EEXIST:       vsrpc.Status_ALREADY_EXISTS,
+	syscall.EPERM:        vsrpc.Status_PERMISSION_DENIED,
+	syscall.EACCES:       vsrpc.Status_PERMISSION_DENIED,
+	syscall.EROFS:        vsrpc.Status_PERMISSION_DENIED,
+	syscall.EINVAL:       vsrpc.Status_INVALID_ARGUMENT,
+	syscall.ENAMETOOLONG: vsrpc.Status_INVALID_ARGUMENT,
+	syscall.ETIMEDOUT:    vsrpc.Status_DEADLINE_EXCEEDED,
+	syscall.EINTR:        vsrpc.Status_CANCELLED,
+	syscall.ECANCELED:    vsrpc.Status_CANCELLED,
+	syscall.ENOSPC:       vsrpc.Status_RESOURCE_EXHAUSTED,
+	syscall.ENOMEM:       vsrpc.Status_RESOURCE_EXHAUSTED,
+	syscall.EMFILE:       vsrpc.Status_RESOURCE_EXHAUSTED,
+	syscall.ENFILE:       vsrpc.Status_RESOURCE_EXHAUSTED,
+	syscall.EDQUOT:       vsrpc.Status_RESOURCE_EXHAUSTED,
+	syscall.ENOTDIR:      vsrpc.Status_FAILED_PRECONDITION,
+	syscall.EISDIR:       vsrpc.Status_FAILED_PRECONDITION,
+	syscall.ENOTEMPTY:    vsrpc.Status_FAILED_PRECONDITION,
+	syscall.EBUSY:        vsrpc.Status_FAILED_PRECONDITION,
+	syscall.ERANGE:       vsrpc.Status_OUT_OF_RANGE,
+	syscall.EFBIG:        vsrpc.Status_OUT_OF_RANGE,
+	syscall.ENOSYS:       vsrpc.Status_UNIMPLEMENTED,
+	syscall.ENOTSUP:      vsrpc.Status_UNIMPLEMENTED,
+	syscall.EAGAIN:       vsrpc.Status_UNAVAILABLE,
+	syscall.ECONNREFUSED: vsrpc.Status_UNAVAILABLE,
+	syscall.ECONNRESET:   vsrpc.Status_UNAVAILABLE,
+	syscall.ECONNABORTED: vsrpc.Status_UNAVAILABLE,
+	syscall.EHOSTUNREACH: vsrpc.Status_UNAVAILABLE,
+	syscall.ENETUNREACH:  vsrpc.Status_UNAVAILABLE,
+	syscall.ENETDOWN:     vsrpc.Status_UNAVAILABLE,
+	syscall.EPIPE:        vsrpc.Status_UNAVAILABLE,
+	syscall.EIO:          vsrpc.Status_DATA_LOSS,
+}
+
+// The sentinel errors of io/fs and os, for errors that carry no errno.
+var codeBySentinel = []struct {
+	err  error
+	code vsrpc.Status_Code
+}{
+	{fs.ErrNotExist, vsrpc.Status_NOT_FOUND},
+	{fs.ErrExist, vsrpc.Status_ALREADY_EXISTS},
+	{fs.ErrPermission, vsrpc.Status_PERMISSION_DENIED},
+	{fs.ErrInvalid, vsrpc.Status_INVALID_ARGUMENT},
+	{os.ErrDeadlineExceeded, vsrpc.Status_DEADLINE_EXCEEDED},
+}
+
+// Importing statusconv teaches Status.FromError about operating system
+// errors; see FromOSError.
+func init() {
+	vsrpc.AddErrorMapper(FromOSError)
+}
+
+// CodeFromOSError returns the status code for an error from the operating
+// system, such as an *os.PathError wrapping syscall.ENOENT, and reports
+// whether it recognized the error.
+func CodeFromOSError(err error) (vsrpc.Status_Code, bool) {
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		if code, found := codeByErrno[errno]; found {
+			return code, true
+		}
+	}
+	for _, row := range codeBySentinel {
+		if errors.Is(err, row.err) {
+			return row.code, true
+		}
+	}
+	return vsrpc.Status_UNKNOWN, false
+}
+
+// FromOSError is a vsrpc.ErrorMapper for errors from the operating system.
+func FromOSError(err error) *vsrpc.Status {
+	code, ok := CodeFromOSError(err)
+	if !ok {
+		return nil
+	}
+	return &vsrpc.Status{
+		Code:    code,
+		Text:    err.Error(),
+		Details: vsrpc.AppendDetails(nil, err),
+	}
+}
+
+// ToOSError returns an error for status that errors.Is matches against the
+// io/fs sentinel for its code, e.g. fs.ErrNotExist for NOT_FOUND, as well as
+// against the vsrpc sentinel, e.g. vsrpc.ErrNotFound.
+func ToOSError(status *vsrpc.Status) error {
+	if status.IsOK() {
+		return nil
+	}
+	return osError{StatusError: vsrpc.StatusError{Status: status}}
+}
+
+type osError struct {
+	vsrpc.StatusError
+}
+
+func (err osError) Is(target error) bool {
+	switch err.Status.GetCode() {
+	case vsrpc.Status_NOT_FOUND:
+		if target == fs.ErrNotExist {
+			return true
+		}
+	case vsrpc.Status_ALREADY_EXISTS:
+		if target == fs.ErrExist {
+			return true
+		}
+	case vsrpc.Status_PERMISSION_DENIED, vsrpc.Status_UNAUTHENTICATED:
+		if target == fs.ErrPermission {
+			return true
+		}
+	case vsrpc.Status_INVALID_ARGUMENT:
+		if target == fs.ErrInvalid {
+			return true
+		}
+	case vsrpc.Status_DEADLINE_EXCEEDED:
+		if target == os.ErrDeadlineExceeded {
+			return true
+		}
+	}
+	return err.StatusError.Is(target)
+}
+
+func (err osError) Unwrap() error {
+	return err.StatusError
+}
+
+var _ error = osError{}
@@ -0,0 +1,150 @@
+package statusconv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	type testCase struct {
+		Code   vsrpc.Status_Code
+		Status int
+		Back   vsrpc.Status_Code
+	}
+
+	testCases := []testCase{
+		{vsrpc.Status_OK, http.StatusOK, vsrpc.Status_OK},
+		{vsrpc.Status_CANCELLED, StatusClientClosedRequest, vsrpc.Status_CANCELLED},
+		{vsrpc.Status_INVALID_ARGUMENT, http.StatusBadRequest, vsrpc.Status_INVALID_ARGUMENT},
+		{vsrpc.Status_DEADLINE_EXCEEDED, http.StatusGatewayTimeout, vsrpc.Status_DEADLINE_EXCEEDED},
+		{vsrpc.Status_NOT_FOUND, http.StatusNotFound, vsrpc.Status_NOT_FOUND},
+		{vsrpc.Status_ALREADY_EXISTS, http.StatusConflict, vsrpc.Status_ABORTED},
+		{vsrpc.Status_PERMISSION_DENIED, http.StatusForbidden, vsrpc.Status_PERMISSION_DENIED},
+		{vsrpc.Status_RESOURCE_EXHAUSTED, http.StatusTooManyRequests, vsrpc.Status_RESOURCE_EXHAUSTED},
+		{vsrpc.Status_UNIMPLEMENTED, http.StatusNotImplemented, vsrpc.Status_UNIMPLEMENTED},
+		{vsrpc.Status_INTERNAL, http.StatusInternalServerError, vsrpc.Status_INTERNAL},
+		{vsrpc.Status_UNAVAILABLE, http.StatusServiceUnavailable, vsrpc.Status_UNAVAILABLE},
+		{vsrpc.Status_UNAUTHENTICATED, http.StatusUnauthorized, vsrpc.Status_UNAUTHENTICATED},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Code.String(), func(t *testing.T) {
+			if actual := HTTPStatus(tc.Code); actual != tc.Status {
+				t.Errorf("HTTPStatus: expected %d, got %d", tc.Status, actual)
+			}
+			if actual := CodeFromHTTPStatus(tc.Status); actual != tc.Back {
+				t.Errorf("CodeFromHTTPStatus: expected %v, got %v", tc.Back, actual)
+			}
+		})
+	}
+
+	if code := CodeFromHTTPStatus(http.StatusTeapot); code != vsrpc.Status_FAILED_PRECONDITION {
+		t.Errorf("expected FAILED_PRECONDITION for 418, got %v", code)
+	}
+	if code := CodeFromHTTPStatus(http.StatusNoContent); code != vsrpc.Status_OK {
+		t.Errorf("expected OK for 204, got %v", code)
+	}
+}
+
+func TestGoogleStatus(t *testing.T) {
+	status := vsrpc.NewStatus(vsrpc.Status_UNAVAILABLE).
+		Text("overloaded").
+		Details(vsrpc.NewErrorInfo("OVERLOADED", "example.com", nil)).
+		RetryAfter(time.Second).
+		Status()
+
+	raw, err := MarshalGoogleStatus(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Decoding without renaming shows what a gRPC peer would see.
+	var wire vsrpc.Status
+	if err := proto.Unmarshal(raw, &wire); err != nil {
+		t.Fatal(err)
+	}
+	if wire.CanRetry {
+		t.Error("expected can_retry to be left out")
+	}
+	for _, detail := range wire.Details {
+		if name := detail.MessageName(); name.Parent() != "google.rpc" {
+			t.Errorf("expected a google.rpc detail, got %s", name)
+		}
+	}
+
+	back, err := UnmarshalGoogleStatus(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Code != vsrpc.Status_UNAVAILABLE || back.Text != "overloaded" || !back.CanRetry {
+		t.Errorf("unexpected status %v", back)
+	}
+	if info := back.RetryInfo(); info == nil || info.RetryDelay.AsDuration() != time.Second {
+		t.Errorf("expected a 1s RetryInfo, got %v", info)
+	}
+	if info := back.ErrorInfo(); info == nil || info.Reason != "OVERLOADED" {
+		t.Errorf("expected an ErrorInfo with reason OVERLOADED, got %v", info)
+	}
+}
+
+func TestFromOSError(t *testing.T) {
+	_, openErr := os.Open(filepath.Join(t.TempDir(), "missing"))
+
+	type testCase struct {
+		Name   string
+		Err    error
+		Expect vsrpc.Status_Code
+	}
+
+	testCases := []testCase{
+		{Name: "open", Err: openErr, Expect: vsrpc.Status_NOT_FOUND},
+		{Name: "eacces", Err: &os.PathError{Op: "open", Path: "/x", Err: syscall.EACCES}, Expect: vsrpc.Status_PERMISSION_DENIED},
+		{Name: "etimedout", Err: fmt.Errorf("dial: %w", syscall.ETIMEDOUT), Expect: vsrpc.Status_DEADLINE_EXCEEDED},
+		{Name: "fs-exist", Err: fs.ErrExist, Expect: vsrpc.Status_ALREADY_EXISTS},
+		{Name: "deadline", Err: os.ErrDeadlineExceeded, Expect: vsrpc.Status_DEADLINE_EXCEEDED},
+		{Name: "other", Err: errors.New("boom"), Expect: vsrpc.Status_UNKNOWN},
+		{Name: "status-wins", Err: vsrpc.Errorf(vsrpc.Status_ABORTED, "%v", syscall.ENOENT), Expect: vsrpc.Status_ABORTED},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			status := vsrpc.StatusFromError(tc.Err)
+			if status.Code != tc.Expect {
+				t.Errorf("expected %v, got %v", tc.Expect, status.Code)
+			}
+			if tc.Name != "status-wins" && status.Text != tc.Err.Error() {
+				t.Errorf("expected text %q, got %q", tc.Err.Error(), status.Text)
+			}
+		})
+	}
+}
+
+func TestToOSError(t *testing.T) {
+	err := ToOSError(&vsrpc.Status{Code: vsrpc.Status_NOT_FOUND, Text: "no such user"})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("expected errors.Is(err, fs.ErrNotExist)")
+	}
+	if !errors.Is(err, vsrpc.ErrNotFound) {
+		t.Error("expected errors.Is(err, vsrpc.ErrNotFound)")
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		t.Error("expected !errors.Is(err, fs.ErrPermission)")
+	}
+	if code := vsrpc.StatusFromError(err).Code; code != vsrpc.Status_NOT_FOUND {
+		t.Errorf("expected NOT_FOUND, got %v", code)
+	}
+	if ToOSError(&vsrpc.Status{}) != nil {
+		t.Error("expected nil for OK")
+	}
+}
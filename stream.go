@@ -2,6 +2,7 @@ package vsrpc
 
 import (
 	"context"
+	"iter"
 
 	"github.com/chronos-tachyon/assert"
 	"google.golang.org/protobuf/proto"
@@ -24,9 +25,19 @@ type SendStream[T proto.Message] interface {
 	CloseSend() error
 }
 
+// RecvStream receives the messages of a call.
+//
+// A blocking Recv gives up when the call's context is cancelled; RecvContext
+// does the same for an arbitrary ctx.  Ready returns a channel for use in a
+// select statement, which is closed once Recv(false, out) has something to
+// report.  All iterates over the remaining messages, allocating a new message
+// for each one.
 type RecvStream[T proto.Message] interface {
 	BaseStream
 	Recv(blocking bool, out T) (ok bool, done bool, err error)
+	RecvContext(ctx context.Context, out T) (ok bool, done bool, err error)
+	Ready() <-chan struct{}
+	All(ctx context.Context) iter.Seq2[T, error]
 }
 
 type BiStream[T proto.Message, U proto.Message] interface {
@@ -35,6 +46,38 @@ type BiStream[T proto.Message, U proto.Message] interface {
 	RecvStream[U]
 }
 
+// ClientStream is the object-style stream returned by the generated OpenX
+// client methods.  Close must be called when the caller is done with it; it
+// cancels the call if it has not already ended, and returns the final status
+// of the call as an error.
+type ClientStream[T proto.Message, U proto.Message] interface {
+	BiStream[T, U]
+	CloseAndRecv(out U) error
+	CloseAndWait() error
+	Close() error
+}
+
+type ClientRecvStream[U proto.Message] interface {
+	RecvStream[U]
+	Close() error
+}
+
+type ClientSendStream[T proto.Message, U proto.Message] interface {
+	SendStream[T]
+	CloseAndRecv(out U) error
+	CloseAndWait() error
+	Close() error
+}
+
+type ClientBiStream[T proto.Message, U proto.Message] interface {
+	BiStream[T, U]
+	Close() error
+}
+
+func NewClientStream[T proto.Message, U proto.Message](call *Call) ClientStream[T, U] {
+	return &implStream[T, U]{call: call}
+}
+
 func NewStream[T proto.Message, U proto.Message](call *Call) BiStream[T, U] {
 	return &implStream[T, U]{call: call}
 }
@@ -102,17 +145,106 @@ func (stream implStream[T, U]) CloseSend() error {
 	return stream.Call().CloseSend()
 }
 
+// CloseAndRecv half-closes the call, receives the single response into out,
+// and waits for the call to end.  If the call ends OK without a response, it
+// returns ErrNoResponse.
+func (stream implStream[T, U]) CloseAndRecv(out U) error {
+	assert.NotNil(&out)
+
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	ok, _, err := stream.Recv(true, out)
+	if err != nil {
+		return err
+	}
+	if err := stream.Call().Wait().AsError(); err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoResponse
+	}
+	return nil
+}
+
+// CloseAndWait half-closes the call and waits for it to end.
+func (stream implStream[T, U]) CloseAndWait() error {
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	return stream.Call().Wait().AsError()
+}
+
 func (stream implStream[T, U]) Recv(blocking bool, out U) (ok bool, done bool, err error) {
+	if blocking {
+		return stream.RecvContext(stream.Context(), out)
+	}
+
 	assert.NotNil(&out)
 	proto.Reset(out)
 
-	var payload *anypb.Any
-	payload, ok, done = stream.Queue().Recv(blocking)
+	payload, ok, done := stream.Queue().Recv(false)
+	return stream.unmarshal(payload, ok, done, out)
+}
+
+func (stream implStream[T, U]) RecvContext(ctx context.Context, out U) (ok bool, done bool, err error) {
+	assert.NotNil(&ctx)
+	assert.NotNil(&out)
+	proto.Reset(out)
+
+	payload, ok, done, err := stream.Queue().RecvContext(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	return stream.unmarshal(payload, ok, done, out)
+}
+
+func (stream implStream[T, U]) Ready() <-chan struct{} {
+	return stream.Queue().Ready()
+}
+
+func (stream implStream[T, U]) All(ctx context.Context) iter.Seq2[U, error] {
+	return RecvAll[U](ctx, stream)
+}
+
+func (stream implStream[T, U]) unmarshal(payload *anypb.Any, ok bool, done bool, out U) (bool, bool, error) {
 	if ok && payload != nil {
-		err = UnmarshalAny(out, payload)
-		if err != nil {
-			ok = false
+		if err := stream.Call().checkSize(payload, false); err != nil {
+			return false, done, err
+		}
+		if err := UnmarshalAny(out, payload); err != nil {
+			return false, done, err
+		}
+	}
+	return ok, done, nil
+}
+
+// RecvAll returns an iterator over the messages remaining on stream.  Each
+// message is newly allocated, so it may be kept after the loop moves on.  If
+// RecvContext fails, the error is yielded with a nil message and the iteration
+// stops.
+func RecvAll[T proto.Message](ctx context.Context, stream RecvStream[T]) iter.Seq2[T, error] {
+	assert.NotNil(&ctx)
+	return func(yield func(T, error) bool) {
+		for {
+			out := newMessage[T]()
+			ok, done, err := stream.RecvContext(ctx, out)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			if ok && !yield(out, nil) {
+				return
+			}
+			if done {
+				return
+			}
 		}
 	}
-	return
+}
+
+func newMessage[T proto.Message]() T {
+	var zero T
+	return zero.ProtoReflect().Type().New().Interface().(T)
 }
@@ -0,0 +1,172 @@
+package vsrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestLoopback(t *testing.T, fn HandlerFunc) *Loopback {
+	t.Helper()
+	lb, err := NewLoopback(context.Background(), fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lb.Close() })
+	return lb
+}
+
+func TestStream_RecvContext(t *testing.T) {
+	errCh := make(chan error, 1)
+	lb := newTestLoopback(t, func(call *Call) error {
+		ctx, cancel := context.WithTimeout(call.Context(), 20*time.Millisecond)
+		defer cancel()
+
+		var req SumRequest
+		stream := NewStream[*SumResponse, *SumRequest](call)
+		_, _, err := stream.RecvContext(ctx, &req)
+		errCh <- err
+		return nil
+	})
+
+	call, err := lb.Conn.Begin(context.Background(), FooServer_Sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = call.Close() }()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RecvContext did not honor its deadline")
+	}
+}
+
+func TestStream_All(t *testing.T) {
+	lb := newTestLoopback(t, func(call *Call) error {
+		stream := NewStream[*SumResponse, *SumRequest](call)
+		for i := int32(1); i <= 3; i++ {
+			if err := stream.Send(&SumResponse{Output: i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	call, err := lb.Conn.Begin(ctx, FooServer_Sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = call.Close() }()
+
+	stream := NewStream[*SumRequest, *SumResponse](call)
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+
+	var kept []*SumResponse
+	for resp, err := range stream.All(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		kept = append(kept, resp)
+	}
+
+	if len(kept) != 3 {
+		t.Fatalf("expected 3 responses, got %d", len(kept))
+	}
+	for i, resp := range kept {
+		if expect := int32(i + 1); resp.Output != expect {
+			t.Errorf("response %d: expected %d, got %d", i, expect, resp.Output)
+		}
+	}
+	if err := call.Wait().AsError(); err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+}
+
+func TestStream_Ready(t *testing.T) {
+	release := make(chan struct{})
+	lb := newTestLoopback(t, func(call *Call) error {
+		<-release
+		return NewStream[*SumResponse, *SumRequest](call).Send(&SumResponse{Output: 42})
+	})
+
+	ctx := context.Background()
+	call, err := lb.Conn.Begin(ctx, FooServer_Sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = call.Close() }()
+
+	stream := NewStream[*SumRequest, *SumResponse](call)
+	select {
+	case <-stream.Ready():
+		t.Fatal("stream is ready before the server has sent anything")
+	default:
+	}
+
+	close(release)
+	select {
+	case <-stream.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream never became ready")
+	}
+
+	var resp SumResponse
+	ok, _, err := stream.Recv(false, &resp)
+	if err != nil || !ok || resp.Output != 42 {
+		t.Errorf("expected 42, got %d (ok=%v err=%v)", resp.Output, ok, err)
+	}
+}
+
+func TestStream_CloseAndRecv(t *testing.T) {
+	type testCase struct {
+		Name    string
+		Handler HandlerFunc
+		Expect  Status_Code
+	}
+
+	testCases := []testCase{
+		{
+			Name: "response",
+			Handler: func(call *Call) error {
+				return NewStream[*SumResponse, *SumRequest](call).Send(&SumResponse{Output: 3})
+			},
+			Expect: Status_OK,
+		},
+		{
+			Name:    "no-response",
+			Handler: func(call *Call) error { return nil },
+			Expect:  Status_INTERNAL,
+		},
+		{
+			Name:    "error",
+			Handler: func(call *Call) error { return ErrNotFound },
+			Expect:  Status_NOT_FOUND,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			lb := newTestLoopback(t, tc.Handler)
+
+			call, err := lb.Conn.Begin(context.Background(), FooServer_Sum)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = call.Close() }()
+
+			var resp SumResponse
+			err = NewClientStream[*SumRequest, *SumResponse](call).CloseAndRecv(&resp)
+			if code := StatusFromError(err).GetCode(); code != tc.Expect {
+				t.Errorf("expected %v, got %v (%v)", tc.Expect, code, err)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package vsrpc
 import (
 	"errors"
 	"fmt"
+	"runtime/debug"
 	"time"
 
 	"google.golang.org/protobuf/proto"
@@ -41,7 +42,7 @@ func try(fn func() error) (err error) {
 		default:
 			err = fmt.Errorf("%v", panicValue)
 		}
-		err = PanicError{Err: err}
+		err = PanicError{Err: err, Stack: debug.Stack()}
 	}()
 	err = fn()
 	return
@@ -0,0 +1,351 @@
+package vsrpcbench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chronos-tachyon/vsrpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	DefaultConns         = 1
+	DefaultWorkers       = 1
+	DefaultDuration      = 5 * time.Second
+	DefaultStreamLength  = 10
+	DefaultMaxPacketSize = 1 << 20
+)
+
+// Config describes one benchmark run.
+//
+// Workers goroutines share Conns connections round-robin, and each worker
+// issues calls back-to-back, choosing each call's shape from Mix with a
+// pseudo-random number generator seeded from Seed.  The run stops after Calls
+// calls in total if Calls is non-zero, or after Duration otherwise.
+type Config struct {
+	Mix           Mix
+	Conns         uint
+	Workers       uint
+	Calls         uint
+	Duration      time.Duration
+	PayloadSize   uint
+	StreamLength  uint
+	MaxPacketSize uint
+	Seed          int64
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Mix.Total() == 0 {
+		cfg.Mix[UnaryShape] = 1
+	}
+	if cfg.Conns == 0 {
+		cfg.Conns = DefaultConns
+	}
+	if cfg.Workers == 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.Calls == 0 && cfg.Duration <= 0 {
+		cfg.Duration = DefaultDuration
+	}
+	if cfg.StreamLength == 0 {
+		cfg.StreamLength = DefaultStreamLength
+	}
+	if cfg.MaxPacketSize == 0 {
+		cfg.MaxPacketSize = DefaultMaxPacketSize
+	}
+	return cfg
+}
+
+// Env is an in-process server and a pool of client connections to it, talking
+// over unixpacket sockets in the abstract namespace.
+type Env struct {
+	Server *vsrpc.Server
+	Client *vsrpc.Client
+	Conns  []*vsrpc.Conn
+	Wire   *WireStats
+
+	streamLength uint
+}
+
+func NewEnv(ctx context.Context, cfg Config) (*Env, error) {
+	cfg = cfg.withDefaults()
+
+	pd := &vsrpc.UnixDialer{MaxPacketSize: cfg.MaxPacketSize}
+	addr, err := net.ResolveUnixAddr("unixpacket", "")
+	if err != nil {
+		return nil, err
+	}
+
+	pl, err := pd.ListenPacket(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Env{
+		Server:       vsrpc.NewServer(pl, NewHandler(cfg.StreamLength)),
+		Wire:         new(WireStats),
+		streamLength: cfg.StreamLength,
+	}
+	env.Client = vsrpc.NewClient(CountingDialer{Dialer: pd, Stats: env.Wire})
+
+	env.Conns = make([]*vsrpc.Conn, 0, cfg.Conns)
+	for i := uint(0); i < cfg.Conns; i++ {
+		conn, err := env.Client.Dial(ctx, env.Server.Addr())
+		if err != nil {
+			_ = env.Close()
+			return nil, err
+		}
+		env.Conns = append(env.Conns, conn)
+	}
+	return env, nil
+}
+
+func (env *Env) Close() error {
+	if env == nil {
+		return nil
+	}
+	err1 := env.Client.Close()
+	err2 := env.Server.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// Do performs one call of the given shape on the n'th connection.
+func (env *Env) Do(ctx context.Context, n uint, shape Shape, payload *wrapperspb.BytesValue) error {
+	conn := env.Conns[n%uint(len(env.Conns))]
+
+	call, err := conn.Begin(ctx, shape.Method())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = call.Close() }()
+
+	stream := vsrpc.NewBiStream[*wrapperspb.BytesValue, *wrapperspb.BytesValue](call)
+	sendCount := uint(1)
+	if shape == ClientStreamShape || shape == BidiStreamShape {
+		sendCount = env.streamLength
+	}
+
+	var resp wrapperspb.BytesValue
+	for i := uint(0); i < sendCount; i++ {
+		if err := stream.Send(payload); err != nil {
+			return err
+		}
+		if shape == BidiStreamShape {
+			if _, _, err := stream.Recv(true, &resp); err != nil {
+				return err
+			}
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	if err := drain(stream, &resp, func() error { return nil }); err != nil {
+		return err
+	}
+	return call.Wait().AsError()
+}
+
+// Result summarizes a benchmark run.  Allocation counts cover the whole
+// process, so they include the server's share of the work.
+type Result struct {
+	Config     Config
+	Calls      uint64
+	Errors     uint64
+	FirstError error
+	Elapsed    time.Duration
+	Latencies  []time.Duration
+	Mallocs    uint64
+	AllocBytes uint64
+	Wire       WireStats
+}
+
+func (r *Result) QPS() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Calls) / r.Elapsed.Seconds()
+}
+
+// Percentile returns the latency below which the fraction p of calls fell,
+// using the nearest-rank method.
+func (r *Result) Percentile(p float64) time.Duration {
+	n := len(r.Latencies)
+	if n <= 0 {
+		return 0
+	}
+	index := int(p*float64(n)+0.999999) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= n {
+		index = n - 1
+	}
+	return r.Latencies[index]
+}
+
+func (r *Result) perCall(x uint64) float64 {
+	if r.Calls == 0 {
+		return 0
+	}
+	return float64(x) / float64(r.Calls)
+}
+
+func (r *Result) AllocsPerCall() float64 {
+	return r.perCall(r.Mallocs)
+}
+
+func (r *Result) AllocBytesPerCall() float64 {
+	return r.perCall(r.AllocBytes)
+}
+
+func (r *Result) WireBytesPerCall() float64 {
+	return r.perCall(r.Wire.BytesWritten + r.Wire.BytesRead)
+}
+
+const resultHeader = "%-10s %8s %8s %10s %10s %10s %10s %10s %10s %12s %12s\n"
+const resultFormat = "%-10d %8d %8d %10.1f %10s %10s %10s %10s %10.1f %12.1f %12.1f\n"
+
+func WriteHeader(w io.Writer) {
+	fmt.Fprintf(w, resultHeader,
+		"size", "calls", "errors", "qps",
+		"p50", "p90", "p99", "max",
+		"allocs/op", "B/op", "wireB/op")
+}
+
+func (r *Result) WriteRow(w io.Writer) {
+	fmt.Fprintf(w, resultFormat,
+		r.Config.PayloadSize, r.Calls, r.Errors, r.QPS(),
+		r.Percentile(0.50), r.Percentile(0.90), r.Percentile(0.99), r.Percentile(1),
+		r.AllocsPerCall(), r.AllocBytesPerCall(), r.WireBytesPerCall())
+}
+
+// Run starts an Env and drives it as described by cfg.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	cfg = cfg.withDefaults()
+
+	env, err := NewEnv(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = env.Close() }()
+
+	return env.Run(ctx, cfg)
+}
+
+// Run drives an existing Env as described by cfg.  Conns and StreamLength
+// were fixed when the Env was created and are ignored here.
+func (env *Env) Run(ctx context.Context, cfg Config) (*Result, error) {
+	cfg = cfg.withDefaults()
+	payload := wrapperspb.Bytes(make([]byte, cfg.PayloadSize))
+
+	// A duration-bound run stops handing out new calls when time is up, but
+	// lets calls already in flight finish: cancelling them part-way through a
+	// write would tear down the connection they share.
+	var stop int32
+	if cfg.Calls == 0 {
+		t := time.AfterFunc(cfg.Duration, func() { atomic.StoreInt32(&stop, 1) })
+		defer t.Stop()
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		remaining = cfg.Calls
+		result    = &Result{Config: cfg}
+	)
+
+	claim := func() bool {
+		if ctx.Err() != nil || atomic.LoadInt32(&stop) != 0 {
+			return false
+		}
+		if cfg.Calls == 0 {
+			return true
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if remaining == 0 {
+			return false
+		}
+		remaining--
+		return true
+	}
+
+	worker := func(n uint) {
+		defer wg.Done()
+
+		rng := rand.New(rand.NewSource(cfg.Seed + int64(n)))
+		total := int64(cfg.Mix.Total())
+		latencies := make([]time.Duration, 0, 1024)
+		var calls, errs uint64
+		var firstErr error
+
+		for claim() {
+			shape := cfg.Mix.Pick(uint(rng.Int63n(total)))
+			start := time.Now()
+			err := env.Do(ctx, n, shape, payload)
+			elapsed := time.Since(start)
+
+			if err != nil {
+				errs++
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			calls++
+			latencies = append(latencies, elapsed)
+		}
+
+		mu.Lock()
+		result.Calls += calls
+		result.Errors += errs
+		if result.FirstError == nil {
+			result.FirstError = firstErr
+		}
+		result.Latencies = append(result.Latencies, latencies...)
+		mu.Unlock()
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	wireBefore := env.Wire.Snapshot()
+	start := time.Now()
+
+	wg.Add(int(cfg.Workers))
+	for n := uint(0); n < cfg.Workers; n++ {
+		go worker(n)
+	}
+	wg.Wait()
+
+	result.Elapsed = time.Since(start)
+	result.Wire = env.Wire.Snapshot().Sub(wireBefore)
+	runtime.ReadMemStats(&after)
+	result.Mallocs = after.Mallocs - before.Mallocs
+	result.AllocBytes = after.TotalAlloc - before.TotalAlloc
+
+	sort.Slice(result.Latencies, func(i, j int) bool {
+		return result.Latencies[i] < result.Latencies[j]
+	})
+
+	if result.Calls == 0 && result.FirstError != nil {
+		return result, result.FirstError
+	}
+	if result.Calls == 0 {
+		return result, errors.New("vsrpcbench: no calls completed")
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package vsrpcbench
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+var benchSizes = []uint{0, 64, 1024, 16384}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	mix := Mix{1, 1, 1, 1}
+	r, err := Run(ctx, Config{Mix: mix, Conns: 2, Workers: 4, Calls: 200, PayloadSize: 128, StreamLength: 3, MaxPacketSize: 1 << 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Calls != 200 || r.Errors != 0 {
+		t.Errorf("expected 200 calls and 0 errors, got %d calls and %d errors (first: %v)", r.Calls, r.Errors, r.FirstError)
+	}
+	if len(r.Latencies) != 200 {
+		t.Errorf("expected 200 latencies, got %d", len(r.Latencies))
+	}
+	if r.Percentile(0.5) > r.Percentile(0.99) || r.Percentile(0.99) > r.Percentile(1) {
+		t.Errorf("percentiles out of order: p50=%v p99=%v max=%v", r.Percentile(0.5), r.Percentile(0.99), r.Percentile(1))
+	}
+	if r.WireBytesPerCall() < 128 {
+		t.Errorf("expected at least 128 wire bytes per call, got %.1f", r.WireBytesPerCall())
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("unary=8, bidi=2,server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := (Mix{8, 1, 0, 2}); mix != expect {
+		t.Errorf("expected %v, got %v", expect, mix)
+	}
+	if str := mix.String(); str != "unary=8,server=1,bidi=2" {
+		t.Errorf("unexpected String: %q", str)
+	}
+	for _, bad := range []string{"", "unary=0", "sideways", "unary=x"} {
+		if _, err := ParseMix(bad); err == nil {
+			t.Errorf("ParseMix(%q): expected error", bad)
+		}
+	}
+}
+
+func BenchmarkUnary(b *testing.B) {
+	benchShape(b, UnaryShape)
+}
+
+func BenchmarkServerStream(b *testing.B) {
+	benchShape(b, ServerStreamShape)
+}
+
+func BenchmarkClientStream(b *testing.B) {
+	benchShape(b, ClientStreamShape)
+}
+
+func BenchmarkBidiStream(b *testing.B) {
+	benchShape(b, BidiStreamShape)
+}
+
+func benchShape(b *testing.B, shape Shape) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			ctx := context.Background()
+			env, err := NewEnv(ctx, Config{Conns: 4})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer func() { _ = env.Close() }()
+
+			payload := wrapperspb.Bytes(make([]byte, size))
+			wireBefore := env.Wire.Snapshot()
+
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			// Give each parallel goroutine its own connection index.
+			ids := make(chan uint, 1)
+			ids <- 0
+			b.RunParallel(func(pb *testing.PB) {
+				n := <-ids
+				ids <- n + 1
+				for pb.Next() {
+					if err := env.Do(ctx, n, shape, payload); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+
+			b.StopTimer()
+			wire := env.Wire.Snapshot().Sub(wireBefore)
+			b.ReportMetric(float64(wire.BytesWritten+wire.BytesRead)/float64(b.N), "wireB/op")
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package vsrpcbench
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+// WireStats counts the packets and bytes that cross a transport.
+type WireStats struct {
+	PacketsWritten uint64
+	PacketsRead    uint64
+	BytesWritten   uint64
+	BytesRead      uint64
+}
+
+func (stats *WireStats) Snapshot() WireStats {
+	return WireStats{
+		PacketsWritten: atomic.LoadUint64(&stats.PacketsWritten),
+		PacketsRead:    atomic.LoadUint64(&stats.PacketsRead),
+		BytesWritten:   atomic.LoadUint64(&stats.BytesWritten),
+		BytesRead:      atomic.LoadUint64(&stats.BytesRead),
+	}
+}
+
+func (stats WireStats) Sub(prev WireStats) WireStats {
+	return WireStats{
+		PacketsWritten: stats.PacketsWritten - prev.PacketsWritten,
+		PacketsRead:    stats.PacketsRead - prev.PacketsRead,
+		BytesWritten:   stats.BytesWritten - prev.BytesWritten,
+		BytesRead:      stats.BytesRead - prev.BytesRead,
+	}
+}
+
+// CountingDialer wraps a PacketDialer and records wire traffic for every
+// connection it dials into Stats.  Accepted connections are not counted.
+type CountingDialer struct {
+	Dialer vsrpc.PacketDialer
+	Stats  *WireStats
+}
+
+func (pd CountingDialer) DialPacket(ctx context.Context, addr net.Addr) (vsrpc.PacketConn, error) {
+	pc, err := pd.Dialer.DialPacket(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return countingConn{PacketConn: pc, stats: pd.Stats}, nil
+}
+
+func (pd CountingDialer) ListenPacket(ctx context.Context, addr net.Addr) (vsrpc.PacketListener, error) {
+	return pd.Dialer.ListenPacket(ctx, addr)
+}
+
+var _ vsrpc.PacketDialer = CountingDialer{}
+
+type countingConn struct {
+	vsrpc.PacketConn
+	stats *WireStats
+}
+
+func (pc countingConn) ReadPacket(ctx context.Context) ([]byte, func(), error) {
+	packet, dispose, err := pc.PacketConn.ReadPacket(ctx)
+	if err == nil {
+		atomic.AddUint64(&pc.stats.PacketsRead, 1)
+		atomic.AddUint64(&pc.stats.BytesRead, uint64(len(packet)))
+	}
+	return packet, dispose, err
+}
+
+func (pc countingConn) WritePacket(ctx context.Context, packet []byte) error {
+	err := pc.PacketConn.WritePacket(ctx, packet)
+	if err == nil {
+		atomic.AddUint64(&pc.stats.PacketsWritten, 1)
+		atomic.AddUint64(&pc.stats.BytesWritten, uint64(len(packet)))
+	}
+	return err
+}
+
+var _ vsrpc.PacketConn = countingConn{}
@@ -0,0 +1,97 @@
+package vsrpcbench
+
+import (
+	"github.com/chronos-tachyon/vsrpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	UnaryMethod        vsrpc.Method = "vsrpc.bench.Unary"
+	ServerStreamMethod vsrpc.Method = "vsrpc.bench.ServerStream"
+	ClientStreamMethod vsrpc.Method = "vsrpc.bench.ClientStream"
+	BidiStreamMethod   vsrpc.Method = "vsrpc.bench.BidiStream"
+)
+
+var shapeMethods = [NumShapes]vsrpc.Method{
+	UnaryMethod,
+	ServerStreamMethod,
+	ClientStreamMethod,
+	BidiStreamMethod,
+}
+
+func (enum Shape) Method() vsrpc.Method {
+	if enum < Shape(len(shapeMethods)) {
+		return shapeMethods[enum]
+	}
+	return ""
+}
+
+type payloadStream = vsrpc.BiStream[*wrapperspb.BytesValue, *wrapperspb.BytesValue]
+
+// NewHandler returns the server half of the benchmark.  Every method echoes
+// its payload: Unary once, ServerStream streamLength times, ClientStream once
+// after reading every request, and BidiStream once per request.
+func NewHandler(streamLength uint) vsrpc.Handler {
+	if streamLength == 0 {
+		streamLength = 1
+	}
+
+	mux := &vsrpc.HandlerMux{}
+	mux.AddFunc(func(call *vsrpc.Call) error {
+		stream := vsrpc.NewBiStream[*wrapperspb.BytesValue, *wrapperspb.BytesValue](call)
+		var req wrapperspb.BytesValue
+		if _, _, err := stream.Recv(true, &req); err != nil {
+			return err
+		}
+		return stream.Send(&req)
+	}, UnaryMethod)
+	mux.AddFunc(func(call *vsrpc.Call) error {
+		stream := vsrpc.NewBiStream[*wrapperspb.BytesValue, *wrapperspb.BytesValue](call)
+		var req wrapperspb.BytesValue
+		if _, _, err := stream.Recv(true, &req); err != nil {
+			return err
+		}
+		for i := uint(0); i < streamLength; i++ {
+			if err := stream.Send(&req); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, ServerStreamMethod)
+	mux.AddFunc(func(call *vsrpc.Call) error {
+		stream := vsrpc.NewBiStream[*wrapperspb.BytesValue, *wrapperspb.BytesValue](call)
+		var req, last wrapperspb.BytesValue
+		if err := drain(stream, &req, func() error {
+			last.Value = req.Value
+			return nil
+		}); err != nil {
+			return err
+		}
+		return stream.Send(&last)
+	}, ClientStreamMethod)
+	mux.AddFunc(func(call *vsrpc.Call) error {
+		stream := vsrpc.NewBiStream[*wrapperspb.BytesValue, *wrapperspb.BytesValue](call)
+		var req wrapperspb.BytesValue
+		return drain(stream, &req, func() error {
+			return stream.Send(&req)
+		})
+	}, BidiStreamMethod)
+	return mux
+}
+
+func drain(stream payloadStream, out *wrapperspb.BytesValue, fn func() error) error {
+	for {
+		ok, done, err := stream.Recv(true, out)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := fn(); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+}
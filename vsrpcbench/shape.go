@@ -0,0 +1,138 @@
+package vsrpcbench
+
+import (
+	"encoding"
+	"fmt"
+	"strings"
+)
+
+type Shape byte
+
+const (
+	UnaryShape Shape = iota
+	ServerStreamShape
+	ClientStreamShape
+	BidiStreamShape
+)
+
+const NumShapes = 4
+
+var shapeGoNames = [...]string{
+	"vsrpcbench.UnaryShape",
+	"vsrpcbench.ServerStreamShape",
+	"vsrpcbench.ClientStreamShape",
+	"vsrpcbench.BidiStreamShape",
+}
+
+var shapeNames = [...]string{
+	"unary",
+	"server",
+	"client",
+	"bidi",
+}
+
+func ParseShape(str string) (Shape, error) {
+	for index, name := range shapeNames {
+		if strings.EqualFold(str, name) {
+			return Shape(index), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown call shape %q; expected one of %s", str, strings.Join(shapeNames[:], ", "))
+}
+
+func (enum Shape) GoString() string {
+	if enum < Shape(len(shapeGoNames)) {
+		return shapeGoNames[enum]
+	}
+	return fmt.Sprintf("vsrpcbench.Shape(%d)", uint32(enum))
+}
+
+func (enum Shape) String() string {
+	if enum < Shape(len(shapeNames)) {
+		return shapeNames[enum]
+	}
+	return fmt.Sprintf("#%d", uint32(enum))
+}
+
+func (enum Shape) MarshalText() ([]byte, error) {
+	str := enum.String()
+	return []byte(str), nil
+}
+
+func (enum *Shape) UnmarshalText(text []byte) error {
+	shape, err := ParseShape(string(text))
+	if err != nil {
+		return err
+	}
+	*enum = shape
+	return nil
+}
+
+var (
+	_ fmt.GoStringer           = Shape(0)
+	_ fmt.Stringer             = Shape(0)
+	_ encoding.TextMarshaler   = Shape(0)
+	_ encoding.TextUnmarshaler = (*Shape)(nil)
+)
+
+// Mix assigns a relative weight to each call shape.
+type Mix [NumShapes]uint
+
+// ParseMix parses a comma-separated list of "shape=weight" pairs, such as
+// "unary=8,bidi=2".  A bare shape name has weight 1.
+func ParseMix(str string) (Mix, error) {
+	var mix Mix
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, weight := item, uint(1)
+		if i := strings.IndexByte(item, '='); i >= 0 {
+			name = item[:i]
+			if _, err := fmt.Sscanf(item[i+1:], "%d", &weight); err != nil {
+				return Mix{}, fmt.Errorf("invalid weight in %q: %w", item, err)
+			}
+		}
+
+		shape, err := ParseShape(name)
+		if err != nil {
+			return Mix{}, err
+		}
+		mix[shape] += weight
+	}
+	if mix.Total() == 0 {
+		return Mix{}, fmt.Errorf("call mix %q has no calls with non-zero weight", str)
+	}
+	return mix, nil
+}
+
+func (mix Mix) Total() uint {
+	var sum uint
+	for _, weight := range mix {
+		sum += weight
+	}
+	return sum
+}
+
+// Pick maps n, which must be in [0, mix.Total()), to a shape.
+func (mix Mix) Pick(n uint) Shape {
+	for index, weight := range mix {
+		if n < weight {
+			return Shape(index)
+		}
+		n -= weight
+	}
+	return UnaryShape
+}
+
+func (mix Mix) String() string {
+	parts := make([]string, 0, NumShapes)
+	for index, weight := range mix {
+		if weight != 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", Shape(index), weight))
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,346 @@
+package vsrpcgateway
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/chronos-tachyon/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+const emptyType = protoreflect.FullName("google.protobuf.Empty")
+
+// DefaultMaxBodySize is the default limit on the size of a request body, and
+// on each line of a client-streaming body.  It matches the default maximum
+// packet size of the vsrpc transports, since a request that is larger than
+// that could not be sent on the Conn anyway.
+const DefaultMaxBodySize = 1 << 24
+
+// Gateway is an http.Handler that exposes vsrpc services to HTTP clients that
+// speak JSON.  A request
+//
+//	POST /<package.Service>/<Method>
+//
+// is forwarded as a call on the Gateway's Conn.  The body holds the request
+// as protojson; for client-streaming methods, it holds one request per line.
+// Methods whose request type is google.protobuf.Empty ignore the body.
+//
+// A unary response is written as a single JSON object.  Server-streaming
+// responses are written as newline-delimited JSON, one response per line, or
+// as server-sent events if the client accepts "text/event-stream".  A failed
+// call is reported with the HTTP status code that corresponds to its Status
+// (see HTTPStatus) and a JSON error body (see ErrorJSON); if the failure comes
+// after streaming has begun, the error is sent as the last line or event.  A
+// body larger than the limit (see SetMaxBodySize) is rejected with 413 and
+// RESOURCE_EXHAUSTED.
+type Gateway struct {
+	conn *vsrpc.Conn
+
+	mu          sync.Mutex
+	services    map[string]*vsrpc.ServiceDesc
+	maxBodySize int64
+}
+
+// New returns a Gateway that forwards calls on conn to the given services.
+func New(conn *vsrpc.Conn, services ...*vsrpc.ServiceDesc) *Gateway {
+	assert.NotNil(&conn)
+
+	gw := &Gateway{conn: conn}
+	for _, desc := range services {
+		gw.Register(desc)
+	}
+	return gw
+}
+
+// Register adds a service to the Gateway, typically the generated
+// <Service>_ServiceDesc.
+func (gw *Gateway) Register(desc *vsrpc.ServiceDesc) {
+	if desc == nil {
+		return
+	}
+
+	gw.mu.Lock()
+	if gw.services == nil {
+		gw.services = make(map[string]*vsrpc.ServiceDesc, 16)
+	}
+	gw.services[desc.Name] = desc
+	gw.mu.Unlock()
+}
+
+// SetMaxBodySize sets the limit on the size of a request body, which also
+// limits each line of a client-streaming body.  Zero or less means
+// DefaultMaxBodySize.
+func (gw *Gateway) SetMaxBodySize(size int64) {
+	gw.mu.Lock()
+	gw.maxBodySize = size
+	gw.mu.Unlock()
+}
+
+func (gw *Gateway) getMaxBodySize() int64 {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	if gw.maxBodySize <= 0 {
+		return DefaultMaxBodySize
+	}
+	return gw.maxBodySize
+}
+
+// FindMethod maps a URL path of the form "/<package.Service>/<Method>" to the
+// method it names.
+func (gw *Gateway) FindMethod(path string) (*vsrpc.MethodDesc, bool) {
+	path = strings.TrimPrefix(path, "/")
+	i := strings.LastIndexByte(path, '/')
+	if i < 0 {
+		return nil, false
+	}
+	serviceName, methodName := path[:i], path[i+1:]
+
+	gw.mu.Lock()
+	desc := gw.services[serviceName]
+	gw.mu.Unlock()
+
+	md := desc.FindMethod(vsrpc.Method(serviceName + "." + methodName))
+	return md, md != nil
+}
+
+func (gw *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	md, found := gw.FindMethod(r.URL.Path)
+	if !found {
+		writeError(w, &vsrpc.Status{
+			Code: vsrpc.Status_NOT_FOUND,
+			Text: fmt.Sprintf("no method is registered for path %q", r.URL.Path),
+		})
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrorWithCode(w, http.StatusMethodNotAllowed, &vsrpc.Status{
+			Code: vsrpc.Status_UNIMPLEMENTED,
+			Text: fmt.Sprintf("HTTP method %s is not allowed; use POST", r.Method),
+		})
+		return
+	}
+
+	reqType, err := md.RequestType()
+	if err == nil {
+		_, err = md.ResponseType()
+	}
+	if err != nil {
+		writeError(w, &vsrpc.Status{
+			Code: vsrpc.Status_INTERNAL,
+			Text: fmt.Sprintf("method %q: %v", md.Name, err),
+		})
+		return
+	}
+
+	maxBodySize := gw.getMaxBodySize()
+	body := http.MaxBytesReader(w, r.Body, maxBodySize)
+	requests, err := readRequests(body, md, reqType, maxBodySize)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge) || errors.Is(err, bufio.ErrTooLong):
+		writeErrorWithCode(w, http.StatusRequestEntityTooLarge, &vsrpc.Status{
+			Code: vsrpc.Status_RESOURCE_EXHAUSTED,
+			Text: fmt.Sprintf("request body is larger than the limit of %d bytes", maxBodySize),
+		})
+		return
+	case err != nil:
+		writeError(w, &vsrpc.Status{
+			Code: vsrpc.Status_INVALID_ARGUMENT,
+			Text: err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	call, err := gw.conn.Begin(ctx, md.Name, vsrpc.WithMethodPolicy(md.Policy))
+	if err != nil {
+		writeError(w, vsrpc.StatusFromError(err))
+		return
+	}
+	defer func() { _ = call.Close() }()
+
+	if err := sendRequests(call, requests); err != nil {
+		_ = call.Cancel()
+		writeError(w, vsrpc.StatusFromError(err))
+		return
+	}
+
+	if md.ServerStreaming {
+		gw.serveStream(ctx, w, r, call, md)
+		return
+	}
+	gw.serveUnary(ctx, w, call, md)
+}
+
+func (gw *Gateway) serveUnary(ctx context.Context, w http.ResponseWriter, call *vsrpc.Call, md *vsrpc.MethodDesc) {
+	payload, ok, _, err := call.Queue().RecvContext(ctx)
+	if err != nil {
+		writeError(w, vsrpc.StatusFromError(err))
+		return
+	}
+	if status := call.Wait(); !status.IsOK() {
+		writeError(w, status)
+		return
+	}
+	if !ok && md.Response != emptyType {
+		// Methods whose response type is google.protobuf.Empty send no
+		// response, matching the generated server.
+		writeError(w, vsrpc.StatusFromError(vsrpc.ErrNoResponse))
+		return
+	}
+
+	raw, err := responseJSON(payload, md)
+	if err != nil {
+		writeError(w, vsrpc.StatusFromError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(raw)
+	_, _ = w.Write([]byte("\n"))
+}
+
+func (gw *Gateway) serveStream(ctx context.Context, w http.ResponseWriter, r *http.Request, call *vsrpc.Call, md *vsrpc.MethodDesc) {
+	out := newStreamWriter(w, acceptsEventStream(r))
+	for {
+		payload, ok, done, err := call.Queue().RecvContext(ctx)
+		if err != nil {
+			out.WriteError(vsrpc.StatusFromError(err))
+			return
+		}
+
+		if ok {
+			raw, err := responseJSON(payload, md)
+			if err != nil {
+				_ = call.Cancel()
+				out.WriteError(vsrpc.StatusFromError(err))
+				return
+			}
+			out.WriteMessage(raw)
+		}
+
+		if done {
+			break
+		}
+	}
+
+	if status := call.Wait(); !status.IsOK() {
+		out.WriteError(status)
+		return
+	}
+	out.Finish()
+}
+
+// readRequests parses the whole body up front, so that a malformed request
+// is rejected before any call is begun.  No line of a client-streaming body
+// may be longer than maxLineSize.
+func readRequests(body io.Reader, md *vsrpc.MethodDesc, reqType protoreflect.MessageType, maxLineSize int64) ([]proto.Message, error) {
+	if md.Request == emptyType && !md.ClientStreaming {
+		// Nullary methods take no request, matching the generated client.
+		return nil, nil
+	}
+
+	parse := func(raw []byte) (proto.Message, error) {
+		msg := reqType.New().Interface()
+		if len(raw) > 0 {
+			if err := protojson.Unmarshal(raw, msg); err != nil {
+				return nil, fmt.Errorf("failed to parse request as %s: %w", md.Request, err)
+			}
+		}
+		return msg, nil
+	}
+
+	if !md.ClientStreaming {
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := parse(bytes.TrimSpace(raw))
+		if err != nil {
+			return nil, err
+		}
+		return []proto.Message{msg}, nil
+	}
+
+	var list []proto.Message
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, min(maxLineSize, bufio.MaxScanTokenSize)), int(maxLineSize))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) > 0 {
+			msg, err := parse(line)
+			if err != nil {
+				// After a read error, the Scanner still returns
+				// what it has of the last line; report the read
+				// error rather than the truncated JSON.
+				if serr := scanner.Err(); serr != nil {
+					return nil, serr
+				}
+				return nil, err
+			}
+			list = append(list, msg)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func sendRequests(call *vsrpc.Call, requests []proto.Message) error {
+	for _, msg := range requests {
+		payload, err := anypb.New(msg)
+		if err != nil {
+			return err
+		}
+		if err := call.Send(payload); err != nil {
+			return err
+		}
+	}
+	return call.CloseSend()
+}
+
+func responseJSON(payload *anypb.Any, md *vsrpc.MethodDesc) ([]byte, error) {
+	respType, err := md.ResponseType()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := respType.New().Interface()
+	if payload != nil {
+		if err := vsrpc.UnmarshalAny(msg, payload); err != nil {
+			return nil, err
+		}
+	}
+	return protojson.Marshal(msg)
+}
+
+func acceptsEventStream(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept") {
+		for _, item := range strings.Split(value, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(item))
+			if err == nil && mediaType == "text/event-stream" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var _ http.Handler = (*Gateway)(nil)
@@ -0,0 +1,274 @@
+package vsrpcgateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/chronos-tachyon/vsrpc"
+	"github.com/chronos-tachyon/vsrpc/example"
+	"github.com/chronos-tachyon/vsrpc/vsrpctest"
+)
+
+// testServer fails OneInOneOut and OneInManyOut for negative values.
+type testServer struct {
+	vsrpctest.ReferenceServer
+}
+
+func (s testServer) OneInOneOut(ctx context.Context, req *example.ExampleRequest, resp *example.ExampleResponse) error {
+	if req.Value < 0 {
+		detail, _ := anypb.New(wrapperspb.String("negative"))
+		return vsrpc.StatusError{Status: &vsrpc.Status{
+			Code:    vsrpc.Status_NOT_FOUND,
+			Text:    "no such value",
+			Details: []*anypb.Any{detail},
+		}}
+	}
+	return s.ReferenceServer.OneInOneOut(ctx, req, resp)
+}
+
+func (s testServer) OneInManyOut(ctx context.Context, req *example.ExampleRequest, stream vsrpc.SendStream[*example.ExampleResponse]) error {
+	if req.Value < 0 {
+		if err := stream.Send(&example.ExampleResponse{Value: 1}); err != nil {
+			return err
+		}
+		return vsrpc.StatusError{Status: &vsrpc.Status{Code: vsrpc.Status_UNAVAILABLE, Text: "went away"}}
+	}
+	return s.ReferenceServer.OneInManyOut(ctx, req, stream)
+}
+
+const testMaxBodySize = 1 << 10
+
+func newTestGateway(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := &vsrpc.HandlerMux{}
+	example.RegisterExampleApiServer(mux, testServer{})
+
+	lb, err := vsrpc.NewLoopback(context.Background(), mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lb.Close() })
+
+	gw := New(lb.Conn, mux.Services()...)
+	gw.SetMaxBodySize(testMaxBodySize)
+	ts := httptest.NewServer(gw)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestGateway(t *testing.T) {
+	type testCase struct {
+		Name        string
+		Method      string
+		Path        string
+		Accept      string
+		Body        string
+		ExpectCode  int
+		ExpectType  string
+		ExpectBody  string
+		ExpectError string
+	}
+
+	testCases := []testCase{
+		{
+			Name:       "unary",
+			Path:       "/vsrpc.ExampleApi/OneInOneOut",
+			Body:       `{"value": 7}`,
+			ExpectCode: http.StatusOK,
+			ExpectType: "application/json",
+			ExpectBody: `{"value":"7"}` + "\n",
+		},
+		{
+			Name:       "nullary",
+			Path:       "/vsrpc.ExampleApi/ZeroInZeroOut",
+			ExpectCode: http.StatusOK,
+			ExpectType: "application/json",
+			ExpectBody: "{}\n",
+		},
+		{
+			Name:       "client-streaming",
+			Path:       "/vsrpc.ExampleApi/ManyInOneOut",
+			Body:       "{\"value\": 1}\n{\"value\": 2}\n\n{\"value\": 3}",
+			ExpectCode: http.StatusOK,
+			ExpectType: "application/json",
+			ExpectBody: `{"value":"6"}` + "\n",
+		},
+		{
+			Name:       "ndjson",
+			Path:       "/vsrpc.ExampleApi/OneInManyOut",
+			Body:       `{"value": 3}`,
+			ExpectCode: http.StatusOK,
+			ExpectType: "application/x-ndjson",
+			ExpectBody: "{\"value\":\"1\"}\n{\"value\":\"2\"}\n{\"value\":\"3\"}\n",
+		},
+		{
+			Name:       "sse",
+			Path:       "/vsrpc.ExampleApi/OneInManyOut",
+			Accept:     "text/html, text/event-stream",
+			Body:       `{"value": 2}`,
+			ExpectCode: http.StatusOK,
+			ExpectType: "text/event-stream",
+			ExpectBody: "data: {\"value\":\"1\"}\n\ndata: {\"value\":\"2\"}\n\nevent: end\ndata: {}\n\n",
+		},
+		{
+			Name:        "status-error",
+			Path:        "/vsrpc.ExampleApi/OneInOneOut",
+			Body:        `{"value": -1}`,
+			ExpectCode:  http.StatusNotFound,
+			ExpectType:  "application/json",
+			ExpectError: `"code":"NOT_FOUND"`,
+		},
+		{
+			Name:        "status-error-details",
+			Path:        "/vsrpc.ExampleApi/OneInOneOut",
+			Body:        `{"value": -1}`,
+			ExpectCode:  http.StatusNotFound,
+			ExpectType:  "application/json",
+			ExpectError: `"value":"negative"`,
+		},
+		{
+			Name:        "stream-error",
+			Path:        "/vsrpc.ExampleApi/OneInManyOut",
+			Body:        `{"value": -1}`,
+			ExpectCode:  http.StatusOK,
+			ExpectType:  "application/x-ndjson",
+			ExpectError: "{\"value\":\"1\"}\n{\"error\":{\"code\":\"UNAVAILABLE\"",
+		},
+		{
+			Name:        "unknown-method",
+			Path:        "/vsrpc.ExampleApi/Bogus",
+			ExpectCode:  http.StatusNotFound,
+			ExpectType:  "application/json",
+			ExpectError: `"code":"NOT_FOUND"`,
+		},
+		{
+			Name:        "wrong-http-method",
+			Method:      http.MethodGet,
+			Path:        "/vsrpc.ExampleApi/OneInOneOut",
+			ExpectCode:  http.StatusMethodNotAllowed,
+			ExpectType:  "application/json",
+			ExpectError: `"code":"UNIMPLEMENTED"`,
+		},
+		{
+			Name:        "body-too-large",
+			Path:        "/vsrpc.ExampleApi/OneInOneOut",
+			Body:        `{"value": 7` + strings.Repeat(" ", testMaxBodySize) + `}`,
+			ExpectCode:  http.StatusRequestEntityTooLarge,
+			ExpectType:  "application/json",
+			ExpectError: `"code":"RESOURCE_EXHAUSTED"`,
+		},
+		{
+			Name:        "stream-too-large",
+			Path:        "/vsrpc.ExampleApi/ManyInOneOut",
+			Body:        strings.Repeat("{\"value\": 1}\n", testMaxBodySize/8),
+			ExpectCode:  http.StatusRequestEntityTooLarge,
+			ExpectType:  "application/json",
+			ExpectError: `"code":"RESOURCE_EXHAUSTED"`,
+		},
+		{
+			Name:        "bad-json",
+			Path:        "/vsrpc.ExampleApi/OneInOneOut",
+			Body:        `{"value": "seven"}`,
+			ExpectCode:  http.StatusBadRequest,
+			ExpectType:  "application/json",
+			ExpectError: `"code":"INVALID_ARGUMENT"`,
+		},
+	}
+
+	ts := newTestGateway(t)
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			method := tc.Method
+			if method == "" {
+				method = http.MethodPost
+			}
+
+			req, err := http.NewRequest(method, ts.URL+tc.Path, strings.NewReader(tc.Body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.Accept != "" {
+				req.Header.Set("Accept", tc.Accept)
+			}
+
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+
+			raw, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := string(raw)
+
+			if resp.StatusCode != tc.ExpectCode {
+				t.Errorf("expected HTTP %d, got %d: %s", tc.ExpectCode, resp.StatusCode, body)
+			}
+			if actual := resp.Header.Get("Content-Type"); actual != tc.ExpectType {
+				t.Errorf("expected Content-Type %q, got %q", tc.ExpectType, actual)
+			}
+
+			// protojson deliberately varies its spacing between builds.
+			body = strings.ReplaceAll(body, " ", "")
+			if tc.ExpectError != "" {
+				if !strings.Contains(body, tc.ExpectError) {
+					t.Errorf("expected body containing %q, got %q", tc.ExpectError, body)
+				}
+			} else if expect := strings.ReplaceAll(tc.ExpectBody, " ", ""); body != expect {
+				t.Errorf("expected body %q, got %q", expect, body)
+			}
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	for code := range vsrpc.Status_Code_name {
+		if status := HTTPStatus(vsrpc.Status_Code(code)); status < 200 || status > 599 {
+			t.Errorf("%v: unexpected HTTP status %d", vsrpc.Status_Code(code), status)
+		}
+	}
+	if status := HTTPStatus(vsrpc.Status_Code(999)); status != http.StatusInternalServerError {
+		t.Errorf("unknown code: expected %d, got %d", http.StatusInternalServerError, status)
+	}
+}
+
+func TestGateway_NoResponse(t *testing.T) {
+	// The backend ends every call OK without sending a response.
+	mux := &vsrpc.HandlerMux{}
+	example.RegisterExampleApiServer(mux, testServer{})
+	lb, err := vsrpc.NewLoopback(context.Background(), vsrpc.HandlerFunc(func(call *vsrpc.Call) error { return nil }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lb.Close() }()
+
+	ts := httptest.NewServer(New(lb.Conn, mux.Services()...))
+	defer ts.Close()
+
+	resp, err := ts.Client().Post(ts.URL+"/vsrpc.ExampleApi/OneInOneOut", "application/json", strings.NewReader(`{"value": 7}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected HTTP %d, got %d: %s", http.StatusInternalServerError, resp.StatusCode, raw)
+	}
+	if body := strings.ReplaceAll(string(raw), " ", ""); !strings.Contains(body, `"code":"INTERNAL"`) {
+		t.Errorf("expected body containing %q, got %q", `"code":"INTERNAL"`, body)
+	}
+}
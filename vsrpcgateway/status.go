@@ -0,0 +1,69 @@
+package vsrpcgateway
+
+import (
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/chronos-tachyon/vsrpc"
+	"github.com/chronos-tachyon/vsrpc/statusconv"
+)
+
+// StatusClientClosedRequest is the non-standard HTTP status code that is used
+// for CANCELLED, following nginx.
+const StatusClientClosedRequest = statusconv.StatusClientClosedRequest
+
+// HTTPStatus returns the HTTP status code that corresponds to a vsrpc status
+// code; see statusconv.HTTPStatus.
+func HTTPStatus(code vsrpc.Status_Code) int {
+	return statusconv.HTTPStatus(code)
+}
+
+// ErrorJSON renders status as the JSON error body used by the Gateway:
+//
+//	{"error": {"code": "NOT_FOUND", "text": "...", "details": [...]}}
+//
+// Details whose types are not linked into the binary are left out, since
+// protojson cannot render them.
+func ErrorJSON(status *vsrpc.Status) []byte {
+	raw, err := protojson.Marshal(status)
+	if err != nil {
+		var clone vsrpc.Status
+		proto.Merge(&clone, status)
+		clone.Details = knownDetails(clone.Details)
+		raw, err = protojson.Marshal(&clone)
+	}
+	if err != nil {
+		raw = []byte(`{"code":"INTERNAL"}`)
+	}
+
+	out := make([]byte, 0, len(raw)+11)
+	out = append(out, `{"error":`...)
+	out = append(out, raw...)
+	out = append(out, '}')
+	return out
+}
+
+func knownDetails(details []*anypb.Any) []*anypb.Any {
+	out := details[:0]
+	for _, detail := range details {
+		if _, err := protoregistry.GlobalTypes.FindMessageByURL(detail.GetTypeUrl()); err == nil {
+			out = append(out, detail)
+		}
+	}
+	return out
+}
+
+func writeError(w http.ResponseWriter, status *vsrpc.Status) {
+	writeErrorWithCode(w, HTTPStatus(status.GetCode()), status)
+}
+
+func writeErrorWithCode(w http.ResponseWriter, code int, status *vsrpc.Status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(ErrorJSON(status))
+	_, _ = w.Write([]byte("\n"))
+}
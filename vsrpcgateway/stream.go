@@ -0,0 +1,78 @@
+package vsrpcgateway
+
+import (
+	"net/http"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+// streamWriter writes the responses of a server-streaming call, either as
+// newline-delimited JSON or as server-sent events.  The HTTP status line is
+// delayed until the first response, so that a call that fails before
+// producing anything still gets a proper HTTP error.
+type streamWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	sse     bool
+	started bool
+}
+
+func newStreamWriter(w http.ResponseWriter, sse bool) *streamWriter {
+	return &streamWriter{w: w, rc: http.NewResponseController(w), sse: sse}
+}
+
+func (sw *streamWriter) start() {
+	if sw.started {
+		return
+	}
+	sw.started = true
+
+	h := sw.w.Header()
+	if sw.sse {
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+	} else {
+		h.Set("Content-Type", "application/x-ndjson")
+	}
+	sw.w.WriteHeader(http.StatusOK)
+}
+
+func (sw *streamWriter) WriteMessage(raw []byte) {
+	sw.start()
+	if sw.sse {
+		sw.write("data: ", raw, "\n\n")
+	} else {
+		sw.write("", raw, "\n")
+	}
+}
+
+func (sw *streamWriter) WriteError(status *vsrpc.Status) {
+	if !sw.started {
+		sw.started = true
+		writeError(sw.w, status)
+		return
+	}
+	if sw.sse {
+		sw.write("event: error\ndata: ", ErrorJSON(status), "\n\n")
+	} else {
+		sw.write("", ErrorJSON(status), "\n")
+	}
+}
+
+// Finish ends a stream that completed successfully.  Server-sent events get
+// an explicit "end" event, so that an EventSource does not reconnect.
+func (sw *streamWriter) Finish() {
+	sw.start()
+	if sw.sse {
+		sw.write("event: end\ndata: {}", nil, "\n\n")
+	}
+}
+
+func (sw *streamWriter) write(prefix string, raw []byte, suffix string) {
+	buf := make([]byte, 0, len(prefix)+len(raw)+len(suffix))
+	buf = append(buf, prefix...)
+	buf = append(buf, raw...)
+	buf = append(buf, suffix...)
+	_, _ = sw.w.Write(buf)
+	_ = sw.rc.Flush()
+}
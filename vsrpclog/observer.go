@@ -99,6 +99,25 @@ func (o Observer) OnEnd(call *vsrpc.Call, status *vsrpc.Status) {
 		Msg("RPC end")
 }
 
+func (o Observer) OnPanic(call *vsrpc.Call, err vsrpc.PanicError) {
+	o.GetLogger().Error().
+		Uint32("rpcID", uint32(call.ID())).
+		Str("rpcMethod", string(call.Method())).
+		Err(err.Err).
+		Bytes("stack", err.Stack).
+		Msg("RPC panic")
+}
+
+func (o Observer) OnBudget(call *vsrpc.Call, budget vsrpc.Budget) {
+	o.GetLogger().Info().
+		Uint32("rpcID", uint32(call.ID())).
+		Str("rpcMethod", string(call.Method())).
+		Dur("budgetTotal", budget.Total).
+		Dur("budgetUsed", budget.Used).
+		Dur("budgetMargin", budget.Margin).
+		Msg("RPC budget")
+}
+
 func (o Observer) OnShutdown(conn *vsrpc.Conn) {
 	o.GetLogger().Info().
 		Stringer("localAddr", conn.LocalAddr()).
@@ -144,4 +163,8 @@ func (o Observer) OnClose(conn *vsrpc.Conn, err error) {
 		Msg("connection close")
 }
 
-var _ vsrpc.Observer = Observer{}
+var (
+	_ vsrpc.Observer       = Observer{}
+	_ vsrpc.PanicObserver  = Observer{}
+	_ vsrpc.BudgetObserver = Observer{}
+)
@@ -0,0 +1,163 @@
+package vsrpcsim
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+// Epoch is the default starting time of a Clock.
+var Epoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Clock is a virtual vsrpc.Clock.  Time stands still until Advance or
+// AdvanceTo is called, at which point any timers that have come due are fired
+// in deadline order, synchronously, from the goroutine that moved the clock.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers timerHeap
+	seq    uint64
+}
+
+func NewClock(start time.Time) *Clock {
+	if start.IsZero() {
+		start = Epoch
+	}
+	return &Clock{now: start}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lockedInit()
+	return c.now
+}
+
+func (c *Clock) AfterFunc(d time.Duration, fn func()) vsrpc.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lockedInit()
+
+	if d < 0 {
+		d = 0
+	}
+
+	c.seq++
+	t := &timer{clock: c, when: c.now.Add(d), seq: c.seq, fn: fn, index: -1}
+	heap.Push(&c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing timers as they come due.
+func (c *Clock) Advance(d time.Duration) {
+	c.AdvanceTo(c.Now().Add(d))
+}
+
+// AdvanceTo moves the clock forward to t, firing timers as they come due.
+// Timers scheduled by the callbacks themselves are honored if they fall on or
+// before t.
+func (c *Clock) AdvanceTo(t time.Time) {
+	for {
+		c.mu.Lock()
+		c.lockedInit()
+		if len(c.timers) <= 0 || c.timers[0].when.After(t) {
+			if t.After(c.now) {
+				c.now = t
+			}
+			c.mu.Unlock()
+			return
+		}
+		next := heap.Pop(&c.timers).(*timer)
+		if next.when.After(c.now) {
+			c.now = next.when
+		}
+		fn := next.fn
+		c.mu.Unlock()
+
+		fn()
+	}
+}
+
+// Next returns the time at which the earliest pending timer is due.
+func (c *Clock) Next() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.timers) <= 0 {
+		return time.Time{}, false
+	}
+	return c.timers[0].when, true
+}
+
+// Pending returns the number of timers which have not yet fired or been
+// stopped.
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func (c *Clock) lockedInit() {
+	if c.now.IsZero() {
+		c.now = Epoch
+	}
+}
+
+var _ vsrpc.Clock = (*Clock)(nil)
+
+type timer struct {
+	clock *Clock
+	when  time.Time
+	seq   uint64
+	fn    func()
+	index int
+}
+
+func (t *timer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.index < 0 {
+		return false
+	}
+	heap.Remove(&c.timers, t.index)
+	return true
+}
+
+var _ vsrpc.Timer = (*timer)(nil)
+
+type timerHeap []*timer
+
+func (h timerHeap) Len() int {
+	return len(h)
+}
+
+func (h timerHeap) Less(i, j int) bool {
+	if h[i].when.Equal(h[j].when) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].when.Before(h[j].when)
+}
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x any) {
+	t := x.(*timer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *timerHeap) Pop() any {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*h = old[:n-1]
+	return t
+}
@@ -0,0 +1,523 @@
+package vsrpcsim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chronos-tachyon/assert"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+const DefaultMaxPacketSize = (1 << 24)
+
+const DefaultHost = "localhost"
+
+type Addr string
+
+func (addr Addr) Network() string {
+	return "sim"
+}
+
+func (addr Addr) String() string {
+	return string(addr)
+}
+
+func (addr Addr) Host() string {
+	str := string(addr)
+	if i := strings.LastIndexByte(str, ':'); i >= 0 {
+		return str[:i]
+	}
+	return str
+}
+
+var _ net.Addr = Addr("")
+
+// LinkConfig describes the characteristics of the path between two hosts.
+//
+// Each packet is delayed by its serialization time at Bandwidth bytes per
+// second (if non-zero), plus Latency, plus a uniformly distributed random
+// amount up to Jitter.  Packets are never reordered.
+type LinkConfig struct {
+	Latency   time.Duration
+	Jitter    time.Duration
+	Bandwidth uint64
+}
+
+// Network is a simulated network of hosts which implements vsrpc.PacketDialer.
+//
+// All timing is driven by Clock, which is typically a *Clock from this package;
+// if Clock is nil, the system clock is used.  Dialing and listening directly
+// on the Network act on behalf of DefaultHost; use Host to act on behalf of
+// other hosts.
+type Network struct {
+	Clock         vsrpc.Clock
+	Link          LinkConfig
+	MaxPacketSize uint
+	Seed          int64
+
+	mu         sync.Mutex
+	rng        *rand.Rand
+	links      map[hostPair]LinkConfig
+	partitions map[hostPair]struct{}
+	listeners  map[Addr]*Listener
+	port       uint
+}
+
+type hostPair struct {
+	a string
+	b string
+}
+
+func makeHostPair(a string, b string) hostPair {
+	if b < a {
+		a, b = b, a
+	}
+	return hostPair{a, b}
+}
+
+func (n *Network) Host(name string) *Host {
+	return &Host{net: n, name: name}
+}
+
+func (n *Network) DialPacket(ctx context.Context, addr net.Addr) (vsrpc.PacketConn, error) {
+	return n.Host(DefaultHost).DialPacket(ctx, addr)
+}
+
+func (n *Network) ListenPacket(ctx context.Context, addr net.Addr) (vsrpc.PacketListener, error) {
+	return n.Host(DefaultHost).ListenPacket(ctx, addr)
+}
+
+// SetLink overrides the default LinkConfig for traffic between hosts a and b.
+func (n *Network) SetLink(a string, b string, cfg LinkConfig) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.links == nil {
+		n.links = make(map[hostPair]LinkConfig, 16)
+	}
+	n.links[makeHostPair(a, b)] = cfg
+}
+
+// Partition causes all packets between hosts a and b to be silently dropped
+// until Heal is called.  Packets already in flight are also dropped.
+func (n *Network) Partition(a string, b string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.partitions == nil {
+		n.partitions = make(map[hostPair]struct{}, 16)
+	}
+	n.partitions[makeHostPair(a, b)] = struct{}{}
+}
+
+func (n *Network) Heal(a string, b string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.partitions, makeHostPair(a, b))
+}
+
+func (n *Network) clock() vsrpc.Clock {
+	if n.Clock == nil {
+		return vsrpc.SystemClock{}
+	}
+	return n.Clock
+}
+
+func (n *Network) maxPacketSize() uint {
+	if n.MaxPacketSize == 0 {
+		return DefaultMaxPacketSize
+	}
+	return n.MaxPacketSize
+}
+
+func (n *Network) isPartitioned(a string, b string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, found := n.partitions[makeHostPair(a, b)]
+	return found
+}
+
+func (n *Network) delay(a string, b string, size int, busyUntil time.Time, now time.Time) (time.Time, time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	cfg, found := n.links[makeHostPair(a, b)]
+	if !found {
+		cfg = n.Link
+	}
+
+	start := now
+	if busyUntil.After(start) {
+		start = busyUntil
+	}
+	end := start
+	if cfg.Bandwidth > 0 {
+		end = start.Add(time.Duration(uint64(size) * uint64(time.Second) / cfg.Bandwidth))
+	}
+
+	at := end.Add(cfg.Latency)
+	if cfg.Jitter > 0 {
+		if n.rng == nil {
+			n.rng = rand.New(rand.NewSource(n.Seed))
+		}
+		at = at.Add(time.Duration(n.rng.Int63n(int64(cfg.Jitter) + 1)))
+	}
+	return end, at
+}
+
+func (n *Network) nextPort() uint {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.port++
+	return n.port
+}
+
+func (n *Network) listen(host string, addr net.Addr) (*Listener, error) {
+	var a Addr
+	if addr != nil {
+		a = Addr(addr.String())
+	}
+	if a == "" {
+		a = Addr(host + ":" + strconv.FormatUint(uint64(n.nextPort()), 10))
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, found := n.listeners[a]; found {
+		return nil, fmt.Errorf("vsrpcsim: address %q is already in use", a)
+	}
+
+	pl := &Listener{net: n, addr: a}
+	if n.listeners == nil {
+		n.listeners = make(map[Addr]*Listener, 16)
+	}
+	n.listeners[a] = pl
+	return pl, nil
+}
+
+func (n *Network) forgetListener(pl *Listener) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.listeners[pl.addr] == pl {
+		delete(n.listeners, pl.addr)
+	}
+}
+
+func (n *Network) dial(host string, addr net.Addr) (*Conn, error) {
+	remote := Addr(addr.String())
+	local := Addr(host + ":" + strconv.FormatUint(uint64(n.nextPort()), 10))
+
+	n.mu.Lock()
+	pl := n.listeners[remote]
+	n.mu.Unlock()
+
+	if pl == nil {
+		return nil, fmt.Errorf("vsrpcsim: connection refused: %q", remote)
+	}
+	if n.isPartitioned(local.Host(), remote.Host()) {
+		return nil, fmt.Errorf("vsrpcsim: host unreachable: %q", remote)
+	}
+
+	client := &Conn{net: n, local: local, remote: remote}
+	server := &Conn{net: n, local: remote, remote: local}
+	client.out = &link{net: n, src: local.Host(), dst: remote.Host(), to: server}
+	server.out = &link{net: n, src: remote.Host(), dst: local.Host(), to: client}
+
+	if !pl.enqueue(server) {
+		return nil, fmt.Errorf("vsrpcsim: connection refused: %q", remote)
+	}
+	return client, nil
+}
+
+var _ vsrpc.PacketDialer = (*Network)(nil)
+
+// Host is a vsrpc.PacketDialer which dials and listens on behalf of a single
+// named host in a Network.
+type Host struct {
+	net  *Network
+	name string
+}
+
+func (h *Host) Name() string {
+	return h.name
+}
+
+func (h *Host) DialPacket(ctx context.Context, addr net.Addr) (vsrpc.PacketConn, error) {
+	assert.NotNil(&ctx)
+	assert.NotNil(&addr)
+	return h.net.dial(h.name, addr)
+}
+
+func (h *Host) ListenPacket(ctx context.Context, addr net.Addr) (vsrpc.PacketListener, error) {
+	assert.NotNil(&ctx)
+	return h.net.listen(h.name, addr)
+}
+
+var _ vsrpc.PacketDialer = (*Host)(nil)
+
+type Listener struct {
+	net  *Network
+	addr Addr
+
+	mu     sync.Mutex
+	queue  []*Conn
+	wake   chan struct{}
+	closed bool
+}
+
+func (pl *Listener) AcceptPacket(ctx context.Context) (vsrpc.PacketConn, error) {
+	for {
+		pl.mu.Lock()
+		if pl.closed {
+			pl.mu.Unlock()
+			return nil, net.ErrClosed
+		}
+		if len(pl.queue) > 0 {
+			pc := pl.queue[0]
+			pl.queue[0] = nil
+			pl.queue = pl.queue[1:]
+			pl.mu.Unlock()
+			return pc, nil
+		}
+		if pl.wake == nil {
+			pl.wake = make(chan struct{})
+		}
+		wake := pl.wake
+		pl.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+func (pl *Listener) Addr() net.Addr {
+	return pl.addr
+}
+
+func (pl *Listener) Close() error {
+	pl.mu.Lock()
+	if pl.closed {
+		pl.mu.Unlock()
+		return net.ErrClosed
+	}
+	pl.closed = true
+	pending := pl.queue
+	pl.queue = nil
+	pl.lockedWake()
+	pl.mu.Unlock()
+
+	pl.net.forgetListener(pl)
+	for _, pc := range pending {
+		_ = pc.Close()
+	}
+	return nil
+}
+
+func (pl *Listener) enqueue(pc *Conn) bool {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	if pl.closed {
+		return false
+	}
+	pl.queue = append(pl.queue, pc)
+	pl.lockedWake()
+	return true
+}
+
+func (pl *Listener) lockedWake() {
+	if pl.wake != nil {
+		close(pl.wake)
+		pl.wake = nil
+	}
+}
+
+var _ vsrpc.PacketListener = (*Listener)(nil)
+
+type Conn struct {
+	net    *Network
+	local  Addr
+	remote Addr
+	out    *link
+
+	mu         sync.Mutex
+	queue      [][]byte
+	wake       chan struct{}
+	closed     bool
+	peerClosed bool
+}
+
+func (pc *Conn) ReadPacket(ctx context.Context) ([]byte, func(), error) {
+	assert.NotNil(&ctx)
+
+	for {
+		pc.mu.Lock()
+		if pc.closed {
+			pc.mu.Unlock()
+			return nil, nil, vsrpc.ErrConnClosed
+		}
+		if len(pc.queue) > 0 {
+			packet := pc.queue[0]
+			pc.queue[0] = nil
+			pc.queue = pc.queue[1:]
+			pc.mu.Unlock()
+			return packet, func() {}, nil
+		}
+		if pc.peerClosed {
+			pc.mu.Unlock()
+			return nil, nil, io.EOF
+		}
+		if pc.wake == nil {
+			pc.wake = make(chan struct{})
+		}
+		wake := pc.wake
+		pc.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+func (pc *Conn) WritePacket(ctx context.Context, packet []byte) error {
+	assert.NotNil(&ctx)
+
+	if size := uint(len(packet)); size > pc.net.maxPacketSize() {
+		return fmt.Errorf("vsrpcsim: packet of %d bytes exceeds maximum of %d bytes", size, pc.net.maxPacketSize())
+	}
+
+	pc.mu.Lock()
+	closed := pc.closed
+	pc.mu.Unlock()
+	if closed {
+		return vsrpc.ErrConnClosed
+	}
+
+	dupe := make([]byte, len(packet))
+	copy(dupe, packet)
+	pc.out.send(dupe, false)
+	return nil
+}
+
+func (pc *Conn) LocalAddr() net.Addr {
+	return pc.local
+}
+
+func (pc *Conn) RemoteAddr() net.Addr {
+	return pc.remote
+}
+
+func (pc *Conn) Close() error {
+	pc.mu.Lock()
+	if pc.closed {
+		pc.mu.Unlock()
+		return vsrpc.ErrConnClosed
+	}
+	pc.closed = true
+	pc.queue = nil
+	pc.lockedWake()
+	pc.mu.Unlock()
+
+	pc.out.send(nil, true)
+	return nil
+}
+
+func (pc *Conn) deliver(packet []byte, fin bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.closed {
+		return
+	}
+	if fin {
+		pc.peerClosed = true
+	} else {
+		pc.queue = append(pc.queue, packet)
+	}
+	pc.lockedWake()
+}
+
+func (pc *Conn) lockedWake() {
+	if pc.wake != nil {
+		close(pc.wake)
+		pc.wake = nil
+	}
+}
+
+var _ vsrpc.PacketConn = (*Conn)(nil)
+
+type delivery struct {
+	at     time.Time
+	packet []byte
+	fin    bool
+}
+
+type link struct {
+	net *Network
+	src string
+	dst string
+	to  *Conn
+
+	mu        sync.Mutex
+	pending   []delivery
+	busyUntil time.Time
+	lastAt    time.Time
+}
+
+func (l *link) send(packet []byte, fin bool) {
+	clock := l.net.clock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := clock.Now()
+	busyUntil, at := l.net.delay(l.src, l.dst, len(packet), l.busyUntil, now)
+	if at.Before(l.lastAt) {
+		at = l.lastAt
+	}
+	l.busyUntil = busyUntil
+	l.lastAt = at
+
+	l.pending = append(l.pending, delivery{at: at, packet: packet, fin: fin})
+	if !at.After(now) && len(l.pending) == 1 {
+		l.lockedFlush(now)
+		return
+	}
+	clock.AfterFunc(at.Sub(now), l.flush)
+}
+
+func (l *link) flush() {
+	clock := l.net.clock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := clock.Now()
+	l.lockedFlush(now)
+	if len(l.pending) > 0 {
+		clock.AfterFunc(l.pending[0].at.Sub(now), l.flush)
+	}
+}
+
+func (l *link) lockedFlush(now time.Time) {
+	i := 0
+	for i < len(l.pending) && !l.pending[i].at.After(now) {
+		d := l.pending[i]
+		l.pending[i] = delivery{}
+		i++
+		if !l.net.isPartitioned(l.src, l.dst) {
+			l.to.deliver(d.packet, d.fin)
+		}
+	}
+	l.pending = l.pending[i:]
+}
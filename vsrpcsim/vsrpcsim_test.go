@@ -0,0 +1,182 @@
+package vsrpcsim
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chronos-tachyon/vsrpc"
+	"github.com/chronos-tachyon/vsrpc/example"
+	"github.com/chronos-tachyon/vsrpc/vsrpctest"
+)
+
+func TestTransport(t *testing.T) {
+	n := &Network{MaxPacketSize: vsrpctest.DefaultTransportMaxPacketSize}
+	vsrpctest.TestTransport(t, vsrpctest.TransportConfig{Dialer: n, Addr: Addr("")})
+}
+
+func TestRPC(t *testing.T) {
+	n := &Network{}
+	vsrpctest.TestRPC(t, vsrpctest.RPCConfig{Dialer: n, Addr: Addr("")})
+}
+
+func TestClock(t *testing.T) {
+	clock := NewClock(time.Time{})
+	start := clock.Now()
+
+	var fired []int
+	clock.AfterFunc(3*time.Second, func() { fired = append(fired, 3) })
+	clock.AfterFunc(1*time.Second, func() {
+		fired = append(fired, 1)
+		clock.AfterFunc(1*time.Second, func() { fired = append(fired, 2) })
+	})
+	stopped := clock.AfterFunc(2*time.Second, func() { fired = append(fired, -1) })
+	if !stopped.Stop() {
+		t.Error("Stop returned false for a pending timer")
+	}
+
+	clock.Advance(2 * time.Second)
+	if len(fired) != 2 || fired[0] != 1 || fired[1] != 2 {
+		t.Errorf("after 2s: expected [1 2], got %v", fired)
+	}
+
+	clock.Advance(5 * time.Second)
+	if len(fired) != 3 || fired[2] != 3 {
+		t.Errorf("after 7s: expected [1 2 3], got %v", fired)
+	}
+	if elapsed := clock.Now().Sub(start); elapsed != 7*time.Second {
+		t.Errorf("expected 7s elapsed, got %v", elapsed)
+	}
+}
+
+func TestLinkTiming(t *testing.T) {
+	ctx := context.Background()
+	clock := NewClock(time.Time{})
+	n := &Network{
+		Clock: clock,
+		Link:  LinkConfig{Latency: 100 * time.Millisecond, Bandwidth: 1000},
+	}
+
+	pl, err := n.ListenPacket(ctx, Addr(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pl.Close()
+
+	client, err := n.DialPacket(ctx, pl.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	server, err := pl.AcceptPacket(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	start := clock.Now()
+	for i := 0; i < 2; i++ {
+		if err := client.WritePacket(ctx, make([]byte, 1000)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each 1000-byte packet takes 1s to serialize plus 100ms to arrive.
+	expect := []time.Duration{1100 * time.Millisecond, 2100 * time.Millisecond}
+	for i, d := range expect {
+		clock.AdvanceTo(start.Add(d - time.Millisecond))
+		if pollPacket(server) {
+			t.Fatalf("packet %d arrived before %v", i, d)
+		}
+		clock.AdvanceTo(start.Add(d))
+		if !pollPacket(server) {
+			t.Fatalf("packet %d did not arrive at %v", i, d)
+		}
+	}
+
+	n.Partition(DefaultHost, DefaultHost)
+	if err := client.WritePacket(ctx, []byte("lost")); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if pollPacket(server) {
+		t.Error("packet crossed a partition")
+	}
+
+	n.Heal(DefaultHost, DefaultHost)
+	if err := client.WritePacket(ctx, []byte("found")); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if !pollPacket(server) {
+		t.Error("packet did not arrive after Heal")
+	}
+}
+
+func TestVirtualDeadline(t *testing.T) {
+	ctx := context.Background()
+	clock := NewClock(time.Time{})
+	n := &Network{Clock: clock, Link: LinkConfig{Latency: 10 * time.Millisecond}}
+
+	pl, err := n.Host("server").ListenPacket(ctx, Addr(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := vsrpc.NewServer(pl, vsrpctest.NewReferenceHandler(), vsrpc.WithClock(clock))
+	defer s.Close()
+
+	c := vsrpc.NewClient(n.Host("client"), vsrpc.WithClock(clock))
+	defer c.Close()
+
+	conn, err := c.Dial(ctx, pl.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := clock.Now()
+	realStart := time.Now()
+
+	dctx, cancel := vsrpc.WithContextTimeout(ctx, clock, 30*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		api := example.NewExampleApiClient(conn)
+		errCh <- api.OneInZeroOut(dctx, &example.ExampleRequest{Value: 3600000})
+	}()
+
+	var callErr error
+	for looping := true; looping; {
+		select {
+		case callErr = <-errCh:
+			looping = false
+		case <-time.After(time.Millisecond):
+			if next, ok := clock.Next(); ok {
+				clock.AdvanceTo(next)
+			}
+		}
+	}
+
+	if code := vsrpc.StatusFromError(callErr).GetCode(); code != vsrpc.Status_DEADLINE_EXCEEDED {
+		t.Errorf("expected %v, got %v", vsrpc.Status_DEADLINE_EXCEEDED, callErr)
+	}
+	if elapsed := clock.Now().Sub(start); elapsed < 30*time.Second {
+		t.Errorf("call ended after only %v of virtual time", elapsed)
+	}
+	if elapsed := time.Since(realStart); elapsed > 10*time.Second {
+		t.Errorf("call took %v of real time", elapsed)
+	}
+}
+
+func pollPacket(pc vsrpc.PacketConn) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, dispose, err := pc.ReadPacket(ctx)
+	if err != nil {
+		return false
+	}
+	dispose()
+	return true
+}
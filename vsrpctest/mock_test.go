@@ -0,0 +1,189 @@
+package vsrpctest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/chronos-tachyon/vsrpc"
+	"github.com/chronos-tachyon/vsrpc/example"
+)
+
+func TestMockClient_Responses(t *testing.T) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+
+	mock := &example.MockExampleApiClient{
+		OneInOneOutResponses:  []*example.ExampleResponse{{Value: 1}, {Value: 2}},
+		OneInManyOutResponses: []*example.ExampleResponse{{Value: 10}, {Value: 20}, {Value: 30}},
+		ManyInZeroOutErr:      errBoom,
+	}
+
+	var api example.ExampleApiClient = mock
+	var resp example.ExampleResponse
+	for i, expect := range []int64{1, 2, 2} {
+		if err := api.OneInOneOut(ctx, &example.ExampleRequest{Value: int64(i)}, &resp); err != nil {
+			t.Fatalf("OneInOneOut #%d: %v", i, err)
+		}
+		if resp.Value != expect {
+			t.Errorf("OneInOneOut #%d: expected %d, got %d", i, expect, resp.Value)
+		}
+	}
+
+	var values []int64
+	err := api.OneInManyOut(ctx, &example.ExampleRequest{Value: 3}, func(stream vsrpc.RecvStream[*example.ExampleResponse]) error {
+		var err error
+		values, err = recvAll(stream)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("OneInManyOut: %v", err)
+	}
+	if err := expectValues(values, []int64{10, 20, 30}); err != nil {
+		t.Errorf("OneInManyOut: %v", err)
+	}
+
+	err = api.ManyInZeroOut(ctx, func(stream vsrpc.SendStream[*example.ExampleRequest]) error {
+		return sendAll(stream, []int64{4, 5})
+	})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("ManyInZeroOut: expected %v, got %v", errBoom, err)
+	}
+
+	calls := mock.Calls()
+	if len(calls) != 5 {
+		t.Fatalf("expected 5 recorded calls, got %d", len(calls))
+	}
+
+	oneInOneOut := mock.CallsTo("vsrpc.ExampleApi.OneInOneOut")
+	if len(oneInOneOut) != 3 {
+		t.Errorf("expected 3 calls to OneInOneOut, got %d", len(oneInOneOut))
+	}
+
+	last := calls[4]
+	if last.Method != "vsrpc.ExampleApi.ManyInZeroOut" {
+		t.Errorf("expected last call to ManyInZeroOut, got %q", last.Method)
+	}
+	if len(last.Requests) != 2 || !proto.Equal(last.Requests[1], &example.ExampleRequest{Value: 5}) {
+		t.Errorf("ManyInZeroOut: unexpected recorded requests %v", last.Requests)
+	}
+
+	mock.ResetCalls()
+	if n := len(mock.Calls()); n != 0 {
+		t.Errorf("expected no calls after ResetCalls, got %d", n)
+	}
+}
+
+func TestMockClient_Func(t *testing.T) {
+	ctx := context.Background()
+
+	mock := &example.MockExampleApiClient{
+		OneInOneOutFunc: func(ctx context.Context, req *example.ExampleRequest, resp *example.ExampleResponse, options ...vsrpc.Option) error {
+			resp.Value = req.Value * 2
+			return nil
+		},
+	}
+
+	var resp example.ExampleResponse
+	if err := mock.OneInOneOut(ctx, &example.ExampleRequest{Value: 21}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Value != 42 {
+		t.Errorf("expected 42, got %d", resp.Value)
+	}
+
+	calls := mock.Calls()
+	if len(calls) != 1 || len(calls[0].Requests) != 1 || !proto.Equal(calls[0].Requests[0], &example.ExampleRequest{Value: 21}) {
+		t.Errorf("unexpected recorded calls %v", calls)
+	}
+}
+
+func TestMockClient_Open(t *testing.T) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+
+	mock := &example.MockExampleApiClient{
+		ManyInOneOutResponses:  []*example.ExampleResponse{{Value: 55}},
+		ZeroInManyOutResponses: []*example.ExampleResponse{{Value: 1}, {Value: 2}},
+		ZeroInManyOutErr:       errBoom,
+	}
+
+	send, err := mock.OpenManyInOneOut(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sendAll(send, []int64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	var resp example.ExampleResponse
+	if err := send.CloseAndRecv(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Value != 55 {
+		t.Errorf("OpenManyInOneOut: expected 55, got %d", resp.Value)
+	}
+	if err := send.Send(&example.ExampleRequest{Value: 3}); !errors.Is(err, vsrpc.ErrHalfClosed) {
+		t.Errorf("Send after CloseAndRecv: expected %v, got %v", vsrpc.ErrHalfClosed, err)
+	}
+
+	recv, err := mock.OpenZeroInManyOut(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := recvAll(recv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := expectValues(values, []int64{1, 2}); err != nil {
+		t.Errorf("OpenZeroInManyOut: %v", err)
+	}
+	if err := recv.Close(); !errors.Is(err, errBoom) {
+		t.Errorf("OpenZeroInManyOut: expected %v from Close, got %v", errBoom, err)
+	}
+
+	calls := mock.CallsTo("vsrpc.ExampleApi.ManyInOneOut")
+	if len(calls) != 1 || len(calls[0].Requests) != 2 {
+		t.Errorf("OpenManyInOneOut: unexpected recorded calls %v", calls)
+	}
+}
+
+func TestLoopback(t *testing.T) {
+	ctx, cancel := ContextFromTest(t)
+	defer cancel()
+
+	api, lb, err := example.NewExampleApiLoopback(ctx, ReferenceServer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lb.Close() }()
+
+	var resp example.ExampleResponse
+	if err := api.OneInOneOut(ctx, &example.ExampleRequest{Value: 7}, &resp); err != nil {
+		t.Fatalf("OneInOneOut: %v", err)
+	}
+	if resp.Value != 7 {
+		t.Errorf("OneInOneOut: expected 7, got %d", resp.Value)
+	}
+
+	var values []int64
+	err = api.ManyInManyOut(ctx, func(stream vsrpc.BiStream[*example.ExampleRequest, *example.ExampleResponse]) error {
+		for _, value := range []int64{1, 2, 3} {
+			if err := stream.Send(&example.ExampleRequest{Value: value}); err != nil {
+				return err
+			}
+			if _, _, err := stream.Recv(true, &resp); err != nil {
+				return err
+			}
+			values = append(values, resp.Value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ManyInManyOut: %v", err)
+	}
+	if err := expectValues(values, []int64{1, 2, 3}); err != nil {
+		t.Errorf("ManyInManyOut: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package vsrpctest
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronos-tachyon/vsrpc"
+	"github.com/chronos-tachyon/vsrpc/example"
+)
+
+// ReferenceValue is the value returned by ReferenceServer.ZeroInOneOut.
+const ReferenceValue = 42
+
+// ReferenceCount is the number of responses sent by
+// ReferenceServer.ZeroInManyOut.
+const ReferenceCount = 3
+
+// ReferenceServer is the implementation of example.ExampleApiServer that the
+// RPC conformance suite expects to be talking to.
+//
+// - ZeroInZeroOut does nothing.
+// - ZeroInOneOut responds with ReferenceValue.
+// - ZeroInManyOut responds with the values 1 through ReferenceCount.
+// - OneInZeroOut sleeps for req.Value milliseconds, respecting the context.
+// - OneInOneOut echoes req.Value.
+// - OneInManyOut responds with the values 1 through req.Value.
+// - ManyInZeroOut consumes all requests.
+// - ManyInOneOut responds with the sum of all request values.
+// - ManyInManyOut echoes each request as a response.
+type ReferenceServer struct{}
+
+func NewReferenceHandler() vsrpc.Handler {
+	return example.NewExampleApiHandler(ReferenceServer{})
+}
+
+func (ReferenceServer) ZeroInZeroOut(ctx context.Context) error {
+	return nil
+}
+
+func (ReferenceServer) ZeroInOneOut(ctx context.Context, resp *example.ExampleResponse) error {
+	resp.Value = ReferenceValue
+	return nil
+}
+
+func (ReferenceServer) ZeroInManyOut(ctx context.Context, stream vsrpc.SendStream[*example.ExampleResponse]) error {
+	for i := int64(1); i <= ReferenceCount; i++ {
+		if err := stream.Send(&example.ExampleResponse{Value: i}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ReferenceServer) OneInZeroOut(ctx context.Context, req *example.ExampleRequest) error {
+	if req.Value <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(time.Duration(req.Value) * time.Millisecond)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return contextStatus(ctx).AsError()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (ReferenceServer) OneInOneOut(ctx context.Context, req *example.ExampleRequest, resp *example.ExampleResponse) error {
+	resp.Value = req.Value
+	return nil
+}
+
+func (ReferenceServer) OneInManyOut(ctx context.Context, req *example.ExampleRequest, stream vsrpc.SendStream[*example.ExampleResponse]) error {
+	for i := int64(1); i <= req.Value; i++ {
+		if err := stream.Send(&example.ExampleResponse{Value: i}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ReferenceServer) ManyInZeroOut(ctx context.Context, stream vsrpc.RecvStream[*example.ExampleRequest]) error {
+	var req example.ExampleRequest
+	for {
+		_, done, err := stream.Recv(true, &req)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+func (ReferenceServer) ManyInOneOut(ctx context.Context, resp *example.ExampleResponse, stream vsrpc.RecvStream[*example.ExampleRequest]) error {
+	var req example.ExampleRequest
+	for {
+		ok, done, err := stream.Recv(true, &req)
+		if err != nil {
+			return err
+		}
+		if ok {
+			resp.Value += req.Value
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+func (ReferenceServer) ManyInManyOut(ctx context.Context, stream vsrpc.BiStream[*example.ExampleResponse, *example.ExampleRequest]) error {
+	var req example.ExampleRequest
+	for {
+		ok, done, err := stream.Recv(true, &req)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := stream.Send(&example.ExampleResponse{Value: req.Value}); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+var _ example.ExampleApiServer = ReferenceServer{}
+
+func contextStatus(ctx context.Context) *vsrpc.Status {
+	code := vsrpc.Status_CANCELLED
+	if ctx.Err() == context.DeadlineExceeded {
+		code = vsrpc.Status_DEADLINE_EXCEEDED
+	}
+	return &vsrpc.Status{Code: code, Text: ctx.Err().Error()}
+}
@@ -0,0 +1,67 @@
+package vsrpctest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chronos-tachyon/vsrpc"
+	"github.com/chronos-tachyon/vsrpc/example"
+)
+
+func TestRegisterServer(t *testing.T) {
+	mux := &vsrpc.HandlerMux{}
+	example.RegisterExampleApiServer(mux, ReferenceServer{})
+
+	methods := mux.Methods()
+	if len(methods) != len(example.ExampleApi_ServiceDesc.Methods) {
+		t.Fatalf("expected %d methods, got %v", len(example.ExampleApi_ServiceDesc.Methods), methods)
+	}
+	for _, method := range methods {
+		if example.ExampleApi_ServiceDesc.FindMethod(method) == nil {
+			t.Errorf("unexpected method %q", method)
+		}
+	}
+
+	services := mux.Services()
+	if len(services) != 1 || services[0] != &example.ExampleApi_ServiceDesc {
+		t.Fatalf("expected only ExampleApi_ServiceDesc, got %v", services)
+	}
+
+	md := mux.FindMethod(example.ExampleApi_ManyInOneOut_FullMethodName)
+	if md == nil {
+		t.Fatal("FindMethod: ManyInOneOut not found")
+	}
+	if !md.ClientStreaming || md.ServerStreaming {
+		t.Errorf("ManyInOneOut: expected ClientStreaming only, got %+v", md)
+	}
+	mt, err := md.RequestType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mt.New().Interface().(*example.ExampleRequest); !ok {
+		t.Errorf("ManyInOneOut: expected request type *example.ExampleRequest, got %T", mt.New().Interface())
+	}
+
+	lb, err := vsrpc.NewLoopback(context.Background(), mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lb.Close() }()
+
+	var resp example.ExampleResponse
+	client := example.NewExampleApiClient(lb.Conn)
+	if err := client.OneInOneOut(context.Background(), &example.ExampleRequest{Value: 7}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Value != 7 {
+		t.Errorf("OneInOneOut: expected 7, got %d", resp.Value)
+	}
+
+	mux.Remove(example.ExampleApi_ServiceDesc.MethodNames()...)
+	if services := mux.Services(); len(services) != 0 {
+		t.Errorf("expected no services after Remove, got %v", services)
+	}
+	if md := mux.FindMethod(example.ExampleApi_ManyInOneOut_FullMethodName); md != nil {
+		t.Errorf("expected FindMethod to fail after Remove, got %+v", md)
+	}
+}
@@ -0,0 +1,486 @@
+package vsrpctest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chronos-tachyon/vsrpc"
+	"github.com/chronos-tachyon/vsrpc/example"
+)
+
+// RPCConfig describes the server under test for the RPC conformance suite.
+//
+// If Handler is nil, the handler from NewReferenceHandler is used.  Custom
+// handlers (such as middleware wrapping the reference handler) must behave
+// like ReferenceServer.
+type RPCConfig struct {
+	Dialer  vsrpc.PacketDialer
+	Addr    net.Addr
+	Handler vsrpc.Handler
+	Options []vsrpc.Option
+}
+
+type RPCEnv struct {
+	Server *vsrpc.Server
+	Client *vsrpc.Client
+	Conn   *vsrpc.Conn
+	API    example.ExampleApiClient
+}
+
+func NewRPCEnv(ctx context.Context, t *testing.T, cfg RPCConfig) *RPCEnv {
+	t.Helper()
+
+	h := cfg.Handler
+	if h == nil {
+		h = NewReferenceHandler()
+	}
+
+	pl, err := cfg.Dialer.ListenPacket(ctx, cfg.Addr)
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+
+	s := vsrpc.NewServer(pl, h, cfg.Options...)
+	c := vsrpc.NewClient(cfg.Dialer, cfg.Options...)
+
+	conn, err := c.Dial(ctx, s.Addr())
+	if err != nil {
+		_ = c.Close()
+		_ = s.Close()
+		t.Fatalf("Dial: %v", err)
+	}
+
+	return &RPCEnv{
+		Server: s,
+		Client: c,
+		Conn:   conn,
+		API:    example.NewExampleApiClient(conn),
+	}
+}
+
+func (env *RPCEnv) Close() {
+	_ = env.Client.Close()
+	_ = env.Server.Close()
+}
+
+type RPCCase struct {
+	Name       string
+	Func       func(ctx context.Context, t *testing.T, env *RPCEnv) error
+	Timeout    time.Duration
+	HasTimeout bool
+}
+
+var RPCCases = []RPCCase{
+	{"ZeroInZeroOut", CaseZeroInZeroOut, 0, false},
+	{"ZeroInOneOut", CaseZeroInOneOut, 0, false},
+	{"ZeroInManyOut", CaseZeroInManyOut, 0, false},
+	{"OneInZeroOut", CaseOneInZeroOut, 0, false},
+	{"OneInOneOut", CaseOneInOneOut, 0, false},
+	{"OneInManyOut", CaseOneInManyOut, 0, false},
+	{"ManyInZeroOut", CaseManyInZeroOut, 0, false},
+	{"ManyInOneOut", CaseManyInOneOut, 0, false},
+	{"ManyInManyOut", CaseManyInManyOut, 0, false},
+	{"OpenOneInManyOut", CaseOpenOneInManyOut, 0, false},
+	{"OpenManyInZeroOut", CaseOpenManyInZeroOut, 0, false},
+	{"OpenManyInOneOut", CaseOpenManyInOneOut, 0, false},
+	{"OpenManyInManyOut", CaseOpenManyInManyOut, 0, false},
+	{"OpenClose", CaseOpenClose, 0, false},
+	{"NoSuchMethod", CaseNoSuchMethod, 0, false},
+	{"Cancel", CaseCancel, 0, false},
+	{"Deadline", CaseDeadline, 0, false},
+	{"ClientShutdown", CaseClientShutdown, 0, false},
+	{"ServerShutdown", CaseServerShutdown, 0, false},
+}
+
+// TestRPC runs the standard RPC conformance suite against the PacketDialer
+// and Handler in cfg.
+func TestRPC(t *testing.T, cfg RPCConfig) {
+	RunRPC(t, cfg, RPCCases)
+}
+
+func RunRPC(t *testing.T, cfg RPCConfig, cases []RPCCase) {
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx, cancel := ContextFromTest(t)
+			defer cancel()
+
+			if tc.HasTimeout {
+				dctx, cancel := context.WithTimeout(ctx, tc.Timeout)
+				defer cancel()
+				ctx = dctx
+			}
+
+			env := NewRPCEnv(ctx, t, cfg)
+			defer env.Close()
+
+			if err := tc.Func(ctx, t, env); err != nil {
+				t.Error(err.Error())
+			}
+		})
+	}
+}
+
+func CaseZeroInZeroOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	return env.API.ZeroInZeroOut(ctx)
+}
+
+func CaseZeroInOneOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	var resp example.ExampleResponse
+	if err := env.API.ZeroInOneOut(ctx, &resp); err != nil {
+		return err
+	}
+	return expectValue(resp.Value, ReferenceValue)
+}
+
+func CaseZeroInManyOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	var values []int64
+	err := env.API.ZeroInManyOut(ctx, func(stream vsrpc.RecvStream[*example.ExampleResponse]) (err error) {
+		values, err = recvAll(stream)
+		return
+	})
+	if err != nil {
+		return err
+	}
+	return expectValues(values, countUpTo(ReferenceCount))
+}
+
+func CaseOneInZeroOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	return env.API.OneInZeroOut(ctx, &example.ExampleRequest{Value: 1})
+}
+
+func CaseOneInOneOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	var resp example.ExampleResponse
+	if err := env.API.OneInOneOut(ctx, &example.ExampleRequest{Value: 17}, &resp); err != nil {
+		return err
+	}
+	return expectValue(resp.Value, 17)
+}
+
+func CaseOneInManyOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	for _, n := range []int64{0, 1, 5} {
+		var values []int64
+		err := env.API.OneInManyOut(ctx, &example.ExampleRequest{Value: n}, func(stream vsrpc.RecvStream[*example.ExampleResponse]) (err error) {
+			values, err = recvAll(stream)
+			return
+		})
+		if err != nil {
+			return err
+		}
+		if err := expectValues(values, countUpTo(n)); err != nil {
+			return fmt.Errorf("n=%d: %w", n, err)
+		}
+	}
+	return nil
+}
+
+func CaseManyInZeroOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	return env.API.ManyInZeroOut(ctx, func(stream vsrpc.SendStream[*example.ExampleRequest]) error {
+		return sendAll(stream, countUpTo(10))
+	})
+}
+
+func CaseManyInOneOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	var resp example.ExampleResponse
+	err := env.API.ManyInOneOut(ctx, &resp, func(stream vsrpc.SendStream[*example.ExampleRequest]) error {
+		return sendAll(stream, countUpTo(10))
+	})
+	if err != nil {
+		return err
+	}
+	return expectValue(resp.Value, 55)
+}
+
+func CaseManyInManyOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	input := []int64{3, 1, 4, 1, 5, 9, 2, 6}
+	var values []int64
+	err := env.API.ManyInManyOut(ctx, func(stream vsrpc.BiStream[*example.ExampleRequest, *example.ExampleResponse]) error {
+		var resp example.ExampleResponse
+		for _, value := range input {
+			if err := stream.Send(&example.ExampleRequest{Value: value}); err != nil {
+				return err
+			}
+			ok, _, err := stream.Recv(true, &resp)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("stream ended early")
+			}
+			values = append(values, resp.Value)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return expectValues(values, input)
+}
+
+func CaseOpenOneInManyOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	stream, err := env.API.OpenOneInManyOut(ctx, &example.ExampleRequest{Value: 5})
+	if err != nil {
+		return err
+	}
+
+	values, err := recvAll(stream)
+	if err2 := stream.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return err
+	}
+	return expectValues(values, countUpTo(5))
+}
+
+func CaseOpenManyInZeroOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	stream, err := env.API.OpenManyInZeroOut(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+
+	if err := sendAll(stream, countUpTo(10)); err != nil {
+		return err
+	}
+	return stream.CloseAndWait()
+}
+
+func CaseOpenManyInOneOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	stream, err := env.API.OpenManyInOneOut(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+
+	if err := sendAll(stream, countUpTo(10)); err != nil {
+		return err
+	}
+
+	var resp example.ExampleResponse
+	if err := stream.CloseAndRecv(&resp); err != nil {
+		return err
+	}
+	return expectValue(resp.Value, 55)
+}
+
+func CaseOpenManyInManyOut(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	stream, err := env.API.OpenManyInManyOut(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+
+	input := []int64{2, 7, 1, 8}
+	errCh := make(chan error, 1)
+	go func() {
+		err := sendAll(stream, input)
+		if err == nil {
+			err = stream.CloseSend()
+		}
+		errCh <- err
+	}()
+
+	values, err := recvAll(stream)
+	if err2 := <-errCh; err == nil {
+		err = err2
+	}
+	if err2 := stream.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return err
+	}
+	return expectValues(values, input)
+}
+
+func CaseOpenClose(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	stream, err := env.API.OpenManyInManyOut(ctx)
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&example.ExampleRequest{Value: 1}); err != nil {
+		return err
+	}
+
+	// The handler decides how a cancelled call ends; the reference handler
+	// just stops reading and reports OK.
+	status := vsrpc.StatusFromError(stream.Close())
+	if code := status.GetCode(); code != vsrpc.Status_OK && code != vsrpc.Status_CANCELLED {
+		return fmt.Errorf("unexpected status after Close: %v", status.AsError())
+	}
+	return CaseOneInOneOut(ctx, t, env)
+}
+
+func CaseNoSuchMethod(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	call, err := env.Conn.Begin(ctx, "vsrpc.ExampleApi.DoesNotExist")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = call.Close() }()
+
+	if err := call.CloseSend(); err != nil {
+		return err
+	}
+	return expectCode(call.Wait(), vsrpc.Status_UNIMPLEMENTED)
+}
+
+func CaseCancel(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	call, err := env.Conn.Begin(ctx, "vsrpc.ExampleApi.OneInZeroOut")
+	if err != nil {
+		return err
+	}
+
+	stream := vsrpc.NewSendStream[*example.ExampleRequest](call)
+	if err := stream.Send(&example.ExampleRequest{Value: 60000}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	if err := call.Cancel(); err != nil {
+		return err
+	}
+	return expectCode(call.Wait(), vsrpc.Status_CANCELLED)
+}
+
+func CaseDeadline(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	dctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	err := env.API.OneInZeroOut(dctx, &example.ExampleRequest{Value: 60000})
+	return expectCode(vsrpc.StatusFromError(err), vsrpc.Status_DEADLINE_EXCEEDED)
+}
+
+func CaseClientShutdown(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	call, err := env.Conn.Begin(ctx, "vsrpc.ExampleApi.OneInOneOut")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = call.Close() }()
+
+	if err := env.Conn.Shutdown(ctx); err != nil {
+		return fmt.Errorf("Shutdown: %w", err)
+	}
+
+	if _, err := env.Conn.Begin(ctx, "vsrpc.ExampleApi.ZeroInZeroOut"); !errors.Is(err, vsrpc.ErrConnShuttingDown) {
+		return fmt.Errorf("Begin after Shutdown: expected %v, got %v", vsrpc.ErrConnShuttingDown, err)
+	}
+
+	stream := vsrpc.NewStream[*example.ExampleRequest, *example.ExampleResponse](call)
+	if err := stream.Send(&example.ExampleRequest{Value: 23}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	var resp example.ExampleResponse
+	if _, _, err := stream.Recv(true, &resp); err != nil {
+		return err
+	}
+	if err := call.Wait().AsError(); err != nil {
+		return fmt.Errorf("in-flight call failed after Shutdown: %w", err)
+	}
+	return expectValue(resp.Value, 23)
+}
+
+func CaseServerShutdown(ctx context.Context, t *testing.T, env *RPCEnv) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- env.API.OneInZeroOut(ctx, &example.ExampleRequest{Value: 100})
+	}()
+
+	// Give the in-flight call time to reach the server.
+	time.Sleep(20 * time.Millisecond)
+
+	if err := env.Server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("Shutdown: %w", err)
+	}
+
+	for {
+		err := env.API.ZeroInZeroOut(ctx)
+		if errors.Is(err, vsrpc.ErrConnGoingAway) {
+			break
+		}
+		if err == nil {
+			return fmt.Errorf("call succeeded after server Shutdown")
+		}
+		if code := vsrpc.StatusFromError(err).GetCode(); code != vsrpc.Status_ABORTED {
+			return fmt.Errorf("call after server Shutdown: expected %v, got %v", vsrpc.Status_ABORTED, err)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("never received GO_AWAY: %w", ctx.Err())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := <-errCh; err != nil {
+		return fmt.Errorf("in-flight call failed after Shutdown: %w", err)
+	}
+	return nil
+}
+
+func recvAll(stream vsrpc.RecvStream[*example.ExampleResponse]) ([]int64, error) {
+	var values []int64
+	var resp example.ExampleResponse
+	for {
+		ok, done, err := stream.Recv(true, &resp)
+		if err != nil {
+			return values, err
+		}
+		if ok {
+			values = append(values, resp.Value)
+		}
+		if done {
+			return values, nil
+		}
+	}
+}
+
+func sendAll(stream vsrpc.SendStream[*example.ExampleRequest], values []int64) error {
+	for _, value := range values {
+		if err := stream.Send(&example.ExampleRequest{Value: value}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func countUpTo(n int64) []int64 {
+	out := make([]int64, 0, n)
+	for i := int64(1); i <= n; i++ {
+		out = append(out, i)
+	}
+	return out
+}
+
+func expectValue(actual int64, expect int64) error {
+	if actual != expect {
+		return fmt.Errorf("expected value %d, got %d", expect, actual)
+	}
+	return nil
+}
+
+func expectValues(actual []int64, expect []int64) error {
+	if len(actual) != len(expect) {
+		return fmt.Errorf("expected values %v, got %v", expect, actual)
+	}
+	for i := range actual {
+		if actual[i] != expect[i] {
+			return fmt.Errorf("expected values %v, got %v", expect, actual)
+		}
+	}
+	return nil
+}
+
+func expectCode(status *vsrpc.Status, expect vsrpc.Status_Code) error {
+	if actual := status.GetCode(); actual != expect {
+		return fmt.Errorf("wrong status code: expected %v, got %v (%v)", expect, actual, status.AsError())
+	}
+	return nil
+}
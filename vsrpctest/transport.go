@@ -0,0 +1,357 @@
+package vsrpctest
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+const DefaultTransportMaxPacketSize = (1 << 16)
+
+type TransportConfig struct {
+	Dialer        vsrpc.PacketDialer
+	Addr          net.Addr
+	MaxPacketSize uint
+}
+
+type TransportEnv struct {
+	Listener      vsrpc.PacketListener
+	Client        vsrpc.PacketConn
+	Server        vsrpc.PacketConn
+	MaxPacketSize uint
+}
+
+func NewTransportEnv(ctx context.Context, t *testing.T, cfg TransportConfig) *TransportEnv {
+	t.Helper()
+
+	pl, err := cfg.Dialer.ListenPacket(ctx, cfg.Addr)
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+
+	type result struct {
+		pc  vsrpc.PacketConn
+		err error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		pc, err := pl.AcceptPacket(ctx)
+		ch <- result{pc, err}
+	}()
+
+	client, err := cfg.Dialer.DialPacket(ctx, pl.Addr())
+	if err != nil {
+		_ = pl.Close()
+		t.Fatalf("DialPacket: %v", err)
+	}
+
+	r := <-ch
+	if r.err != nil {
+		_ = client.Close()
+		_ = pl.Close()
+		t.Fatalf("AcceptPacket: %v", r.err)
+	}
+
+	size := cfg.MaxPacketSize
+	if size == 0 {
+		size = DefaultTransportMaxPacketSize
+	}
+
+	return &TransportEnv{
+		Listener:      pl,
+		Client:        client,
+		Server:        r.pc,
+		MaxPacketSize: size,
+	}
+}
+
+func (env *TransportEnv) Close() {
+	_ = env.Client.Close()
+	_ = env.Server.Close()
+	_ = env.Listener.Close()
+}
+
+type TransportCase struct {
+	Name       string
+	Func       func(ctx context.Context, t *testing.T, env *TransportEnv) error
+	Timeout    time.Duration
+	HasTimeout bool
+}
+
+var TransportCases = []TransportCase{
+	{"Ordering", CaseOrdering, 0, false},
+	{"MessageBoundaries", CaseMessageBoundaries, 0, false},
+	{"MaxPacketSize", CaseMaxPacketSize, 0, false},
+	{"ReadDeadline", CaseReadDeadline, 0, false},
+	{"ReadCancel", CaseReadCancel, 0, false},
+	{"CloseLocal", CaseCloseLocal, 0, false},
+	{"ClosePeer", CaseClosePeer, 0, false},
+	{"ConcurrentWriters", CaseConcurrentWriters, 0, false},
+	{"ListenerClose", CaseListenerClose, 0, false},
+}
+
+// TestTransport runs the standard transport conformance suite against the
+// PacketDialer in cfg.
+func TestTransport(t *testing.T, cfg TransportConfig) {
+	RunTransport(t, cfg, TransportCases)
+}
+
+func RunTransport(t *testing.T, cfg TransportConfig, cases []TransportCase) {
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx, cancel := ContextFromTest(t)
+			defer cancel()
+
+			if tc.HasTimeout {
+				dctx, cancel := context.WithTimeout(ctx, tc.Timeout)
+				defer cancel()
+				ctx = dctx
+			}
+
+			env := NewTransportEnv(ctx, t, cfg)
+			defer env.Close()
+
+			if err := tc.Func(ctx, t, env); err != nil {
+				t.Error(err.Error())
+			}
+		})
+	}
+}
+
+func CaseOrdering(ctx context.Context, t *testing.T, env *TransportEnv) error {
+	const n = 100
+
+	packets := make([][]byte, n)
+	for i := range packets {
+		packets[i] = []byte(fmt.Sprintf("packet-%03d", i))
+	}
+
+	if err := transfer(ctx, env.Client, env.Server, packets); err != nil {
+		return fmt.Errorf("client to server: %w", err)
+	}
+	if err := transfer(ctx, env.Server, env.Client, packets); err != nil {
+		return fmt.Errorf("server to client: %w", err)
+	}
+	return nil
+}
+
+func CaseMessageBoundaries(ctx context.Context, t *testing.T, env *TransportEnv) error {
+	sizes := []uint{1, 2, 7, 64, 1000, 4096, env.MaxPacketSize / 2, 3, env.MaxPacketSize}
+
+	packets := make([][]byte, len(sizes))
+	for i, size := range sizes {
+		packets[i] = Pattern(size, byte(i))
+	}
+	return transfer(ctx, env.Client, env.Server, packets)
+}
+
+func CaseMaxPacketSize(ctx context.Context, t *testing.T, env *TransportEnv) error {
+	packets := [][]byte{Pattern(env.MaxPacketSize, 0x5a)}
+	if err := transfer(ctx, env.Client, env.Server, packets); err != nil {
+		return fmt.Errorf("client to server: %w", err)
+	}
+	if err := transfer(ctx, env.Server, env.Client, packets); err != nil {
+		return fmt.Errorf("server to client: %w", err)
+	}
+	return nil
+}
+
+func CaseReadDeadline(ctx context.Context, t *testing.T, env *TransportEnv) error {
+	dctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	packet, dispose, err := env.Server.ReadPacket(dctx)
+	if err == nil {
+		dispose()
+		return fmt.Errorf("expected ReadPacket to fail after deadline, got %d-byte packet", len(packet))
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		return fmt.Errorf("ReadPacket took %v to honor a 50ms deadline", elapsed)
+	}
+
+	packets := [][]byte{[]byte("after deadline")}
+	if err := transfer(ctx, env.Client, env.Server, packets); err != nil {
+		return fmt.Errorf("connection unusable after deadline: %w", err)
+	}
+	return nil
+}
+
+func CaseReadCancel(ctx context.Context, t *testing.T, env *TransportEnv) error {
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	packet, dispose, err := env.Server.ReadPacket(cctx)
+	if err == nil {
+		dispose()
+		return fmt.Errorf("expected ReadPacket to fail after cancel, got %d-byte packet", len(packet))
+	}
+	return nil
+}
+
+func CaseCloseLocal(ctx context.Context, t *testing.T, env *TransportEnv) error {
+	if err := env.Client.Close(); err != nil {
+		return fmt.Errorf("Close: %w", err)
+	}
+	if err := env.Client.WritePacket(ctx, []byte("x")); err == nil {
+		return fmt.Errorf("expected WritePacket after Close to fail")
+	}
+	if _, dispose, err := env.Client.ReadPacket(ctx); err == nil {
+		dispose()
+		return fmt.Errorf("expected ReadPacket after Close to fail")
+	}
+	return nil
+}
+
+func CaseClosePeer(ctx context.Context, t *testing.T, env *TransportEnv) error {
+	if err := env.Server.Close(); err != nil {
+		return fmt.Errorf("Close: %w", err)
+	}
+	if _, dispose, err := env.Client.ReadPacket(ctx); err == nil {
+		dispose()
+		return fmt.Errorf("expected ReadPacket after peer Close to fail")
+	}
+	return nil
+}
+
+func CaseConcurrentWriters(ctx context.Context, t *testing.T, env *TransportEnv) error {
+	const writers = 8
+	const perWriter = 32
+
+	errCh := make(chan error, writers)
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for seq := 0; seq < perWriter; seq++ {
+				if err := env.Client.WritePacket(ctx, taggedPacket(byte(w), uint16(seq))); err != nil {
+					errCh <- err
+					return
+				}
+			}
+		}(w)
+	}
+
+	var next [writers]uint16
+	var err error
+	for i := 0; i < writers*perWriter && err == nil; i++ {
+		var packet []byte
+		var dispose func()
+		packet, dispose, err = env.Server.ReadPacket(ctx)
+		if err != nil {
+			break
+		}
+		err = checkTaggedPacket(packet, next[:])
+		dispose()
+	}
+
+	wg.Wait()
+	close(errCh)
+	if werr := <-errCh; werr != nil && err == nil {
+		err = werr
+	}
+	return err
+}
+
+func CaseListenerClose(ctx context.Context, t *testing.T, env *TransportEnv) error {
+	if env.Listener.Addr() == nil {
+		return fmt.Errorf("listener has nil Addr")
+	}
+	if err := env.Listener.Close(); err != nil {
+		return fmt.Errorf("Close: %w", err)
+	}
+
+	dctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if pc, err := env.Listener.AcceptPacket(dctx); err == nil {
+		_ = pc.Close()
+		return fmt.Errorf("expected AcceptPacket after Close to fail")
+	}
+	return nil
+}
+
+// Pattern returns a deterministic byte sequence of the given length.
+func Pattern(size uint, seed byte) []byte {
+	out := make([]byte, size)
+	x := seed
+	for i := range out {
+		x = x*31 + 7
+		out[i] = x
+	}
+	return out
+}
+
+func transfer(ctx context.Context, w vsrpc.PacketWriter, r vsrpc.PacketReader, packets [][]byte) error {
+	errCh := make(chan error, 1)
+	go func() {
+		for _, packet := range packets {
+			if err := w.WritePacket(ctx, packet); err != nil {
+				errCh <- err
+				return
+			}
+		}
+		errCh <- nil
+	}()
+
+	for i, expect := range packets {
+		actual, dispose, err := r.ReadPacket(ctx)
+		if err != nil {
+			return fmt.Errorf("packet %d: ReadPacket: %w", i, err)
+		}
+		equal := bytes.Equal(actual, expect)
+		n := len(actual)
+		dispose()
+		if !equal {
+			return fmt.Errorf("packet %d: expected %d bytes, got %d bytes with different contents", i, len(expect), n)
+		}
+	}
+	return <-errCh
+}
+
+func taggedPacket(writer byte, seq uint16) []byte {
+	packet := Pattern(uint(64+seq), writer^byte(seq))
+	packet[0] = writer
+	binary.BigEndian.PutUint16(packet[1:3], seq)
+	return packet
+}
+
+func checkTaggedPacket(packet []byte, next []uint16) error {
+	if len(packet) < 3 || int(packet[0]) >= len(next) {
+		return fmt.Errorf("received malformed %d-byte packet", len(packet))
+	}
+	writer := packet[0]
+	seq := binary.BigEndian.Uint16(packet[1:3])
+	if seq != next[writer] {
+		return fmt.Errorf("writer %d: expected seq %d, got seq %d", writer, next[writer], seq)
+	}
+	if !bytes.Equal(packet, taggedPacket(writer, seq)) {
+		return fmt.Errorf("writer %d: seq %d: packet contents were corrupted or interleaved", writer, seq)
+	}
+	next[writer]++
+	return nil
+}
+
+func ContextFromTest(t *testing.T) (ctx context.Context, cancel context.CancelFunc) {
+	ctx = context.Background()
+	cancel = func() {}
+	if deadline, ok := t.Deadline(); ok {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+	}
+	return
+}
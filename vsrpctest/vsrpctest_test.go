@@ -0,0 +1,53 @@
+package vsrpctest
+
+import (
+	"net"
+	"testing"
+
+	"github.com/chronos-tachyon/vsrpc"
+)
+
+func unixAddr(t *testing.T) net.Addr {
+	addr, err := net.ResolveUnixAddr("unixpacket", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func unixDialer() *vsrpc.UnixDialer {
+	return &vsrpc.UnixDialer{MaxPacketSize: DefaultTransportMaxPacketSize}
+}
+
+func TestUnixTransport(t *testing.T) {
+	TestTransport(t, TransportConfig{Dialer: unixDialer(), Addr: unixAddr(t)})
+}
+
+func TestUnixRPC(t *testing.T) {
+	TestRPC(t, RPCConfig{Dialer: unixDialer(), Addr: unixAddr(t)})
+}
+
+func TestFaultyTransport(t *testing.T) {
+	pd := &vsrpc.FaultyDialer{Dialer: unixDialer()}
+	TestTransport(t, TransportConfig{Dialer: pd, Addr: unixAddr(t)})
+}
+
+func TestPipeTransport(t *testing.T) {
+	TestTransport(t, TransportConfig{Dialer: &vsrpc.PipeDialer{}})
+}
+
+func TestPipeRPC(t *testing.T) {
+	TestRPC(t, RPCConfig{Dialer: &vsrpc.PipeDialer{}})
+}
+
+func webSocketDialer() *vsrpc.WebSocketDialer {
+	return &vsrpc.WebSocketDialer{MaxPacketSize: DefaultTransportMaxPacketSize}
+}
+
+func TestWebSocketTransport(t *testing.T) {
+	TestTransport(t, TransportConfig{Dialer: webSocketDialer(), Addr: vsrpc.WebSocketAddr("ws://127.0.0.1:0/vsrpc")})
+}
+
+func TestWebSocketRPC(t *testing.T) {
+	TestRPC(t, RPCConfig{Dialer: webSocketDialer(), Addr: vsrpc.WebSocketAddr("ws://127.0.0.1:0/vsrpc")})
+}
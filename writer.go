@@ -0,0 +1,438 @@
+package vsrpc
+
+import (
+	"context"
+	"sync"
+)
+
+// WriteBatchConfig enables coalescing of small frames into a single write,
+// for PacketConns that implement PacketBatchWriter.  A batch holds at most
+// MaxFrames frames and, unless it holds just one frame, at most MaxBytes
+// bytes; zero means no limit on bytes.
+type WriteBatchConfig struct {
+	MaxFrames uint
+	MaxBytes  uint
+}
+
+// WithWriteBatch sets the WriteBatchConfig of each Conn.  Without it, every
+// frame is written on its own.
+func WithWriteBatch(config *WriteBatchConfig) Option {
+	if config == nil {
+		return (*withWriteBatch)(nil)
+	}
+	return &withWriteBatch{config: *config}
+}
+
+type withWriteBatch struct {
+	config WriteBatchConfig
+}
+
+func (opt *withWriteBatch) applyToClient(c *Client) {}
+
+func (opt *withWriteBatch) applyToServer(s *Server) {}
+
+func (opt *withWriteBatch) applyToConn(conn *Conn) {
+	if opt == nil || conn == nil {
+		return
+	}
+	conn.writer.batch = opt.config
+}
+
+func (opt *withWriteBatch) applyToCall(call *Call) {}
+
+var _ Option = (*withWriteBatch)(nil)
+
+// pendingWrite is one marshaled frame waiting for the writer thread.  It is
+// a PacketWriter so that the Write* functions can fill it in.
+type pendingWrite struct {
+	ctx  context.Context
+	call *Call
+	raw  []byte
+	seq  uint64
+	done chan error
+	late func(PacketWriter) error
+}
+
+func (pw *pendingWrite) WritePacket(ctx context.Context, p []byte) error {
+	pw.raw = p
+	return nil
+}
+
+// refresh marshals a frame queued with queueLate again, just before it is
+// written.
+func (pw *pendingWrite) refresh() error {
+	if pw.late == nil {
+		return nil
+	}
+	return pw.late(pw)
+}
+
+func (pw *pendingWrite) finish(err error) {
+	if pw.done != nil {
+		pw.done <- err
+	}
+}
+
+var _ PacketWriter = (*pendingWrite)(nil)
+
+// laneFrameOverhead is the cost charged to a Call for each frame it writes,
+// on top of the size of the frame, so that a stream of tiny frames still pays
+// its way.
+const laneFrameOverhead = 64
+
+// lanePassShift is the number of fractional bits in the pass of a writeLane,
+// so that the cost of a frame divided by even MaxPriority is never rounded
+// down to nothing.
+const lanePassShift = 16
+
+// writeLane is the FIFO of frames queued by one Call.
+//
+// Lanes are scheduled by start-time fair queueing: the writer serves the lane
+// with the lowest pass, and each frame advances the pass of its lane by its
+// cost divided by the priority of its Call.  A lane that becomes active starts
+// no earlier than the pass of the frame most recently served, so that an idle
+// Call cannot bank credit.
+type writeLane struct {
+	frames    []*pendingWrite
+	weight    uint64
+	pass      uint64
+	forgotten bool
+}
+
+// connWriter is the outbound queue of a Conn.  Senders marshal their frames
+// and queue them; a single writer thread drains the queue, so that no lock is
+// held while a write blocks.
+//
+// Each Call has its own lane, and the lanes share the Conn in proportion to
+// the priorities of their Calls (see writeLane), so that a Call with many
+// frames to send cannot starve the others.  Frames without a Call, such as
+// SHUTDOWN and GO_AWAY, have a FIFO of their own, and are held back until
+// every Call frame queued before them has been written.
+type connWriter struct {
+	conn  *Conn
+	batch WriteBatchConfig
+
+	mu     sync.Mutex
+	wake   chan struct{}
+	lanes  map[*Call]*writeLane
+	global []*pendingWrite
+	seq    uint64
+	vtime  uint64
+	closed bool
+}
+
+func newConnWriter(conn *Conn) *connWriter {
+	return &connWriter{
+		conn: conn,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// queue prepares a frame with fn, which calls one of the Write* functions,
+// and queues it.  If wait is false, nobody is told how the write went, other
+// than the Observers if it fails.
+func (w *connWriter) queue(ctx context.Context, call *Call, wait bool, fn func(PacketWriter) error) (*pendingWrite, error) {
+	return w.queueFrame(&pendingWrite{ctx: ctx, call: call}, wait, fn)
+}
+
+// queueLate is like queue, but fn is called again just before the frame is
+// written, for frames whose contents depend on the time they are sent, such
+// as the timeout in BEGIN.
+func (w *connWriter) queueLate(ctx context.Context, call *Call, wait bool, fn func(PacketWriter) error) (*pendingWrite, error) {
+	return w.queueFrame(&pendingWrite{ctx: ctx, call: call, late: fn}, wait, fn)
+}
+
+func (w *connWriter) queueFrame(pw *pendingWrite, wait bool, fn func(PacketWriter) error) (*pendingWrite, error) {
+	call := pw.call
+	if err := fn(pw); err != nil {
+		return nil, err
+	}
+	if wait {
+		pw.done = make(chan error, 1)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil, ErrConnClosed
+	}
+
+	w.seq++
+	pw.seq = w.seq
+	if call == nil {
+		w.global = append(w.global, pw)
+	} else {
+		if w.lanes == nil {
+			w.lanes = make(map[*Call]*writeLane, 16)
+		}
+		lane := w.lanes[call]
+		if lane == nil {
+			lane = &writeLane{weight: uint64(call.Priority())}
+			w.lanes[call] = lane
+		}
+		if len(lane.frames) <= 0 && lane.pass < w.vtime {
+			lane.pass = w.vtime
+		}
+		lane.frames = append(lane.frames, pw)
+	}
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return pw, nil
+}
+
+// wait waits for a queued frame to be written.  If ctx is cancelled first
+// and the frame has not been taken by the writer thread yet, it is withdrawn.
+func (w *connWriter) wait(pw *pendingWrite) error {
+	select {
+	case err := <-pw.done:
+		return err
+	case <-pw.ctx.Done():
+		if w.withdraw(pw) {
+			return pw.ctx.Err()
+		}
+		return <-pw.done
+	}
+}
+
+func (w *connWriter) withdraw(pw *pendingWrite) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if pw.call == nil {
+		for index, other := range w.global {
+			if other == pw {
+				w.global = append(w.global[:index], w.global[index+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+
+	lane := w.lanes[pw.call]
+	if lane == nil {
+		return false
+	}
+	for index, other := range lane.frames {
+		if other == pw {
+			lane.frames = append(lane.frames[:index], lane.frames[index+1:]...)
+			w.lockedTidy(pw.call, lane)
+			return true
+		}
+	}
+	return false
+}
+
+// forget drops the lane of a Call that has ended, once its last frames have
+// been written.
+func (w *connWriter) forget(call *Call) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if lane := w.lanes[call]; lane != nil {
+		lane.forgotten = true
+		w.lockedTidy(call, lane)
+	}
+}
+
+func (w *connWriter) lockedTidy(call *Call, lane *writeLane) {
+	if lane.forgotten && len(lane.frames) <= 0 {
+		delete(w.lanes, call)
+	}
+}
+
+func (w *connWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+	w.closed = true
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *connWriter) writeThread() {
+	ctx := context.Background()
+	ctx = WithContextClient(ctx, w.conn.c)
+	ctx = WithContextServer(ctx, w.conn.s)
+	ctx = WithContextConn(ctx, w.conn)
+
+	var batch []*pendingWrite
+	var packets [][]byte
+	for {
+		batch = w.next(batch[:0])
+		if batch == nil {
+			return
+		}
+
+		var err error
+		bw, canBatch := w.conn.pc.(PacketBatchWriter)
+		if len(batch) == 1 || !canBatch {
+			for _, pw := range batch {
+				if err := pw.ctx.Err(); err != nil {
+					pw.finish(err)
+					continue
+				}
+				if err := pw.refresh(); err != nil {
+					pw.finish(err)
+					continue
+				}
+				// A write that fails because its sender gave up is
+				// that sender's problem, not the Conn's.
+				err := w.conn.pc.WritePacket(pw.ctx, pw.raw)
+				if err != nil && pw.ctx.Err() != nil {
+					pw.finish(err)
+					continue
+				}
+				pw.finish(w.gotWriteError(err))
+			}
+			continue
+		}
+
+		// Frames whose senders have given up are left out of the batch.
+		packets = packets[:0]
+		live := batch[:0]
+		for _, pw := range batch {
+			if err := pw.ctx.Err(); err != nil {
+				pw.finish(err)
+				continue
+			}
+			if err := pw.refresh(); err != nil {
+				pw.finish(err)
+				continue
+			}
+			live = append(live, pw)
+			packets = append(packets, pw.raw)
+		}
+		if len(live) > 0 {
+			err = w.gotWriteError(bw.WritePackets(ctx, packets))
+		}
+		for _, pw := range live {
+			pw.finish(err)
+		}
+		clear(batch)
+		clear(packets)
+	}
+}
+
+func (w *connWriter) gotWriteError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	closed := w.closed
+	w.mu.Unlock()
+	if closed {
+		return ErrConnClosed
+	}
+
+	conn := w.conn
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.lockedGotWriteError(err)
+}
+
+// next waits for frames to write, and returns the next batch.  It returns
+// nil once the writer is closed, after failing whatever is still queued.
+func (w *connWriter) next(batch []*pendingWrite) []*pendingWrite {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	maxFrames := w.batch.MaxFrames
+	if maxFrames <= 0 {
+		maxFrames = 1
+	}
+
+	for !w.closed {
+		var size uint
+		for uint(len(batch)) < maxFrames {
+			pw := w.lockedPeek()
+			if pw == nil {
+				break
+			}
+			if len(batch) > 0 && w.batch.MaxBytes > 0 && size+uint(len(pw.raw)) > w.batch.MaxBytes {
+				break
+			}
+			w.lockedPop(pw)
+			batch = append(batch, pw)
+			size += uint(len(pw.raw))
+		}
+		if len(batch) > 0 {
+			return batch
+		}
+
+		w.mu.Unlock()
+		<-w.wake
+		w.mu.Lock()
+	}
+
+	for _, pw := range w.global {
+		pw.finish(ErrConnClosed)
+	}
+	for _, lane := range w.lanes {
+		for _, pw := range lane.frames {
+			pw.finish(ErrConnClosed)
+		}
+	}
+	w.global = nil
+	w.lanes = nil
+	return nil
+}
+
+// lockedPeek returns the frame that should be written next: the oldest frame
+// without a Call if no earlier Call frame is still waiting, and otherwise the
+// first frame of the lane with the lowest pass.
+func (w *connWriter) lockedPeek() *pendingWrite {
+	var best *writeLane
+	for _, lane := range w.lanes {
+		if len(lane.frames) <= 0 {
+			continue
+		}
+		if best == nil || lane.pass < best.pass || (lane.pass == best.pass && lane.frames[0].seq < best.frames[0].seq) {
+			best = lane
+		}
+	}
+
+	if len(w.global) > 0 {
+		head := w.global[0]
+		earliest := true
+		for _, lane := range w.lanes {
+			if len(lane.frames) > 0 && lane.frames[0].seq < head.seq {
+				earliest = false
+				break
+			}
+		}
+		if earliest {
+			return head
+		}
+	}
+
+	if best != nil {
+		return best.frames[0]
+	}
+	return nil
+}
+
+func (w *connWriter) lockedPop(pw *pendingWrite) {
+	if pw.call == nil {
+		w.global[0] = nil
+		w.global = w.global[1:]
+		return
+	}
+
+	lane := w.lanes[pw.call]
+	w.vtime = lane.pass
+	cost := (uint64(len(pw.raw)) + laneFrameOverhead) << lanePassShift
+	lane.pass += max(1, cost*uint64(DefaultPriority)/lane.weight)
+	lane.frames[0] = nil
+	lane.frames = lane.frames[1:]
+	w.lockedTidy(pw.call, lane)
+}
@@ -0,0 +1,215 @@
+package vsrpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type recordingConn struct {
+	mu      sync.Mutex
+	packets []string
+	batches []int
+	fail    string
+}
+
+func (pc *recordingConn) ReadPacket(ctx context.Context) ([]byte, func(), error) {
+	<-ctx.Done()
+	return nil, nil, ctx.Err()
+}
+
+func (pc *recordingConn) WritePacket(ctx context.Context, p []byte) error {
+	return pc.WritePackets(ctx, [][]byte{p})
+}
+
+func (pc *recordingConn) WritePackets(ctx context.Context, packets [][]byte) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	for _, p := range packets {
+		if string(p) == pc.fail {
+			return RecoverableError{Err: errors.New("write failed")}
+		}
+	}
+	for _, p := range packets {
+		pc.packets = append(pc.packets, string(p))
+	}
+	pc.batches = append(pc.batches, len(packets))
+	return nil
+}
+
+func (pc *recordingConn) LocalAddr() net.Addr  { return nil }
+func (pc *recordingConn) RemoteAddr() net.Addr { return nil }
+func (pc *recordingConn) Close() error         { return nil }
+
+var _ PacketConn = (*recordingConn)(nil)
+var _ PacketBatchWriter = (*recordingConn)(nil)
+
+func queueRaw(t *testing.T, w *connWriter, ctx context.Context, call *Call, name string) *pendingWrite {
+	t.Helper()
+	pw, err := w.queue(ctx, call, true, func(pw PacketWriter) error {
+		return pw.WritePacket(ctx, []byte(name))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pw
+}
+
+func TestConnWriter_Fairness(t *testing.T) {
+	pc := &recordingConn{}
+	conn := newConn(ClientRole, nil, nil, pc, nil)
+	defer conn.writer.close()
+
+	ctx := context.Background()
+	a, b := &Call{}, &Call{}
+	var pending []*pendingWrite
+	for _, item := range []struct {
+		call *Call
+		name string
+	}{{a, "a1"}, {a, "a2"}, {a, "a3"}, {b, "b1"}, {nil, "shutdown"}, {b, "b2"}} {
+		pending = append(pending, queueRaw(t, conn.writer, ctx, item.call, item.name))
+	}
+
+	go conn.writer.writeThread()
+	for _, pw := range pending {
+		if err := conn.writer.wait(pw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The Calls take turns, and the frame without a Call waits for every
+	// Call frame that was queued before it.
+	expect := []string{"a1", "b1", "a2", "b2", "a3", "shutdown"}
+	if !reflect.DeepEqual(pc.packets, expect) {
+		t.Errorf("expected %q, got %q", expect, pc.packets)
+	}
+}
+
+func TestConnWriter_Batch(t *testing.T) {
+	pc := &recordingConn{fail: "bad"}
+	conn := newConn(ClientRole, nil, nil, pc, []Option{WithWriteBatch(&WriteBatchConfig{MaxFrames: 3})})
+	defer conn.writer.close()
+
+	ctx := context.Background()
+	cancelled, cancel := context.WithCancel(ctx)
+	call := &Call{}
+	first := queueRaw(t, conn.writer, ctx, call, "1")
+	second := queueRaw(t, conn.writer, ctx, call, "2")
+	withdrawn := queueRaw(t, conn.writer, cancelled, call, "withdrawn")
+	third := queueRaw(t, conn.writer, ctx, call, "3")
+	bad := queueRaw(t, conn.writer, ctx, call, "bad")
+	cancel()
+
+	if err := conn.writer.wait(withdrawn); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	go conn.writer.writeThread()
+	for _, pw := range []*pendingWrite{first, second, third} {
+		if err := conn.writer.wait(pw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := conn.writer.wait(bad); err == nil || err.Error() != "write failed" {
+		t.Errorf("expected the write error, got %v", err)
+	}
+
+	if expect := []string{"1", "2", "3"}; !reflect.DeepEqual(pc.packets, expect) {
+		t.Errorf("expected %q, got %q", expect, pc.packets)
+	}
+	if expect := []int{3}; !reflect.DeepEqual(pc.batches, expect) {
+		t.Errorf("expected batches %v, got %v", expect, pc.batches)
+	}
+}
+
+func TestConnWriter_Close(t *testing.T) {
+	conn := newConn(ClientRole, nil, nil, &recordingConn{}, nil)
+	pw := queueRaw(t, conn.writer, context.Background(), nil, "x")
+
+	conn.writer.close()
+	go conn.writer.writeThread()
+	if err := conn.writer.wait(pw); err != ErrConnClosed {
+		t.Errorf("expected %v, got %v", ErrConnClosed, err)
+	}
+	if _, err := conn.writer.queue(context.Background(), nil, true, func(PacketWriter) error { return nil }); err != ErrConnClosed {
+		t.Errorf("expected %v, got %v", ErrConnClosed, err)
+	}
+}
+
+func TestConnWriter_Priority(t *testing.T) {
+	conn := newConn(ClientRole, nil, nil, &recordingConn{}, nil)
+	defer conn.writer.close()
+
+	ctx := context.Background()
+	bulk := &Call{}
+	urgent := &Call{priority: 8 * DefaultPriority}
+	for i := 0; i < 10; i++ {
+		queueRaw(t, conn.writer, ctx, bulk, "bulk")
+		queueRaw(t, conn.writer, ctx, urgent, "urgent")
+	}
+
+	// With equal frame sizes, the urgent call gets eight frames for every
+	// frame of the bulk call.
+	var urgentCount int
+	for i := 0; i < 9; i++ {
+		batch := conn.writer.next(nil)
+		if len(batch) != 1 {
+			t.Fatalf("expected 1 frame, got %d", len(batch))
+		}
+		if batch[0].call == urgent {
+			urgentCount++
+		}
+	}
+	if urgentCount != 8 {
+		t.Errorf("expected 8 urgent frames out of 9, got %d", urgentCount)
+	}
+}
+
+func TestConnWriter_IdleLane(t *testing.T) {
+	conn := newConn(ClientRole, nil, nil, &recordingConn{}, nil)
+	defer conn.writer.close()
+
+	ctx := context.Background()
+	a, b := &Call{}, &Call{}
+
+	// A lane that drains keeps its pass, so that a call with one frame in
+	// flight at a time is still charged for what it has sent.
+	for i := 0; i < 3; i++ {
+		queueRaw(t, conn.writer, ctx, a, "aaaaaaaaaaaaaaaa")
+		conn.writer.next(nil)
+	}
+	queueRaw(t, conn.writer, ctx, a, "aaaaaaaaaaaaaaaa")
+	queueRaw(t, conn.writer, ctx, b, "b")
+	if batch := conn.writer.next(nil); batch[0].call != b {
+		t.Errorf("expected the frame of the idle call first, got %q", batch[0].raw)
+	}
+}
+
+func TestConnWriter_HugePriority(t *testing.T) {
+	conn := newConn(ClientRole, nil, nil, &recordingConn{}, nil)
+	defer conn.writer.close()
+
+	// A priority from the peer is lowered to MaxPriority, and the pass of its
+	// lane still advances, so the default lane gets one frame in every 65.
+	ctx := context.Background()
+	greedy := &Call{priority: math.MaxUint32}
+	normal := &Call{}
+	for i := 0; i < 200; i++ {
+		queueRaw(t, conn.writer, ctx, greedy, "x")
+		queueRaw(t, conn.writer, ctx, normal, "x")
+	}
+
+	var normalCount int
+	for i := 0; i < 130; i++ {
+		if batch := conn.writer.next(nil); batch[0].call == normal {
+			normalCount++
+		}
+	}
+	if normalCount != 2 {
+		t.Errorf("expected 2 default frames out of 130, got %d", normalCount)
+	}
+}